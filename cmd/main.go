@@ -12,6 +12,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"net"
 	"net/http"
@@ -124,6 +125,7 @@ func serverSetup(options *Options, logger *zap.Logger) {
 		logger.Fatal("Failed in initialize s3 COS driver", zap.Error(err))
 		os.Exit(1)
 	}
+	http.HandleFunc("/readyz", readinessHandler(S3CSIDriver.Readiness, logger))
 	serveMetrics(options.ServerMode, options.MetricsAddress, logger)
 	S3CSIDriver.Run()
 }
@@ -164,6 +166,20 @@ func serveMetrics(mode, metricsAddress string, logger *zap.Logger) {
 	libMetrics.RegisterAll()
 }
 
+// readinessHandler answers 200 if the driver is ready and 503 with the reasons it is not otherwise
+func readinessHandler(readiness func(context.Context) []string, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if reasons := readiness(r.Context()); len(reasons) > 0 {
+			http.Error(w, "not ready:\n"+strings.Join(reasons, "\n"), http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("ok")); err != nil {
+			logger.Warn("failed to write data")
+		}
+	}
+}
+
 func checkCosCsiMounterSocketHealth() error {
 	socketPath := os.Getenv(constants.COSCSIMounterSocketPathEnv)
 	if socketPath == "" {
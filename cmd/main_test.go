@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
@@ -92,3 +94,20 @@ func TestCheckCosCsiMounterSocketHealth_Negative(t *testing.T) {
 	err := checkCosCsiMounterSocketHealth()
 	assert.Error(t, err)
 }
+
+func TestReadinessHandler(t *testing.T) {
+	logger := getZapLogger()
+	var reasons []string
+	handler := readinessHandler(func(context.Context) []string { return reasons }, logger)
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ok", w.Body.String())
+
+	reasons = []string{"cos-csi-mounter does not respond", "endpoint https://iam.cloud.ibm.com does not resolve"}
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "cos-csi-mounter does not respond\nendpoint https://iam.cloud.ibm.com does not resolve")
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mountpolicy"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	configFile = flag.String("config", "", "YAML configuration file of the service, reloaded on SIGHUP. The flags below take precedence over it")
+
+	kubeletRootDirsFlag   = flag.String("kubelet-root-dirs", "", "Comma separated root directories of kubelet, buckets can only be mounted in their pods directory")
+	mounterConfigDirFlag  = flag.String("mounter-config-dir", "", "Directory the node server writes the mounter credentials to")
+	socketPathFlag        = flag.String("socket-path", "", "Path of the unix socket of the mounter API")
+	mountersFlag          = flag.String("mounters", "", "Comma separated mounters requests may use")
+	logLevelFlag          = flag.String("log-level", "", "Log level: debug, info, warn or error")
+	readHeaderTimeoutFlag = flag.Duration("read-header-timeout", 0, "Time allowed to read the headers of a request")
+
+	// allMounters are the mounters the service knows
+	allMounters = []string{constants.S3FS, constants.RClone, constants.MountpointS3, constants.Native}
+
+	logLevel      = zap.NewAtomicLevel()
+	currentConfig atomic.Pointer[Config]
+)
+
+func init() {
+	currentConfig.Store(defaultConfig())
+}
+
+// Config is the configuration of the service
+type Config struct {
+	// KubeletRootDirs are the root directories of kubelet, i.e. its --root-dir on the nodes. Buckets can only be
+	// mounted in their pods directory.
+	KubeletRootDirs []string `json:"kubeletRootDirs"`
+	// ConfigDir is the directory the node server writes the mounter credentials to, the configuration files passed
+	// to the mounters must be in it
+	ConfigDir string `json:"configDir"`
+	// CacheRoot is the directory of the cache directories of the volumes, the cache and temporary directories
+	// passed to the mounters must be below it
+	CacheRoot string `json:"cacheRoot"`
+	// SocketPath is the unix socket of the mounter API
+	SocketPath string `json:"socketPath"`
+	// Mounters are the mounters requests may use
+	Mounters []string `json:"mounters"`
+	// OptionPolicy restricts the mounter arguments of the requests, including the options of add-mount-param.
+	// The namespaces of its rules are unknown to the service, only the rules without namespaces apply.
+	OptionPolicy *mountpolicy.Policy `json:"optionPolicy,omitempty"`
+	// LogLevel is debug, info, warn or error
+	LogLevel string `json:"logLevel"`
+	// ReadHeaderTimeout is the time allowed to read the headers of a request
+	ReadHeaderTimeout metav1.Duration `json:"readHeaderTimeout"`
+	// MaxOperationWait is the longest wait of a poll of an operation
+	MaxOperationWait metav1.Duration `json:"maxOperationWait"`
+	// ShutdownTimeout bounds the wait for the requests and operations in progress when the service stops
+	ShutdownTimeout metav1.Duration `json:"shutdownTimeout"`
+	// UnmountTimeout bounds an unmount, including the wait for the mounter process to exit, the process is killed
+	// when it runs out
+	UnmountTimeout metav1.Duration `json:"unmountTimeout"`
+	// StateFile keeps the mounts done by the service across restarts
+	StateFile string `json:"stateFile"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		KubeletRootDirs:   []string{"/var/data/kubelet", "/var/lib/kubelet"},
+		ConfigDir:         constants.MounterConfigPathOnHost,
+		CacheRoot:         constants.DefaultCacheRoot,
+		SocketPath:        filepath.Join(constants.SocketDir, constants.SocketFile),
+		Mounters:          slices.Clone(allMounters),
+		LogLevel:          "info",
+		ReadHeaderTimeout: metav1.Duration{Duration: 3 * time.Second},
+		MaxOperationWait:  metav1.Duration{Duration: time.Minute},
+		ShutdownTimeout:   metav1.Duration{Duration: time.Minute},
+		UnmountTimeout:    metav1.Duration{Duration: time.Minute},
+		StateFile:         "/var/lib/cos-csi-mounter/mounts.json",
+	}
+}
+
+// config returns the configuration in effect
+func config() *Config {
+	return currentConfig.Load()
+}
+
+// MountDirs are the directories buckets can be mounted in
+func (c *Config) MountDirs() []string {
+	dirs := make([]string, 0, len(c.KubeletRootDirs))
+	for _, dir := range c.KubeletRootDirs {
+		dirs = append(dirs, filepath.Join(dir, "pods"))
+	}
+	return dirs
+}
+
+// MounterAllowed tells whether requests may use mounter
+func (c *Config) MounterAllowed(mounter string) bool {
+	return slices.Contains(c.Mounters, mounter)
+}
+
+// CheckOptions applies the option policy to the arguments args of a request with mounter, it returns a
+// *mountpolicy.Violation naming the first argument the policy rejects
+func (c *Config) CheckOptions(mounter string, args json.RawMessage) error {
+	if c.OptionPolicy == nil || len(args) == 0 {
+		return nil
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(args, &raw); err != nil {
+		return err
+	}
+	options := make(map[string]string, len(raw))
+	for name, value := range raw {
+		var str string
+		if err := json.Unmarshal(value, &str); err != nil {
+			str = string(value)
+		}
+		options[name] = str
+	}
+	if param, ok := options["add-mount-param"]; ok {
+		for name, value := range mountpolicy.ParseOptions(param) {
+			options[name] = value
+		}
+	}
+	return c.OptionPolicy.Check(mounter, "", options)
+}
+
+func (c *Config) validate() error {
+	if len(c.KubeletRootDirs) == 0 {
+		return fmt.Errorf("no kubelet root directory")
+	}
+	for _, dir := range c.KubeletRootDirs {
+		if !filepath.IsAbs(dir) {
+			return fmt.Errorf("kubelet root directory %q is not absolute", dir)
+		}
+	}
+	if !filepath.IsAbs(c.ConfigDir) {
+		return fmt.Errorf("config directory %q is not absolute", c.ConfigDir)
+	}
+	if !filepath.IsAbs(c.CacheRoot) {
+		return fmt.Errorf("cache root %q is not absolute", c.CacheRoot)
+	}
+	if !filepath.IsAbs(c.SocketPath) {
+		return fmt.Errorf("socket path %q is not absolute", c.SocketPath)
+	}
+	if !filepath.IsAbs(c.StateFile) {
+		return fmt.Errorf("state file %q is not absolute", c.StateFile)
+	}
+	for _, mounter := range c.Mounters {
+		if !slices.Contains(allMounters, mounter) {
+			return fmt.Errorf("unknown mounter %q", mounter)
+		}
+	}
+	if c.OptionPolicy != nil {
+		for _, rule := range c.OptionPolicy.Rules {
+			for _, mounter := range rule.Mounters {
+				if !slices.Contains(allMounters, mounter) {
+					return fmt.Errorf("option policy of unknown mounter %q", mounter)
+				}
+			}
+		}
+		if err := c.OptionPolicy.Compile(); err != nil {
+			return fmt.Errorf("invalid option policy: %v", err)
+		}
+	}
+	if _, err := zapcore.ParseLevel(c.LogLevel); err != nil {
+		return err
+	}
+	if c.ReadHeaderTimeout.Duration <= 0 || c.MaxOperationWait.Duration <= 0 || c.ShutdownTimeout.Duration <= 0 ||
+		c.UnmountTimeout.Duration <= 0 {
+		return fmt.Errorf("timeouts must be positive")
+	}
+	return nil
+}
+
+// loadConfig reads the configuration from file, if any, and applies the flags set on the command line
+func loadConfig(file string, setFlags map[string]bool) (*Config, error) {
+	cfg := defaultConfig()
+	if file != "" {
+		data, err := os.ReadFile(file) // #nosec G304: file given by the administrator
+		if err != nil {
+			return nil, err
+		}
+		if err = yaml.UnmarshalStrict(data, cfg); err != nil {
+			return nil, fmt.Errorf("invalid configuration file %s: %v", file, err)
+		}
+	}
+
+	if setFlags["kubelet-root-dirs"] {
+		cfg.KubeletRootDirs = splitList(*kubeletRootDirsFlag)
+	}
+	if setFlags["mounter-config-dir"] {
+		cfg.ConfigDir = *mounterConfigDirFlag
+	}
+	if setFlags["socket-path"] {
+		cfg.SocketPath = *socketPathFlag
+	}
+	if setFlags["mounters"] {
+		cfg.Mounters = splitList(*mountersFlag)
+	}
+	if setFlags["log-level"] {
+		cfg.LogLevel = *logLevelFlag
+	}
+	if setFlags["read-header-timeout"] {
+		cfg.ReadHeaderTimeout.Duration = *readHeaderTimeoutFlag
+	}
+
+	cfg.ConfigDir = filepath.Clean(cfg.ConfigDir)
+	for i, dir := range cfg.KubeletRootDirs {
+		cfg.KubeletRootDirs[i] = filepath.Clean(dir)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %v", err)
+	}
+	return cfg, nil
+}
+
+// applyConfig puts cfg in effect
+func applyConfig(cfg *Config) {
+	level, _ := zapcore.ParseLevel(cfg.LogLevel) // validated when loaded
+	logLevel.SetLevel(level)
+	currentConfig.Store(cfg)
+}
+
+// reloadConfig loads the configuration again and puts it in effect. Settings read at startup keep their value
+// until the service is restarted, the configuration in effect is kept if the new one is invalid.
+func reloadConfig(file string, setFlags map[string]bool) error {
+	cfg, err := loadConfig(file, setFlags)
+	if err != nil {
+		return err
+	}
+	current := config()
+	if cfg.SocketPath != current.SocketPath || cfg.ConfigDir != current.ConfigDir ||
+		cfg.ReadHeaderTimeout != current.ReadHeaderTimeout {
+		logger.Warn("The socket path, config directory and read header timeout only change on restart")
+		cfg.SocketPath, cfg.ConfigDir, cfg.ReadHeaderTimeout = current.SocketPath, current.ConfigDir, current.ReadHeaderTimeout
+	}
+	applyConfig(cfg)
+	logger.Info("Reloaded configuration", zap.Any("config", cfg))
+	return nil
+}
+
+// handleReloads reloads the configuration on SIGHUP
+func handleReloads(file string, setFlags map[string]bool) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		for range signals {
+			if err := reloadConfig(file, setFlags); err != nil {
+				logger.Error("Keeping the configuration in effect, cannot reload it", zap.String("file", file), zap.Error(err))
+			}
+		}
+	}()
+}
+
+// setFlags returns the names of the flags set on the command line
+func setFlags() map[string]bool {
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	return set
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mountpolicy"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func useConfig(t *testing.T, update func(*Config)) *Config {
+	original := config()
+	t.Cleanup(func() { applyConfig(original) })
+	cfg := defaultConfig()
+	update(cfg)
+	applyConfig(cfg)
+	return cfg
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte(content), 0600))
+	return file
+}
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	cfg, err := loadConfig("", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, defaultConfig(), cfg)
+	assert.Equal(t, []string{"/var/data/kubelet/pods", "/var/lib/kubelet/pods"}, cfg.MountDirs())
+	assert.True(t, cfg.MounterAllowed(constants.Native))
+}
+
+func TestLoadConfig_FileAndFlags(t *testing.T) {
+	file := writeConfigFile(t, `
+kubeletRootDirs: [/data/kubelet/]
+configDir: /run/coscsi-config
+mounters: [s3fs, rclone]
+optionPolicy:
+  rules:
+  - mounters: [s3fs]
+    deny: [url]
+logLevel: debug
+readHeaderTimeout: 5s
+`)
+	origMounters, origLevel := *mountersFlag, *logLevelFlag
+	t.Cleanup(func() { *mountersFlag, *logLevelFlag = origMounters, origLevel })
+	*mountersFlag = "s3fs"
+	*logLevelFlag = "warn"
+
+	cfg, err := loadConfig(file, map[string]bool{"mounters": true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/data/kubelet/pods"}, cfg.MountDirs())
+	assert.Equal(t, "/run/coscsi-config", cfg.ConfigDir)
+	assert.Equal(t, []string{constants.S3FS}, cfg.Mounters)
+	// flags that are not set do not override the file
+	assert.Equal(t, "debug", cfg.LogLevel)
+	assert.Equal(t, 5*time.Second, cfg.ReadHeaderTimeout.Duration)
+	assert.Equal(t, time.Minute, cfg.MaxOperationWait.Duration)
+
+	assert.ErrorContains(t, cfg.CheckOptions(constants.S3FS, json.RawMessage(`{"url":"https://evil","retries":"5"}`)), `"url"`)
+	assert.NoError(t, cfg.CheckOptions(constants.RClone, json.RawMessage(`{"url":"https://s3.test"}`)))
+}
+
+func TestCheckOptions(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.OptionPolicy = &mountpolicy.Policy{Rules: []mountpolicy.Rule{
+		{Mounters: []string{constants.S3FS}, Deny: []string{"allow_other"},
+			Values: map[string]string{"passwd_file": `/var/lib/coscsi-config/[0-9a-f]+/\.passwd-s3fs`, "retries": `[0-9]+`}},
+		{Namespaces: []string{"team-a"}, Deny: []string{"retries"}},
+	}}
+	assert.NoError(t, cfg.validate())
+
+	assert.NoError(t, cfg.CheckOptions(constants.S3FS, json.RawMessage(`{"passwd_file":"/var/lib/coscsi-config/ab12/.passwd-s3fs","retries":5}`)))
+	assert.ErrorContains(t, cfg.CheckOptions(constants.S3FS, json.RawMessage(`{"passwd_file":"/etc/shadow"}`)), `"passwd_file"`)
+	assert.ErrorContains(t, cfg.CheckOptions(constants.S3FS, json.RawMessage(`{"retries":"5x"}`)), `"retries"`)
+	assert.ErrorContains(t, cfg.CheckOptions(constants.S3FS, json.RawMessage(`{"add-mount-param":"max_stat_cache_size=1000,allow_other"}`)), `"allow_other"`)
+	assert.NoError(t, cfg.CheckOptions(constants.RClone, json.RawMessage(`{"allow_other":"true"}`)))
+	assert.Error(t, cfg.CheckOptions(constants.S3FS, json.RawMessage(`["retries"]`)))
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	testCases := map[string]string{
+		"unknown field":    "socket: /tmp/coscsi.sock",
+		"relative root":    "kubeletRootDirs: [kubelet]",
+		"unknown mounter":  "mounters: [goofys]",
+		"bad log level":    "logLevel: loud",
+		"negative timeout": "maxOperationWait: -1s",
+		"zero unmount":     "unmountTimeout: 0s",
+		"policy mounter":   "optionPolicy:\n  rules:\n  - mounters: [goofys]",
+		"policy value":     "optionPolicy:\n  rules:\n  - values: {retries: \"[\"}",
+	}
+	for name, content := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := loadConfig(writeConfigFile(t, content), nil)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := loadConfig(filepath.Join(t.TempDir(), "missing.yaml"), nil)
+	assert.Error(t, err)
+}
+
+func TestReloadConfig(t *testing.T) {
+	useConfig(t, func(*Config) {})
+	file := writeConfigFile(t, "logLevel: debug\nsocketPath: /run/other.sock\nmounters: [rclone]\n")
+
+	assert.NoError(t, reloadConfig(file, nil))
+	assert.Equal(t, []string{constants.RClone}, config().Mounters)
+	assert.Equal(t, zapcore.DebugLevel, logLevel.Level())
+	// the socket is only moved on restart
+	assert.Equal(t, defaultConfig().SocketPath, config().SocketPath)
+
+	// an invalid configuration keeps the one in effect
+	assert.NoError(t, os.WriteFile(file, []byte("mounters: [goofys]"), 0600))
+	assert.Error(t, reloadConfig(file, nil))
+	assert.Equal(t, []string{constants.RClone}, config().Mounters)
+}
+
+func TestHandleCosMount_ConfigPolicy(t *testing.T) {
+	useConfig(t, func(c *Config) {
+		c.Mounters = []string{constants.S3FS}
+		c.OptionPolicy = &mountpolicy.Policy{Rules: []mountpolicy.Rule{{Deny: []string{"passwd_file"}}}}
+	})
+	router := gin.Default()
+	router.POST("/mount", handleCosMount(new(MockMounterUtils), &DefaultMounterArgsParser{}))
+
+	mount := func(request MountRequest) (int, mounterapi.Response) {
+		body, _ := json.Marshal(request)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/mount", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		var response mounterapi.Response
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response
+	}
+
+	status, response := mount(MountRequest{Path: testMountPath, Bucket: testBucket, Mounter: constants.RClone, Args: json.RawMessage(`{}`)})
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Equal(t, mounterapi.CodeForbidden, response.Code)
+
+	status, response = mount(MountRequest{Path: testMountPath, Bucket: testBucket, Mounter: constants.S3FS,
+		Args: json.RawMessage(`{"passwd_file":"/etc/shadow"}`)})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, mounterapi.CodeInvalidArgs, response.Code)
+	assert.Contains(t, response.Error, "passwd_file")
+}
+
+func TestPathValidator_KubeletRootDirs(t *testing.T) {
+	useConfig(t, func(c *Config) { c.KubeletRootDirs = []string{"/data/kubelet"} })
+
+	assert.NoError(t, pathValidator("/data/kubelet/pods/uid/volumes/kubernetes.io~csi/pv/mount"))
+	assert.Error(t, pathValidator(testMountPath))
+}
+
+func TestLoadConfig_InstalledFile(t *testing.T) {
+	cfg, err := loadConfig("../install/cos-csi-mounter.yaml", map[string]bool{})
+	assert.NoError(t, err)
+	assert.Equal(t, defaultConfig(), cfg)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
+	"go.uber.org/zap"
+	k8sMountUtils "k8s.io/mount-utils"
+)
+
+const defaultConfigTmpfsSize = "16m"
+
+var (
+	// credentialsRoot is the directory the node server writes the credentials of each mount to, in a
+	// subdirectory named after the hash of the target path. It is the ConfigDir of the configuration at startup.
+	credentialsRoot = constants.MounterConfigPathOnHost
+
+	mountTmpfs = func(dir, size string) error {
+		return syscall.Mount("tmpfs", dir, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV|syscall.MS_NOEXEC, "mode=0700,size="+size)
+	}
+	listMountInfo = func() ([]k8sMountUtils.MountInfo, error) {
+		return k8sMountUtils.ParseMountInfo("/proc/self/mountinfo")
+	}
+)
+
+// credentialsDir returns the directory of the credentials of the mount of path, as named by the node server
+func credentialsDir(root, path string) string {
+	return filepath.Join(root, fmt.Sprintf("%x", sha256.Sum256([]byte(path))))
+}
+
+// prepareCredentialsRoot wipes the credentials left by an earlier run of the service, except those of paths
+// that are still mounted, and mounts a tmpfs of size on root if tmpfs is set so that credentials never reach
+// the disk. The credentials on disk are wiped before root is replaced by a tmpfs, the mounters read them when
+// they start.
+func prepareCredentialsRoot(root string, tmpfs bool, size string) error {
+	infos, err := listMountInfo()
+	if err != nil {
+		return fmt.Errorf("cannot list mounts: %v", err)
+	}
+	root = filepath.Clean(root)
+	isTmpfs := false
+	for _, info := range infos {
+		if info.MountPoint == root && info.FsType == "tmpfs" {
+			isTmpfs = true
+		}
+	}
+	fuseMounts, err := fuseMountpoints()
+	if err != nil {
+		return err
+	}
+	mounted := map[string]bool{}
+	for path := range fuseMounts {
+		mounted[credentialsDir(root, path)] = true
+	}
+
+	if err := MakeDir(root, 0700); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return err
+	}
+	keepMounted := isTmpfs || !tmpfs
+	for _, entry := range entries {
+		dir := filepath.Join(root, entry.Name())
+		if keepMounted && mounted[dir] {
+			continue
+		}
+		logger.Info("Wiping stale mounter credentials", zap.String("dir", dir))
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("cannot wipe %s: %v", dir, err)
+		}
+	}
+
+	if !tmpfs || isTmpfs {
+		return nil
+	}
+	if err := mountTmpfs(root, size); err != nil {
+		return fmt.Errorf("cannot mount tmpfs on %s: %v", root, err)
+	}
+	logger.Info("Mounted tmpfs for mounter credentials", zap.String("dir", root), zap.String("size", size))
+	return nil
+}
+
+// wipeCredentials removes the credentials of the mount of path, once it is unmounted or failed to mount
+func wipeCredentials(path string) {
+	dir := credentialsDir(credentialsRoot, path)
+	if err := os.RemoveAll(dir); err != nil {
+		logger.Warn("Cannot wipe mounter credentials", zap.String("path", path), zap.String("dir", dir), zap.Error(err))
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	k8sMountUtils "k8s.io/mount-utils"
+)
+
+func stubCredentialMounts(t *testing.T, infos []k8sMountUtils.MountInfo) *[]string {
+	origMount, origList := mountTmpfs, listMountInfo
+	t.Cleanup(func() { mountTmpfs, listMountInfo = origMount, origList })
+
+	var mounted []string
+	mountTmpfs = func(dir, size string) error {
+		mounted = append(mounted, dir+":"+size)
+		return nil
+	}
+	listMountInfo = func() ([]k8sMountUtils.MountInfo, error) { return infos, nil }
+	return &mounted
+}
+
+func writeCredentials(t *testing.T, root, path string) string {
+	dir := credentialsDir(root, path)
+	assert.NoError(t, os.MkdirAll(dir, 0700))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".passwd-s3fs"), []byte("key:secret"), 0600))
+	return dir
+}
+
+func TestPrepareCredentialsRoot_MountsTmpfs(t *testing.T) {
+	root := t.TempDir()
+	mounted := stubCredentialMounts(t, []k8sMountUtils.MountInfo{{MountPoint: testMountPath, FsType: "fuse.s3fs"}})
+	live := writeCredentials(t, root, testMountPath)
+	stale := writeCredentials(t, root, "/var/lib/kubelet/pods/a/mount")
+
+	assert.NoError(t, prepareCredentialsRoot(root, true, "8m"))
+	// everything on disk is wiped before the tmpfs hides it
+	assert.NoDirExists(t, live)
+	assert.NoDirExists(t, stale)
+	assert.Equal(t, []string{root + ":8m"}, *mounted)
+}
+
+func TestPrepareCredentialsRoot_Restart(t *testing.T) {
+	root := t.TempDir()
+	mounted := stubCredentialMounts(t, []k8sMountUtils.MountInfo{
+		{MountPoint: root, FsType: "tmpfs"},
+		{MountPoint: testMountPath, FsType: "fuse.s3fs"},
+	})
+	live := writeCredentials(t, root, testMountPath)
+	stale := writeCredentials(t, root, "/var/lib/kubelet/pods/a/mount")
+
+	assert.NoError(t, prepareCredentialsRoot(root, true, "8m"))
+	assert.DirExists(t, live)
+	assert.NoDirExists(t, stale)
+	assert.Empty(t, *mounted)
+}
+
+func TestPrepareCredentialsRoot_NoTmpfs(t *testing.T) {
+	root := t.TempDir()
+	mounted := stubCredentialMounts(t, []k8sMountUtils.MountInfo{{MountPoint: testMountPath, FsType: "fuse.rclone"}})
+	live := writeCredentials(t, root, testMountPath)
+	stale := writeCredentials(t, root, "/var/lib/kubelet/pods/a/mount")
+
+	assert.NoError(t, prepareCredentialsRoot(root, false, defaultConfigTmpfsSize))
+	assert.DirExists(t, live)
+	assert.NoDirExists(t, stale)
+	assert.Empty(t, *mounted)
+}
+
+func TestPrepareCredentialsRoot_MountFailure(t *testing.T) {
+	stubCredentialMounts(t, nil)
+	mountTmpfs = func(string, string) error { return errors.New("operation not permitted") }
+
+	err := prepareCredentialsRoot(t.TempDir(), true, defaultConfigTmpfsSize)
+	assert.ErrorContains(t, err, "operation not permitted")
+}
+
+func TestDoUnmount_WipesCredentials(t *testing.T) {
+	origRoot := credentialsRoot
+	t.Cleanup(func() { credentialsRoot = origRoot })
+	credentialsRoot = t.TempDir()
+	dir := writeCredentials(t, credentialsRoot, testMountPath)
+
+	mockMounter := new(MockMounterUtils)
+	mockMounter.On("FuseUnmountWithContext", mock.Anything, testMountPath, 0).Return(errors.New("device or resource busy")).Once()
+	mockMounter.On("FuseUnmountWithContext", mock.Anything, testMountPath, 0).Return(nil).Once()
+
+	assert.NotNil(t, doUnmount(context.Background(), mockMounter, testMountPath, 0))
+	assert.DirExists(t, dir)
+	assert.Nil(t, doUnmount(context.Background(), mockMounter, testMountPath, 0))
+	assert.NoDirExists(t, dir)
+}
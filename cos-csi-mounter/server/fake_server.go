@@ -4,11 +4,13 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"net"
 	"net/http"
 	"time"
 
+	mounterUtils "github.com/IBM/ibm-object-csi-driver/pkg/mounter/utils"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -22,11 +24,27 @@ func (m *MockMounterUtils) FuseMount(path string, mounter string, args []string)
 	return argsCalled.Error(0)
 }
 
+func (m *MockMounterUtils) FuseMountWithOptions(path string, mounter string, args []string, opts mounterUtils.MountOptions) error {
+	argsCalled := m.Called(path, mounter, args, opts)
+	return argsCalled.Error(0)
+}
+
 func (m *MockMounterUtils) FuseUnmount(path string) error {
 	argsCalled := m.Called(path)
 	return argsCalled.Error(0)
 }
 
+func (m *MockMounterUtils) FuseUnmountWithContext(ctx context.Context, path string, pid int) error {
+	argsCalled := m.Called(ctx, path, pid)
+	return argsCalled.Error(0)
+}
+
+func (m *MockMounterUtils) GetMountInfo(path string) (*mounterUtils.MountInfo, error) {
+	argsCalled := m.Called(path)
+	info, _ := argsCalled.Get(0).(*mounterUtils.MountInfo)
+	return info, argsCalled.Error(1)
+}
+
 type fakeListener struct{}
 
 func (d *fakeListener) Accept() (net.Conn, error) {
@@ -44,7 +62,7 @@ func fakeRouter() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
 }
 
-func fakeHandleSignals() {}
+func fakeHandleSignals(*http.Server, context.CancelFunc, chan<- struct{}) {}
 
 func fakeSetupSocketFail() (net.Listener, error) {
 	return nil, errors.New("socket creation error")
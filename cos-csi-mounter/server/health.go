@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+var (
+	lookPath   = exec.LookPath
+	fuseDevice = "/dev/fuse"
+
+	// requiredBinaries are the commands the mounts need on the host, any of the alternatives of an entry will do
+	requiredBinaries = [][]string{{constants.S3FS}, {constants.RClone}, {"fusermount3", "fusermount"}}
+)
+
+// handleHealth answers whether the service can mount, the node plugin reports it in its probe
+func handleHealth(c *gin.Context) {
+	reasons := checkHealth()
+	if len(reasons) > 0 {
+		logger.Warn("cos-csi-mounter is not healthy", zap.Strings("reasons", reasons))
+		c.JSON(http.StatusServiceUnavailable, mounterapi.Health{Reasons: reasons})
+		return
+	}
+	c.JSON(http.StatusOK, mounterapi.Health{Ready: true})
+}
+
+// checkHealth returns why mounts would fail on this host
+func checkHealth() []string {
+	var reasons []string
+	for _, alternatives := range requiredBinaries {
+		if !anyInPath(alternatives) {
+			reasons = append(reasons, fmt.Sprintf("%s not found in PATH", strings.Join(alternatives, " or ")))
+		}
+	}
+	if _, err := os.Stat(fuseDevice); err != nil {
+		reasons = append(reasons, fmt.Sprintf("FUSE device is not available: %v", err))
+	}
+	return reasons
+}
+
+func anyInPath(commands []string) bool {
+	for _, command := range commands {
+		if _, err := lookPath(command); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func stubHealthChecks(t *testing.T, binaries []string, device string) {
+	origLookPath, origDevice := lookPath, fuseDevice
+	t.Cleanup(func() { lookPath, fuseDevice = origLookPath, origDevice })
+	lookPath = func(file string) (string, error) {
+		for _, binary := range binaries {
+			if binary == file {
+				return "/usr/bin/" + file, nil
+			}
+		}
+		return "", errors.New("executable file not found in $PATH")
+	}
+	fuseDevice = device
+}
+
+func TestHandleHealth(t *testing.T) {
+	device := filepath.Join(t.TempDir(), "fuse")
+	assert.NoError(t, os.WriteFile(device, nil, 0600))
+	router := gin.New()
+	router.GET(mounterapi.HealthPath, handleHealth)
+	get := func() (int, mounterapi.Health) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, mounterapi.HealthPath, nil)
+		router.ServeHTTP(w, req)
+		var health mounterapi.Health
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &health))
+		return w.Code, health
+	}
+
+	stubHealthChecks(t, []string{"s3fs", "rclone", "fusermount"}, device)
+	code, health := get()
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, health.Ready)
+
+	stubHealthChecks(t, []string{"s3fs"}, filepath.Join(t.TempDir(), "missing"))
+	code, health = get()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.False(t, health.Ready)
+	assert.Len(t, health.Reasons, 3)
+	assert.Equal(t, "rclone not found in PATH", health.Reasons[0])
+	assert.Equal(t, "fusermount3 or fusermount not found in PATH", health.Reasons[1])
+	assert.Contains(t, health.Reasons[2], "FUSE device is not available")
+}
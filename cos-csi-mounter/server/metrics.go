@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/procfs"
+	"go.uber.org/zap"
+)
+
+const metricsNamespace = "cos_csi_mounter"
+
+// MounterMetrics holds the Prometheus instrumentation of the service
+type MounterMetrics struct {
+	requests           *prometheus.CounterVec
+	requestDuration    *prometheus.HistogramVec
+	mountFailures      *prometheus.CounterVec
+	unmountEscalations *prometheus.CounterVec
+}
+
+var (
+	metricsRegistry = prometheus.NewRegistry()
+	mounterMetrics  = NewMounterMetrics(metricsRegistry, mountRegistry)
+
+	// readProcessStats returns the resident memory in bytes and the CPU time in seconds used by a process
+	readProcessStats = func(pid int) (float64, float64, error) {
+		proc, err := procfs.NewProc(pid)
+		if err != nil {
+			return 0, 0, err
+		}
+		stat, err := proc.Stat()
+		if err != nil {
+			return 0, 0, err
+		}
+		return float64(stat.ResidentMemory()), stat.CPUTime(), nil
+	}
+)
+
+// NewMounterMetrics creates the metrics of the service and registers them with reg, if reg is not nil.
+// Active mounts and the resource usage of their processes are read from registry when metrics are scraped.
+func NewMounterMetrics(reg prometheus.Registerer, registry *MountRegistry) *MounterMetrics {
+	m := &MounterMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "requests_total",
+			Help:      "Number of mount and unmount requests by operation, mounter and result.",
+		}, []string{"operation", "mounter", "result"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of mount and unmount requests by operation, mounter and result.",
+			Buckets:   prometheus.ExponentialBuckets(0.05, 2, 12),
+		}, []string{"operation", "mounter", "result"}),
+		mountFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "mount_failures_total",
+			Help:      "Number of failed mount requests by mounter and reason.",
+		}, []string{"mounter", "reason"}),
+		unmountEscalations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "unmount_attempts_total",
+			Help:      "Number of unmount attempts by method (standard, lazy, force, abort) and of signals sent to the mounter process (sigterm, sigkill) by result.",
+		}, []string{"method", "result"}),
+	}
+	if reg != nil {
+		reg.MustRegister(m.requests, m.requestDuration, m.mountFailures, m.unmountEscalations, &mountCollector{registry: registry},
+			collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	}
+	return m
+}
+
+// mounterLabel bounds the values of the mounter label to the supported mounters
+func mounterLabel(mounter string) string {
+	switch mounter {
+	case constants.S3FS, constants.RClone, constants.MountpointS3, constants.Native:
+		return mounter
+	}
+	return "unknown"
+}
+
+func resultLabel(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
+
+// observeRequest records a request of operation that started at start. A non empty failure reason
+// marks a failed request.
+func (m *MounterMetrics) observeRequest(operation, mounter string, start time.Time, failureReason string) {
+	mounter = mounterLabel(mounter)
+	result := "success"
+	if failureReason != "" {
+		result = "error"
+		if operation == "mount" {
+			m.mountFailures.WithLabelValues(mounter, failureReason).Inc()
+		}
+	}
+	m.requests.WithLabelValues(operation, mounter, result).Inc()
+	m.requestDuration.WithLabelValues(operation, mounter, result).Observe(time.Since(start).Seconds())
+}
+
+// observeUnmountAttempt is set as OnUnmountAttempt of the mounter utils
+func (m *MounterMetrics) observeUnmountAttempt(method string, err error) {
+	m.unmountEscalations.WithLabelValues(method, resultLabel(err)).Inc()
+}
+
+var (
+	activeMountsDesc = prometheus.NewDesc(metricsNamespace+"_active_mounts",
+		"Number of mounts done by the service by mounter.", []string{"mounter"}, nil)
+	mountMemoryDesc = prometheus.NewDesc(metricsNamespace+"_mount_process_resident_memory_bytes",
+		"Resident memory of the process serving a mount.", []string{"path", "bucket", "mounter"}, nil)
+	mountCPUDesc = prometheus.NewDesc(metricsNamespace+"_mount_process_cpu_seconds_total",
+		"User and system CPU time of the process serving a mount.", []string{"path", "bucket", "mounter"}, nil)
+)
+
+// mountCollector reports the mounts of the registry when metrics are scraped
+type mountCollector struct {
+	registry *MountRegistry
+}
+
+func (c *mountCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeMountsDesc
+	ch <- mountMemoryDesc
+	ch <- mountCPUDesc
+}
+
+func (c *mountCollector) Collect(ch chan<- prometheus.Metric) {
+	active := map[string]int{constants.S3FS: 0, constants.RClone: 0, constants.MountpointS3: 0, constants.Native: 0}
+	for _, record := range c.registry.List() {
+		active[mounterLabel(record.Mounter)]++
+		if record.PID <= 0 {
+			continue
+		}
+		rss, cpu, err := readProcessStats(record.PID)
+		if err != nil {
+			logger.Debug("Cannot read process stats of mount", zap.String("path", record.Path), zap.Int("pid", record.PID), zap.Error(err))
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(mountMemoryDesc, prometheus.GaugeValue, rss, record.Path, record.Bucket, record.Mounter)
+		ch <- prometheus.MustNewConstMetric(mountCPUDesc, prometheus.CounterValue, cpu, record.Path, record.Bucket, record.Mounter)
+	}
+	for mounter, count := range active {
+		ch <- prometheus.MustNewConstMetric(activeMountsDesc, prometheus.GaugeValue, float64(count), mounter)
+	}
+}
+
+// serveMetrics serves the metrics of reg on address until the process exits
+func serveMetrics(address string, reg *prometheus.Registry) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	server := &http.Server{Addr: address, Handler: mux, ReadHeaderTimeout: config().ReadHeaderTimeout.Duration}
+	logger.Info("Starting metrics server", zap.String("address", address))
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("metrics server failed", zap.String("address", address), zap.Error(err))
+		}
+	}()
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMounterMetrics_ObserveRequest(t *testing.T) {
+	m := NewMounterMetrics(nil, NewMountRegistry())
+
+	m.observeRequest("mount", constants.S3FS, time.Now(), "")
+	m.observeRequest("mount", "goofys", time.Now(), "invalid_mounter")
+	m.observeRequest("unmount", constants.RClone, time.Now(), "unmount_failed")
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues("mount", constants.S3FS, "success")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues("mount", "unknown", "error")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.mountFailures.WithLabelValues("unknown", "invalid_mounter")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues("unmount", constants.RClone, "error")))
+	// unmount failures are not mount failures
+	assert.Equal(t, 1, testutil.CollectAndCount(m.mountFailures))
+	assert.Equal(t, 3, testutil.CollectAndCount(m.requestDuration))
+}
+
+func TestMounterMetrics_ObserveUnmountAttempt(t *testing.T) {
+	m := NewMounterMetrics(nil, NewMountRegistry())
+
+	m.observeUnmountAttempt("standard", errors.New("device or resource busy"))
+	m.observeUnmountAttempt("lazy", nil)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.unmountEscalations.WithLabelValues("standard", "error")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.unmountEscalations.WithLabelValues("lazy", "success")))
+}
+
+func TestMountCollector(t *testing.T) {
+	stubMountChecks(t, 42, true, nil)
+	origStats := readProcessStats
+	defer func() { readProcessStats = origStats }()
+	readProcessStats = func(pid int) (float64, float64, error) {
+		return 1024, 2.5, nil
+	}
+
+	registry := NewMountRegistry()
+	registry.Add(testMountPath, testBucket, constants.S3FS, nil)
+
+	expected := `
+# HELP cos_csi_mounter_active_mounts Number of mounts done by the service by mounter.
+# TYPE cos_csi_mounter_active_mounts gauge
+cos_csi_mounter_active_mounts{mounter="mountpoint-s3"} 0
+cos_csi_mounter_active_mounts{mounter="native"} 0
+cos_csi_mounter_active_mounts{mounter="rclone"} 0
+cos_csi_mounter_active_mounts{mounter="s3fs"} 1
+# HELP cos_csi_mounter_mount_process_cpu_seconds_total User and system CPU time of the process serving a mount.
+# TYPE cos_csi_mounter_mount_process_cpu_seconds_total counter
+cos_csi_mounter_mount_process_cpu_seconds_total{bucket="testBucket",mounter="s3fs",path="` + testMountPath + `"} 2.5
+# HELP cos_csi_mounter_mount_process_resident_memory_bytes Resident memory of the process serving a mount.
+# TYPE cos_csi_mounter_mount_process_resident_memory_bytes gauge
+cos_csi_mounter_mount_process_resident_memory_bytes{bucket="testBucket",mounter="s3fs",path="` + testMountPath + `"} 1024
+`
+	assert.NoError(t, testutil.CollectAndCompare(&mountCollector{registry: registry}, strings.NewReader(expected)))
+}
+
+func TestNewMounterMetrics_Registry(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	NewMounterMetrics(reg, NewMountRegistry())
+
+	families, err := reg.Gather()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, families)
+}
+
+func TestHandleCosMount_FailureMetrics(t *testing.T) {
+	failures := mounterMetrics.mountFailures.WithLabelValues("unknown", "invalid_request")
+	before := testutil.ToFloat64(failures)
+
+	router := gin.Default()
+	router.POST("/mount", handleCosMount(new(MockMounterUtils), new(MockMounterArgsParser)))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/mount", strings.NewReader("{invalid json"))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, before+1, testutil.ToFloat64(failures))
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+type MountpointS3Args struct {
+	AllowDelete           string `json:"allow-delete,omitempty"`
+	AllowOther            string `json:"allow-other,omitempty"`
+	AllowOverwrite        string `json:"allow-overwrite,omitempty"`
+	AllowRoot             string `json:"allow-root,omitempty"`
+	AutoUnmount           string `json:"auto-unmount,omitempty"`
+	Cache                 string `json:"cache,omitempty"`
+	CredentialsFile       string `json:"credentials-file,omitempty"`
+	Debug                 string `json:"debug,omitempty"`
+	DirMode               string `json:"dir-mode,omitempty"`
+	EndpointURL           string `json:"endpoint-url,omitempty"`
+	FileMode              string `json:"file-mode,omitempty"`
+	ForcePathStyle        string `json:"force-path-style,omitempty"`
+	GID                   string `json:"gid,omitempty"`
+	MaxCacheSize          string `json:"max-cache-size,omitempty"`
+	MaxThreads            string `json:"max-threads,omitempty"`
+	MaximumThroughputGbps string `json:"maximum-throughput-gbps,omitempty"`
+	MetadataTTL           string `json:"metadata-ttl,omitempty"`
+	PartSize              string `json:"part-size,omitempty"`
+	Prefix                string `json:"prefix,omitempty"`
+	ReadOnly              string `json:"read-only,omitempty"`
+	Region                string `json:"region,omitempty"`
+	UID                   string `json:"uid,omitempty"`
+	UploadChecksums       string `json:"upload-checksums,omitempty"`
+}
+
+func (args MountpointS3Args) PopulateArgsSlice(bucket, targetPath string) ([]string, error) {
+	// Marshal to JSON
+	raw, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal into map[string]string
+	var m map[string]string
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+
+	// credentials are passed to mount-s3 through the environment, see MountRequest.MounterEnv
+	delete(m, "credentials-file")
+
+	// Convert to flag slice, mount-s3 boolean flags do not accept a value
+	result := []string{bucket, targetPath}
+	for k, v := range m {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true":
+			result = append(result, "--"+k) // --key
+		case "false":
+			continue
+		default:
+			result = append(result, fmt.Sprintf("--%s=%v", k, v)) // --key=value
+		}
+	}
+
+	return result, nil // [bucket, path, --key1, --key2=value2, ...]
+}
+
+func (args MountpointS3Args) Validate(targetPath string) error {
+	if err := pathValidator(targetPath); err != nil {
+		return err
+	}
+
+	boolArgs := map[string]string{
+		"allow-delete":     args.AllowDelete,
+		"allow-other":      args.AllowOther,
+		"allow-overwrite":  args.AllowOverwrite,
+		"allow-root":       args.AllowRoot,
+		"auto-unmount":     args.AutoUnmount,
+		"debug":            args.Debug,
+		"force-path-style": args.ForcePathStyle,
+		"read-only":        args.ReadOnly,
+	}
+	for name, val := range boolArgs {
+		if val != "" && !isBoolString(val) {
+			logger.Error("cannot convert value of "+name+" into boolean", zap.Any(name, val))
+			return fmt.Errorf("cannot convert value of %s into boolean: %v", name, val)
+		}
+	}
+
+	intArgs := map[string]string{
+		"gid":            args.GID,
+		"max-cache-size": args.MaxCacheSize,
+		"max-threads":    args.MaxThreads,
+		"part-size":      args.PartSize,
+		"uid":            args.UID,
+	}
+	for name, val := range intArgs {
+		if val == "" {
+			continue
+		}
+		if _, err := strconv.ParseUint(val, 10, 64); err != nil {
+			logger.Error("cannot convert value of "+name+" into integer", zap.Error(err))
+			return fmt.Errorf("cannot convert value of %s into integer: %v", name, err)
+		}
+	}
+
+	modeArgs := map[string]string{
+		"dir-mode":  args.DirMode,
+		"file-mode": args.FileMode,
+	}
+	for name, val := range modeArgs {
+		if val == "" {
+			continue
+		}
+		if _, err := strconv.ParseUint(val, 8, 32); err != nil {
+			logger.Error("cannot convert value of "+name+" into octal mode", zap.Error(err))
+			return fmt.Errorf("cannot convert value of %s into octal mode: %v", name, err)
+		}
+	}
+
+	if err := cacheDirValidator("cache", args.Cache); err != nil {
+		return err
+	}
+
+	if args.MaximumThroughputGbps != "" {
+		if _, err := strconv.ParseFloat(args.MaximumThroughputGbps, 64); err != nil {
+			logger.Error("cannot convert value of maximum-throughput-gbps into number", zap.Error(err))
+			return fmt.Errorf("cannot convert value of maximum-throughput-gbps into number: %v", err)
+		}
+	}
+
+	// metadata-ttl is either a number of seconds or one of the keywords understood by mount-s3
+	if args.MetadataTTL != "" && args.MetadataTTL != "indefinite" && args.MetadataTTL != "minimal" {
+		if _, err := strconv.ParseUint(args.MetadataTTL, 10, 64); err != nil {
+			logger.Error("invalid value for metadata-ttl", zap.Any("metadata-ttl", args.MetadataTTL))
+			return fmt.Errorf("invalid value for metadata-ttl: %v", args.MetadataTTL)
+		}
+	}
+
+	if args.UploadChecksums != "" && args.UploadChecksums != "crc32c" && args.UploadChecksums != "off" {
+		logger.Error("invalid value for upload-checksums", zap.Any("upload-checksums", args.UploadChecksums))
+		return fmt.Errorf("invalid value for upload-checksums: %v", args.UploadChecksums)
+	}
+
+	if args.Prefix != "" && !strings.HasSuffix(args.Prefix, "/") {
+		logger.Error("prefix must end with '/'", zap.Any("prefix", args.Prefix))
+		return fmt.Errorf("prefix must end with '/': %v", args.Prefix)
+	}
+
+	if args.EndpointURL != "" {
+		if u, err := url.Parse(args.EndpointURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			logger.Error("bad value for endpoint-url", zap.Any("endpoint-url", args.EndpointURL))
+			return fmt.Errorf("bad value for endpoint-url \"%v\": must be an absolute http(s) url", args.EndpointURL)
+		}
+	}
+
+	// Check if credentials file exists or not
+	if exists, err := FileExists(args.CredentialsFile); err != nil {
+		logger.Error("error checking credentials file existence")
+		return fmt.Errorf("error checking credentials file existence")
+	} else if !exists {
+		logger.Error("credentials file not found")
+		return fmt.Errorf("credentials file not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMountpointS3PopulateArgsSlice_Success(t *testing.T) {
+	args := MountpointS3Args{
+		AllowOther:      "true",
+		AllowDelete:     "false",
+		CredentialsFile: "/var/lib/coscsi-config/abc/credentials",
+		MaxThreads:      "16",
+	}
+
+	resp, err := args.PopulateArgsSlice(testBucket, testTargetPath)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{testBucket, testTargetPath}, resp[:2])
+	assert.ElementsMatch(t, []string{testBucket, testTargetPath, "--allow-other", "--max-threads=16"}, resp)
+}
+
+func TestMountpointS3Validate_Success(t *testing.T) {
+	FileExists = func(path string) (bool, error) {
+		return true, nil
+	}
+
+	args := MountpointS3Args{
+		DirMode:         "0755",
+		EndpointURL:     testURL,
+		MetadataTTL:     "indefinite",
+		Prefix:          "dir/",
+		UploadChecksums: "off",
+	}
+	err := args.Validate(testTargetPath)
+	assert.NoError(t, err)
+}
+
+func TestMountpointS3Validate_PathValidatorFailed(t *testing.T) {
+	args := MountpointS3Args{}
+	err := args.Validate("invalid-path")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "bad value for target path")
+}
+
+func TestMountpointS3Validate_InvalidParamValues(t *testing.T) {
+	FileExists = func(path string) (bool, error) {
+		return true, nil
+	}
+
+	fields := []string{
+		"AllowDelete",
+		"AllowOther",
+		"AllowOverwrite",
+		"AllowRoot",
+		"AutoUnmount",
+		"Cache",
+		"Debug",
+		"DirMode",
+		"EndpointURL",
+		"FileMode",
+		"ForcePathStyle",
+		"GID",
+		"MaxCacheSize",
+		"MaxThreads",
+		"MaximumThroughputGbps",
+		"MetadataTTL",
+		"PartSize",
+		"Prefix",
+		"ReadOnly",
+		"UID",
+		"UploadChecksums",
+	}
+	for _, f := range fields {
+		args := MountpointS3Args{}
+
+		val := reflect.ValueOf(&args).Elem().FieldByName(f)
+		val.SetString("invalid-value")
+		err := args.Validate(testTargetPath)
+		assert.Error(t, err, f)
+	}
+}
+
+func TestMountpointS3Validate_FailedToCheckCredentialsFile(t *testing.T) {
+	FileExists = func(path string) (bool, error) {
+		return false, errors.New("error")
+	}
+	args := MountpointS3Args{}
+	err := args.Validate(testTargetPath)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "error checking credentials file existence")
+}
+
+func TestMountpointS3Validate_CredentialsFileNotFound(t *testing.T) {
+	FileExists = func(path string) (bool, error) {
+		return false, nil
+	}
+
+	args := MountpointS3Args{}
+	err := args.Validate(testTargetPath)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "credentials file not found")
+}
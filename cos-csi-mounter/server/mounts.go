@@ -0,0 +1,378 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	mounterUtils "github.com/IBM/ibm-object-csi-driver/pkg/mounter/utils"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// MountRecord is a mount done by the service
+type MountRecord struct {
+	Path      string    `json:"path"`
+	Bucket    string    `json:"bucket"`
+	Mounter   string    `json:"mounter"`
+	PID       int       `json:"pid"`
+	MountedAt time.Time `json:"mountedAt"`
+	// Args are the mounter arguments with secret values redacted
+	Args []string `json:"args"`
+}
+
+// MountHealth is the result of the checks of a mount when it is queried
+type MountHealth struct {
+	Healthy      bool   `json:"healthy"`
+	Mounted      bool   `json:"mounted"`
+	Accessible   bool   `json:"accessible"`
+	ProcessAlive bool   `json:"processAlive"`
+	Error        string `json:"error,omitempty"`
+}
+
+// MountStatus is the representation of a mount returned by the mounts API
+type MountStatus struct {
+	Path          string      `json:"path"`
+	Bucket        string      `json:"bucket"`
+	Mounter       string      `json:"mounter"`
+	PID           int         `json:"pid"`
+	MountedAt     time.Time   `json:"mountedAt"`
+	UptimeSeconds int64       `json:"uptimeSeconds"`
+	Health        MountHealth `json:"health"`
+	Args          []string    `json:"args"`
+}
+
+// MountRegistry keeps the mounts done by the service since it started
+type MountRegistry struct {
+	mu     sync.RWMutex
+	mounts map[string]*MountRecord
+	// file the records are saved to after every change, none if empty
+	file string
+}
+
+func NewMountRegistry() *MountRegistry {
+	return &MountRegistry{mounts: map[string]*MountRecord{}}
+}
+
+var (
+	mountRegistry = NewMountRegistry()
+
+	findMounterPID = mounterUtils.FindMounterProcess
+	processAlive   = func(pid int) bool {
+		return pid > 0 && syscall.Kill(pid, 0) == nil
+	}
+	reapProcess = mounterUtils.ReapProcess
+	statPath    = os.Stat
+	// mountStatTimeout bounds the stat of a mount by the mounts API, the mounter of a hung mount never answers it
+	mountStatTimeout = 5 * time.Second
+
+	// values of arguments whose name contains one of these words are not returned by the mounts API
+	secretArgWords = []string{"secret", "password", "passwd", "token", "apikey", "api-key", "api_key", "access-key", "access_key"}
+)
+
+// Add records a successful mount, replacing any previous record of the path
+func (r *MountRegistry) Add(path, bucket, mounter string, args []string) {
+	record := &MountRecord{
+		Path:      filepath.Clean(path),
+		Bucket:    bucket,
+		Mounter:   mounter,
+		PID:       findMounterPID(path),
+		MountedAt: time.Now(),
+		Args:      sanitizeArgs(args),
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mounts[record.Path] = record
+	r.persist()
+}
+
+// Remove forgets the mount of path
+func (r *MountRegistry) Remove(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.mounts, filepath.Clean(path))
+	r.persist()
+}
+
+// Get returns a copy of the record of path, or nil if the service did not mount it
+func (r *MountRegistry) Get(path string) *MountRecord {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	record, ok := r.mounts[filepath.Clean(path)]
+	if !ok {
+		return nil
+	}
+	copied := *record
+	return &copied
+}
+
+// List returns copies of all records sorted by path
+func (r *MountRegistry) List() []MountRecord {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.list()
+}
+
+// list returns copies of all records sorted by path, the caller holds the lock
+func (r *MountRegistry) list() []MountRecord {
+	records := make([]MountRecord, 0, len(r.mounts))
+	for _, record := range r.mounts {
+		records = append(records, *record)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Path < records[j].Path })
+	return records
+}
+
+// Reap reaps every interval the mounter processes of the records that exited. The mounters daemonize, the
+// service is the subreaper of their processes.
+func (r *MountRegistry) Reap(interval time.Duration) {
+	for range time.Tick(interval) {
+		r.reap()
+	}
+}
+
+// reap forgets the PID of the records whose process exited, so that a process reusing it is not taken for the
+// mounter
+func (r *MountRegistry) reap() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, record := range r.mounts {
+		if record.PID > 0 && reapProcess(record.PID) {
+			logger.Warn("Mounter process exited while mounted", zap.String("path", record.Path), zap.Int("pid", record.PID))
+			record.PID = 0
+		}
+	}
+}
+
+// Persist saves the records to file now and after every Add and Remove, so that a service that is killed
+// rather than shut down still knows the mounts it did
+func (r *MountRegistry) Persist(file string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.file = file
+	return r.save(file)
+}
+
+// persist saves the records to the file of Persist, if any. The caller holds the write lock, which orders the
+// saves. A failed save is logged, the mount or unmount itself succeeded.
+func (r *MountRegistry) persist() {
+	if r.file == "" {
+		return
+	}
+	if err := r.save(r.file); err != nil {
+		logger.Error("Failed to save the mounts", zap.String("file", r.file), zap.Error(err))
+	}
+}
+
+// Save writes the records to file, so that a restarted service still knows the mounts it did
+func (r *MountRegistry) Save(file string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.save(file)
+}
+
+// save writes the records to a temporary file next to file and renames it to file, so that a crash never leaves
+// a partial file. The caller holds the write lock.
+func (r *MountRegistry) save(file string) error {
+	data, err := json.Marshal(r.list())
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(file)
+	if err = MakeDir(dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(file)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }() // #nosec G104: fails once renamed
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close() // #nosec G104: the write error is returned
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close() // #nosec G104: the sync error is returned
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// Restore adds the records saved to file whose path is still a FUSE mount, a missing file restores nothing
+func (r *MountRegistry) Restore(file string) error {
+	data, err := os.ReadFile(file) // #nosec G304: state file of the service
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var records []MountRecord
+	if err = json.Unmarshal(data, &records); err != nil {
+		return fmt.Errorf("invalid state file %s: %v", file, err)
+	}
+	mounted, err := fuseMountpoints()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, record := range records {
+		if !mounted[record.Path] {
+			logger.Info("Dropping saved mount that is no longer mounted", zap.String("path", record.Path))
+			continue
+		}
+		// the process may have been restarted, e.g. by a node plugin remount
+		record.PID = findMounterPID(record.Path)
+		r.mounts[record.Path] = &record
+	}
+	logger.Info("Restored mounts", zap.String("file", file), zap.Int("mounts", len(r.mounts)))
+	return nil
+}
+
+// fuseMountpoints returns the paths of the FUSE mounts of the node
+func fuseMountpoints() (map[string]bool, error) {
+	infos, err := listMountInfo()
+	if err != nil {
+		return nil, fmt.Errorf("cannot list mounts: %v", err)
+	}
+	mounted := map[string]bool{}
+	for _, info := range infos {
+		if strings.HasPrefix(info.FsType, "fuse") {
+			mounted[info.MountPoint] = true
+		}
+	}
+	return mounted, nil
+}
+
+// becomeSubreaper makes the daemonized mounter processes children of the service, so that it reaps them when
+// they exit
+func becomeSubreaper() error {
+	const prSetChildSubreaper = 36
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetChildSubreaper, 1, 0); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// sanitizeArgs returns a copy of args in which values of secret looking options are redacted. Options are
+// either "key=value" or an "-o" followed by a comma separated list of them.
+func sanitizeArgs(args []string) []string {
+	sanitized := make([]string, len(args))
+	for i, arg := range args {
+		options := strings.Split(arg, ",")
+		for j, opt := range options {
+			name, _, found := strings.Cut(opt, "=")
+			if found && isSecretArg(name) {
+				options[j] = name + "=<redacted>"
+			}
+		}
+		sanitized[i] = strings.Join(options, ",")
+	}
+	return sanitized
+}
+
+func isSecretArg(name string) bool {
+	name = strings.ToLower(strings.TrimLeft(name, "-"))
+	// files only hold the path to the secret
+	if strings.HasSuffix(name, "file") {
+		return false
+	}
+	for _, word := range secretArgWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// mountStatus checks the health of a recorded mount until ctx is done
+func mountStatus(ctx context.Context, record MountRecord, mounter mounterUtils.MounterUtils) MountStatus {
+	status := MountStatus{
+		Path:          record.Path,
+		Bucket:        record.Bucket,
+		Mounter:       record.Mounter,
+		PID:           record.PID,
+		MountedAt:     record.MountedAt,
+		UptimeSeconds: int64(time.Since(record.MountedAt).Seconds()),
+		Args:          record.Args,
+	}
+
+	info, err := mounter.GetMountInfo(record.Path)
+	if err != nil {
+		status.Health.Error = fmt.Sprintf("cannot read mount table: %v", err)
+	}
+	status.Health.Mounted = info != nil
+	if status.Health.Mounted {
+		// a fuse mount whose process died fails with "transport endpoint is not connected"
+		if err = statMount(ctx, record.Path); err != nil {
+			status.Health.Error = err.Error()
+		} else {
+			status.Health.Accessible = true
+		}
+	} else if status.Health.Error == "" {
+		status.Health.Error = "path is not a mountpoint"
+	}
+	status.Health.ProcessAlive = processAlive(record.PID)
+	status.Health.Healthy = status.Health.Mounted && status.Health.Accessible && status.Health.ProcessAlive
+	return status
+}
+
+// statMount stats path in a goroutine, which is left behind when the stat does not return before ctx is done or
+// mountStatTimeout runs out
+func statMount(ctx context.Context, path string) error {
+	ctx, cancel := context.WithTimeout(ctx, mountStatTimeout)
+	defer cancel()
+	stat, statCh := statPath, make(chan error, 1)
+	go func() {
+		_, err := stat(path)
+		statCh <- err
+	}()
+	select {
+	case err := <-statCh:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("stat timed out: %v", ctx.Err())
+	}
+}
+
+func handleListMounts(mounter mounterUtils.MounterUtils, registry *MountRegistry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		records := registry.List()
+		mounts := make([]MountStatus, len(records))
+		// the mounts are checked in parallel, so that hung mounts do not add up their timeouts
+		var wg sync.WaitGroup
+		for i, record := range records {
+			wg.Go(func() {
+				mounts[i] = mountStatus(c.Request.Context(), record, mounter)
+			})
+		}
+		wg.Wait()
+		c.JSON(http.StatusOK, gin.H{"mounts": mounts})
+	}
+}
+
+func handleGetMount(mounter mounterUtils.MounterUtils, registry *MountRegistry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Param("path")
+		record := registry.Get(path)
+		if record == nil {
+			c.JSON(http.StatusNotFound, mounterapi.ErrorResponse(mounterapi.CodeNotFound, "no mount at %s", path))
+			return
+		}
+		c.JSON(http.StatusOK, mountStatus(c.Request.Context(), *record, mounter))
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
+	mounterUtils "github.com/IBM/ibm-object-csi-driver/pkg/mounter/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	k8sMountUtils "k8s.io/mount-utils"
+)
+
+const testMountPath = "/var/lib/kubelet/pods/pod-uid/volumes/kubernetes.io~csi/pv/mount"
+
+func stubMountChecks(t *testing.T, pid int, alive bool, statErr error) {
+	origFind, origAlive, origStat := findMounterPID, processAlive, statPath
+	t.Cleanup(func() { findMounterPID, processAlive, statPath = origFind, origAlive, origStat })
+
+	findMounterPID = func(string) int { return pid }
+	processAlive = func(int) bool { return alive }
+	statPath = func(string) (os.FileInfo, error) { return nil, statErr }
+}
+
+func TestMountRegistry(t *testing.T) {
+	stubMountChecks(t, 42, true, nil)
+	registry := NewMountRegistry()
+
+	registry.Add(testMountPath+"/", testBucket, constants.S3FS, []string{testBucket, testMountPath})
+	registry.Add("/var/lib/kubelet/pods/a/mount", "other", constants.RClone, nil)
+
+	record := registry.Get(testMountPath)
+	assert.NotNil(t, record)
+	assert.Equal(t, testMountPath, record.Path)
+	assert.Equal(t, testBucket, record.Bucket)
+	assert.Equal(t, 42, record.PID)
+	assert.WithinDuration(t, time.Now(), record.MountedAt, time.Minute)
+
+	records := registry.List()
+	assert.Len(t, records, 2)
+	assert.Equal(t, "/var/lib/kubelet/pods/a/mount", records[0].Path)
+	assert.Equal(t, testMountPath, records[1].Path)
+
+	registry.Remove(testMountPath)
+	assert.Nil(t, registry.Get(testMountPath))
+	assert.Len(t, registry.List(), 1)
+}
+
+func TestMountRegistry_Reap(t *testing.T) {
+	stubMountChecks(t, 42, true, nil)
+	origReap := reapProcess
+	t.Cleanup(func() { reapProcess = origReap })
+	exited := map[int]bool{}
+	reapProcess = func(pid int) bool { return exited[pid] }
+
+	registry := NewMountRegistry()
+	registry.Add(testMountPath, testBucket, constants.S3FS, nil)
+
+	registry.reap()
+	assert.Equal(t, 42, registry.Get(testMountPath).PID)
+
+	exited[42] = true
+	registry.reap()
+	assert.Equal(t, 0, registry.Get(testMountPath).PID)
+}
+
+func TestSanitizeArgs(t *testing.T) {
+	args := []string{
+		"mount", "-o", "passwd_file=/var/lib/coscsi-config/abc/.passwd-s3fs", "-o", "ibm_iam_endpoint=https://iam",
+		"--secret-access-key=abc", "--config=/var/lib/coscsi-config/abc/rclone.conf", "use_sse=custom,sse_token=xyz",
+	}
+	assert.Equal(t, []string{
+		"mount", "-o", "passwd_file=/var/lib/coscsi-config/abc/.passwd-s3fs", "-o", "ibm_iam_endpoint=https://iam",
+		"--secret-access-key=<redacted>", "--config=/var/lib/coscsi-config/abc/rclone.conf", "use_sse=custom,sse_token=<redacted>",
+	}, sanitizeArgs(args))
+}
+
+func TestMountStatus(t *testing.T) {
+	record := MountRecord{Path: testMountPath, Bucket: testBucket, Mounter: constants.S3FS, PID: 42, MountedAt: time.Now().Add(-time.Hour)}
+
+	testCases := []struct {
+		name      string
+		mountInfo *mounterUtils.MountInfo
+		mountErr  error
+		alive     bool
+		statErr   error
+		expected  MountHealth
+	}{
+		{
+			name:      "healthy",
+			mountInfo: &mounterUtils.MountInfo{Source: constants.S3FS, FsType: "fuse.s3fs"},
+			alive:     true,
+			expected:  MountHealth{Healthy: true, Mounted: true, Accessible: true, ProcessAlive: true},
+		},
+		{
+			name:      "process died",
+			mountInfo: &mounterUtils.MountInfo{Source: constants.S3FS, FsType: "fuse.s3fs"},
+			statErr:   errors.New("transport endpoint is not connected"),
+			expected:  MountHealth{Mounted: true, Error: "transport endpoint is not connected"},
+		},
+		{
+			name:     "not mounted",
+			alive:    true,
+			expected: MountHealth{ProcessAlive: true, Error: "path is not a mountpoint"},
+		},
+		{
+			name:     "mount table error",
+			mountErr: errors.New("permission denied"),
+			expected: MountHealth{Error: "cannot read mount table: permission denied"},
+		},
+	}
+	for _, tc := range testCases {
+		stubMountChecks(t, 42, tc.alive, tc.statErr)
+		mockMounter := new(MockMounterUtils)
+		mockMounter.On("GetMountInfo", testMountPath).Return(tc.mountInfo, tc.mountErr)
+
+		status := mountStatus(context.Background(), record, mockMounter)
+
+		assert.Equal(t, tc.expected, status.Health, tc.name)
+		assert.GreaterOrEqual(t, status.UptimeSeconds, int64(3600), tc.name)
+	}
+}
+
+func TestMountStatus_HungMount(t *testing.T) {
+	stubMountChecks(t, 42, true, nil)
+	origTimeout := mountStatTimeout
+	t.Cleanup(func() { mountStatTimeout = origTimeout })
+	mountStatTimeout = 10 * time.Millisecond
+	hung := make(chan struct{})
+	defer close(hung)
+	statPath = func(string) (os.FileInfo, error) {
+		<-hung
+		return nil, nil
+	}
+
+	mockMounter := new(MockMounterUtils)
+	mockMounter.On("GetMountInfo", testMountPath).Return(&mounterUtils.MountInfo{Source: constants.S3FS, FsType: "fuse.s3fs"}, nil)
+
+	status := mountStatus(context.Background(), MountRecord{Path: testMountPath, PID: 42}, mockMounter)
+	assert.True(t, status.Health.Mounted)
+	assert.False(t, status.Health.Accessible)
+	assert.False(t, status.Health.Healthy)
+	assert.Contains(t, status.Health.Error, "timed out")
+}
+
+func TestHandleMounts(t *testing.T) {
+	stubMountChecks(t, 42, true, nil)
+	registry := NewMountRegistry()
+	registry.Add(testMountPath, testBucket, constants.RClone, []string{"mount", testBucket, testMountPath})
+
+	mockMounter := new(MockMounterUtils)
+	mockMounter.On("GetMountInfo", testMountPath).Return(&mounterUtils.MountInfo{Source: "ibmcos:" + testBucket, FsType: "fuse.rclone"}, nil)
+
+	router := gin.Default()
+	router.GET("/api/cos/mounts", handleListMounts(mockMounter, registry))
+	router.GET("/api/cos/mounts/*path", handleGetMount(mockMounter, registry))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/cos/mounts", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var list struct {
+		Mounts []MountStatus `json:"mounts"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Len(t, list.Mounts, 1)
+	assert.Equal(t, testMountPath, list.Mounts[0].Path)
+	assert.Equal(t, 42, list.Mounts[0].PID)
+	assert.True(t, list.Mounts[0].Health.Healthy)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/cos/mounts"+testMountPath, nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var mount MountStatus
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &mount))
+	assert.Equal(t, testBucket, mount.Bucket)
+	assert.Equal(t, constants.RClone, mount.Mounter)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/cos/mounts/var/lib/kubelet/pods/unknown/mount", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestMountRegistry_SaveRestore(t *testing.T) {
+	stubMountChecks(t, 42, true, nil)
+	stubCredentialMounts(t, []k8sMountUtils.MountInfo{{MountPoint: testMountPath, FsType: "fuse.s3fs"}})
+	file := filepath.Join(t.TempDir(), "state", "mounts.json")
+
+	registry := NewMountRegistry()
+	registry.Add(testMountPath, testBucket, constants.S3FS, []string{"-o", "sse_token=xyz"})
+	registry.Add("/var/lib/kubelet/pods/a/mount", "other", constants.RClone, nil)
+	assert.NoError(t, registry.Save(file))
+
+	info, err := os.Stat(file)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	findMounterPID = func(string) int { return 43 }
+	restored := NewMountRegistry()
+	assert.NoError(t, restored.Restore(file))
+	// paths that are no longer mounted are dropped
+	records := restored.List()
+	assert.Len(t, records, 1)
+	assert.Equal(t, testMountPath, records[0].Path)
+	assert.Equal(t, testBucket, records[0].Bucket)
+	assert.Equal(t, 43, records[0].PID)
+	assert.Equal(t, []string{"-o", "sse_token=<redacted>"}, records[0].Args)
+
+	assert.NoError(t, NewMountRegistry().Restore(filepath.Join(t.TempDir(), "missing.json")))
+}
+
+func TestMountRegistry_Persist(t *testing.T) {
+	stubMountChecks(t, 42, true, nil)
+	dir := filepath.Join(t.TempDir(), "state")
+	file := filepath.Join(dir, "mounts.json")
+	saved := func() []MountRecord {
+		data, err := os.ReadFile(file) // #nosec G304: test file
+		assert.NoError(t, err)
+		var records []MountRecord
+		assert.NoError(t, json.Unmarshal(data, &records))
+		return records
+	}
+
+	registry := NewMountRegistry()
+	// changes before Persist are not saved
+	registry.Add("/var/lib/kubelet/pods/a/mount", "other", constants.RClone, nil)
+	_, err := os.Stat(file)
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NoError(t, registry.Persist(file))
+	assert.Len(t, saved(), 1)
+
+	registry.Add(testMountPath, testBucket, constants.S3FS, []string{"-o", "sse_token=xyz"})
+	records := saved()
+	assert.Len(t, records, 2)
+	assert.Equal(t, testMountPath, records[1].Path)
+	assert.Equal(t, []string{"-o", "sse_token=<redacted>"}, records[1].Args)
+
+	registry.Remove("/var/lib/kubelet/pods/a/mount")
+	records = saved()
+	assert.Len(t, records, 1)
+	assert.Equal(t, testMountPath, records[0].Path)
+
+	info, err := os.Stat(file)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	// the temporary files are renamed
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
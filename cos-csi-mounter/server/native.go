@@ -0,0 +1,330 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
+	"github.com/IBM/ibm-object-csi-driver/pkg/nativefs"
+	"github.com/IBM/ibm-object-csi-driver/pkg/s3client"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+// NativeArgs are the options of the native mounter. The filesystem is served by this binary
+// itself, started as "cos-csi-mounter-server native <bucket> <path> --key=value..." for each mount,
+// so that native mounts are FUSE processes just like the s3fs and rclone ones.
+type NativeArgs struct {
+	AllowOther     string `json:"allow-other,omitempty"`
+	AuthType       string `json:"auth-type,omitempty"`
+	CacheDir       string `json:"cache-dir,omitempty"`
+	Debug          string `json:"debug,omitempty"`
+	DirMode        string `json:"dir-mode,omitempty"`
+	EndpointURL    string `json:"endpoint-url,omitempty"`
+	FileMode       string `json:"file-mode,omitempty"`
+	GID            string `json:"gid,omitempty"`
+	IAMEndpoint    string `json:"iam-endpoint,omitempty"`
+	MetadataTTL    string `json:"metadata-ttl,omitempty"`
+	MetricsAddress string `json:"metrics-address,omitempty"`
+	PartSize       string `json:"part-size,omitempty"`
+	PasswdFile     string `json:"passwd-file,omitempty"`
+	Prefix         string `json:"prefix,omitempty"`
+	ReadAhead      string `json:"read-ahead,omitempty"`
+	ReadOnly       string `json:"read-only,omitempty"`
+	Region         string `json:"region,omitempty"`
+	UID            string `json:"uid,omitempty"`
+}
+
+var (
+	// nativeMounterBinary returns the command serving native mounts, i.e. this executable
+	nativeMounterBinary = os.Executable
+	newNativeClient     = func(endpoint, region string, creds *s3client.ObjectStorageCredentials) s3client.ObjectClient {
+		return s3client.NewObjectClient(endpoint, region, creds, logger)
+	}
+	mountNativeFS = func(fsys *nativefs.FS, dir string, mo nativefs.MountOptions) (nativeServer, error) {
+		return fsys.Mount(dir, mo)
+	}
+)
+
+// nativeServer is the part of *fuse.Server used to run a native mount
+type nativeServer interface {
+	Unmount() error
+	Wait()
+}
+
+func (args NativeArgs) PopulateArgsSlice(bucket, targetPath string) ([]string, error) {
+	// Marshal to JSON
+	raw, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal into map[string]string
+	var m map[string]string
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+
+	// Convert to flag slice, the native subcommand parses all flags with the flag package
+	result := []string{constants.Native, bucket, targetPath}
+	for k, v := range m {
+		result = append(result, fmt.Sprintf("--%s=%v", k, v)) // --key=value
+	}
+
+	return result, nil // [native, bucket, path, --key1=value1, ...]
+}
+
+func (args NativeArgs) Validate(targetPath string) error {
+	if err := pathValidator(targetPath); err != nil {
+		return err
+	}
+
+	boolArgs := map[string]string{
+		"allow-other": args.AllowOther,
+		"debug":       args.Debug,
+		"read-only":   args.ReadOnly,
+	}
+	for name, val := range boolArgs {
+		if val != "" && !isBoolString(val) {
+			logger.Error("cannot convert value of "+name+" into boolean", zap.Any(name, val))
+			return fmt.Errorf("cannot convert value of %s into boolean: %v", name, val)
+		}
+	}
+
+	// uid and gid are 32 bits, sizes are int64 in the filesystem
+	intArgs := map[string]struct {
+		val  string
+		bits int
+	}{
+		"gid":        {args.GID, 32},
+		"part-size":  {args.PartSize, 63},
+		"read-ahead": {args.ReadAhead, 63},
+		"uid":        {args.UID, 32},
+	}
+	for name, arg := range intArgs {
+		if arg.val == "" {
+			continue
+		}
+		if _, err := strconv.ParseUint(arg.val, 10, arg.bits); err != nil {
+			logger.Error("cannot convert value of "+name+" into integer", zap.Error(err))
+			return fmt.Errorf("cannot convert value of %s into integer: %v", name, err)
+		}
+	}
+
+	if args.PartSize != "" {
+		if size, _ := strconv.ParseUint(args.PartSize, 10, 63); size < nativefs.MinPartSize {
+			logger.Error("part-size is below the minimum", zap.Any("part-size", args.PartSize))
+			return fmt.Errorf("part-size must be at least %d bytes: %v", nativefs.MinPartSize, args.PartSize)
+		}
+	}
+
+	modeArgs := map[string]string{
+		"dir-mode":  args.DirMode,
+		"file-mode": args.FileMode,
+	}
+	for name, val := range modeArgs {
+		if val == "" {
+			continue
+		}
+		if _, err := strconv.ParseUint(val, 8, 32); err != nil {
+			logger.Error("cannot convert value of "+name+" into octal mode", zap.Error(err))
+			return fmt.Errorf("cannot convert value of %s into octal mode: %v", name, err)
+		}
+	}
+
+	if args.MetadataTTL != "" {
+		if _, err := parseMetadataTTL(args.MetadataTTL); err != nil {
+			logger.Error("invalid value for metadata-ttl", zap.Any("metadata-ttl", args.MetadataTTL))
+			return fmt.Errorf("invalid value for metadata-ttl: %v", args.MetadataTTL)
+		}
+	}
+
+	if args.AuthType != "hmac" && args.AuthType != "iam" {
+		logger.Error("invalid value for auth-type", zap.Any("auth-type", args.AuthType))
+		return fmt.Errorf("invalid value for auth-type: %v", args.AuthType)
+	}
+
+	urlArgs := map[string]string{
+		"endpoint-url": args.EndpointURL,
+		"iam-endpoint": args.IAMEndpoint,
+	}
+	for name, val := range urlArgs {
+		if val == "" {
+			continue
+		}
+		if u, err := url.Parse(val); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			logger.Error("bad value for "+name, zap.Any(name, val))
+			return fmt.Errorf("bad value for %s \"%v\": must be an absolute http(s) url", name, val)
+		}
+	}
+	if args.EndpointURL == "" {
+		logger.Error("missing endpoint-url")
+		return errors.New("missing endpoint-url")
+	}
+
+	if err := cacheDirValidator("cache-dir", args.CacheDir); err != nil {
+		return err
+	}
+
+	if args.MetricsAddress != "" {
+		if _, _, err := net.SplitHostPort(args.MetricsAddress); err != nil {
+			logger.Error("bad value for metrics-address", zap.Any("metrics-address", args.MetricsAddress))
+			return fmt.Errorf("bad value for metrics-address \"%v\": %v", args.MetricsAddress, err)
+		}
+	}
+
+	// Check if passwd file exists or not
+	if exists, err := FileExists(args.PasswdFile); err != nil {
+		logger.Error("error checking credentials file existence")
+		return fmt.Errorf("error checking credentials file existence")
+	} else if !exists {
+		logger.Error("credentials file not found")
+		return fmt.Errorf("credentials file not found")
+	}
+
+	return nil
+}
+
+// parseMetadataTTL accepts a Go duration ("30s") or a number of seconds
+func parseMetadataTTL(val string) (time.Duration, error) {
+	if secs, err := strconv.ParseUint(val, 10, 32); err == nil {
+		return time.Duration(secs) * time.Second, nil
+	}
+	ttl, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, fmt.Errorf("negative duration %v", val)
+	}
+	return ttl, nil
+}
+
+// readNativeCredentials reads the passwd file written by the driver: "accessKey:secretKey" or ":apiKey"
+func readNativeCredentials(passwdFile, authType, iamEndpoint string) (*s3client.ObjectStorageCredentials, error) {
+	data, err := os.ReadFile(passwdFile) // #nosec G304: path is validated by the mount request
+	if err != nil {
+		return nil, err
+	}
+	keys := strings.SplitN(strings.TrimSpace(string(data)), ":", 2)
+	if len(keys) != 2 {
+		return nil, errors.New("malformed credentials file")
+	}
+	if authType == "iam" {
+		return &s3client.ObjectStorageCredentials{AuthType: "iam", APIKey: keys[1], IAMEndpoint: iamEndpoint}, nil
+	}
+	return &s3client.ObjectStorageCredentials{AuthType: "hmac", AccessKey: keys[0], SecretKey: keys[1]}, nil
+}
+
+// runNativeMount serves a bucket on a mountpoint until it is unmounted. argv is
+// "<bucket> <path> --key=value...", as built by NativeArgs.PopulateArgsSlice.
+func runNativeMount(argv []string) error {
+	if len(argv) < 2 {
+		return errors.New("usage: native <bucket> <path> [--option=value...]")
+	}
+	bucket, path := argv[0], argv[1]
+
+	var (
+		flags = flag.NewFlagSet(constants.Native, flag.ContinueOnError)
+
+		allowOther     = flags.Bool("allow-other", false, "allow access by other users")
+		authType       = flags.String("auth-type", "hmac", "hmac or iam")
+		debug          = flags.Bool("debug", false, "log FUSE requests")
+		cacheDir       = flags.String("cache-dir", "", "directory buffering writes, the system temporary directory if empty")
+		dirMode        = flags.String("dir-mode", "", "permissions of directories, octal")
+		endpoint       = flags.String("endpoint-url", "", "COS endpoint")
+		fileMode       = flags.String("file-mode", "", "permissions of files, octal")
+		gid            = flags.Uint("gid", 0, "owner group of files")
+		iamEndpoint    = flags.String("iam-endpoint", constants.PublicIAMEndpoint, "IAM endpoint for iam auth-type")
+		metadataTTL    = flags.String("metadata-ttl", nativefs.DefaultMetadataTTL.String(), "metadata cache TTL")
+		metricsAddress = flags.String("metrics-address", "", "address to serve Prometheus metrics on")
+		partSize       = flags.Int64("part-size", nativefs.DefaultPartSize, "multipart upload part size in bytes")
+		passwdFile     = flags.String("passwd-file", "", "credentials file")
+		prefix         = flags.String("prefix", "", "object prefix to mount")
+		readAhead      = flags.Int64("read-ahead", nativefs.DefaultReadAhead, "ranged GET size in bytes")
+		readOnly       = flags.Bool("read-only", false, "mount read-only")
+		region         = flags.String("region", "", "location constraint of the bucket")
+		uid            = flags.Uint("uid", 0, "owner of files")
+	)
+	if err := flags.Parse(argv[2:]); err != nil {
+		return err
+	}
+
+	ttl, err := parseMetadataTTL(*metadataTTL)
+	if err != nil {
+		return fmt.Errorf("invalid metadata-ttl: %v", err)
+	}
+	var modes [2]uint64
+	for i, mode := range []string{*dirMode, *fileMode} {
+		if mode == "" {
+			continue
+		}
+		if modes[i], err = strconv.ParseUint(mode, 8, 32); err != nil {
+			return fmt.Errorf("invalid mode %q: %v", mode, err)
+		}
+	}
+
+	creds, err := readNativeCredentials(*passwdFile, *authType, *iamEndpoint)
+	if err != nil {
+		return fmt.Errorf("cannot read credentials: %v", err)
+	}
+
+	reg := prometheus.NewRegistry()
+	metrics := nativefs.NewMetrics(reg, prometheus.Labels{"bucket": bucket})
+	if *metricsAddress != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+		metricsServer := &http.Server{Addr: *metricsAddress, Handler: mux, ReadHeaderTimeout: 3 * time.Second}
+		go func() {
+			if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("native mount metrics server failed", zap.String("address", *metricsAddress), zap.Error(err))
+			}
+		}()
+	}
+
+	fsys := nativefs.New(newNativeClient(*endpoint, *region, creds), nativefs.Options{
+		Bucket:      bucket,
+		Prefix:      *prefix,
+		UID:         uint32(*uid), // #nosec G115: flag values are validated by the mount request
+		GID:         uint32(*gid), // #nosec G115: flag values are validated by the mount request
+		DirMode:     uint32(modes[0]),
+		FileMode:    uint32(modes[1]),
+		ReadOnly:    *readOnly,
+		MetadataTTL: ttl,
+		PartSize:    *partSize,
+		ReadAhead:   *readAhead,
+		TempDir:     *cacheDir,
+	}, metrics)
+
+	server, err := mountNativeFS(fsys, path, nativefs.MountOptions{AllowOther: *allowOther, Debug: *debug})
+	if err != nil {
+		return fmt.Errorf("cannot mount %s on %s: %v", bucket, path, err)
+	}
+	logger.Info("native mount is serving", zap.String("bucket", bucket), zap.String("path", path))
+
+	// FuseUnmount unmounts the path and then terminates the process, either way the server stops
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-signals
+		if err := server.Unmount(); err != nil {
+			logger.Warn("native mount unmount on signal failed", zap.String("path", path), zap.Error(err))
+		}
+	}()
+
+	server.Wait()
+	logger.Info("native mount stopped", zap.String("path", path))
+	return nil
+}
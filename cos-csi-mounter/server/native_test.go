@@ -0,0 +1,209 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
+	"github.com/IBM/ibm-object-csi-driver/pkg/nativefs"
+	"github.com/IBM/ibm-object-csi-driver/pkg/s3client"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeNativeServer struct{}
+
+func (fakeNativeServer) Unmount() error { return nil }
+func (fakeNativeServer) Wait()          {}
+
+func TestNativePopulateArgsSlice_Success(t *testing.T) {
+	args := NativeArgs{
+		AllowOther: "true",
+		AuthType:   "hmac",
+		PasswdFile: "/var/lib/coscsi-config/abc/.passwd-native",
+	}
+
+	resp, err := args.PopulateArgsSlice(testBucket, testTargetPath)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{constants.Native, testBucket, testTargetPath}, resp[:3])
+	assert.ElementsMatch(t, []string{constants.Native, testBucket, testTargetPath,
+		"--allow-other=true", "--auth-type=hmac", "--passwd-file=/var/lib/coscsi-config/abc/.passwd-native"}, resp)
+}
+
+func TestNativeValidate_Success(t *testing.T) {
+	FileExists = func(path string) (bool, error) {
+		return true, nil
+	}
+
+	args := NativeArgs{
+		AuthType:       "iam",
+		DirMode:        "0755",
+		EndpointURL:    testURL,
+		IAMEndpoint:    constants.PublicIAMEndpoint,
+		MetadataTTL:    "30s",
+		MetricsAddress: ":9100",
+		PartSize:       "8388608",
+		UID:            "1000",
+	}
+	err := args.Validate(testTargetPath)
+	assert.NoError(t, err)
+}
+
+func TestNativeValidate_PathValidatorFailed(t *testing.T) {
+	args := NativeArgs{}
+	err := args.Validate("invalid-path")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "bad value for target path")
+}
+
+func TestNativeValidate_InvalidParamValues(t *testing.T) {
+	FileExists = func(path string) (bool, error) {
+		return true, nil
+	}
+
+	fields := []string{
+		"AllowOther",
+		"AuthType",
+		"CacheDir",
+		"Debug",
+		"DirMode",
+		"EndpointURL",
+		"FileMode",
+		"GID",
+		"IAMEndpoint",
+		"MetadataTTL",
+		"MetricsAddress",
+		"PartSize",
+		"ReadAhead",
+		"ReadOnly",
+		"UID",
+	}
+	for _, f := range fields {
+		args := NativeArgs{AuthType: "hmac", EndpointURL: testURL}
+
+		val := reflect.ValueOf(&args).Elem().FieldByName(f)
+		val.SetString("invalid-value")
+		err := args.Validate(testTargetPath)
+		assert.Error(t, err, f)
+	}
+}
+
+func TestNativeValidate_PartSizeTooSmall(t *testing.T) {
+	FileExists = func(path string) (bool, error) {
+		return true, nil
+	}
+
+	args := NativeArgs{AuthType: "hmac", EndpointURL: testURL, PartSize: "1024"}
+	err := args.Validate(testTargetPath)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "part-size must be at least")
+}
+
+func TestNativeValidate_MissingEndpoint(t *testing.T) {
+	args := NativeArgs{AuthType: "hmac"}
+	err := args.Validate(testTargetPath)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "missing endpoint-url")
+}
+
+func TestNativeValidate_CredentialsFileNotFound(t *testing.T) {
+	FileExists = func(path string) (bool, error) {
+		return false, nil
+	}
+
+	args := NativeArgs{AuthType: "hmac", EndpointURL: testURL}
+	err := args.Validate(testTargetPath)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "credentials file not found")
+
+	FileExists = func(path string) (bool, error) {
+		return false, errors.New("error")
+	}
+	err = args.Validate(testTargetPath)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "error checking credentials file existence")
+}
+
+func TestParseMetadataTTL(t *testing.T) {
+	ttl, err := parseMetadataTTL("30")
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Second, ttl)
+
+	ttl, err = parseMetadataTTL("1m")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, ttl)
+
+	_, err = parseMetadataTTL("-1s")
+	assert.Error(t, err)
+}
+
+func TestReadNativeCredentials(t *testing.T) {
+	passwdFile := filepath.Join(t.TempDir(), ".passwd-native")
+
+	assert.NoError(t, os.WriteFile(passwdFile, []byte("access:secret"), 0600))
+	creds, err := readNativeCredentials(passwdFile, "hmac", "")
+	assert.NoError(t, err)
+	assert.Equal(t, &s3client.ObjectStorageCredentials{AuthType: "hmac", AccessKey: "access", SecretKey: "secret"}, creds)
+
+	assert.NoError(t, os.WriteFile(passwdFile, []byte(":apikey"), 0600))
+	creds, err = readNativeCredentials(passwdFile, "iam", constants.PublicIAMEndpoint)
+	assert.NoError(t, err)
+	assert.Equal(t, &s3client.ObjectStorageCredentials{AuthType: "iam", APIKey: "apikey", IAMEndpoint: constants.PublicIAMEndpoint}, creds)
+
+	assert.NoError(t, os.WriteFile(passwdFile, []byte("malformed"), 0600))
+	_, err = readNativeCredentials(passwdFile, "hmac", "")
+	assert.Error(t, err)
+}
+
+func TestRunNativeMount(t *testing.T) {
+	defer func() {
+		newNativeClient = func(endpoint, region string, creds *s3client.ObjectStorageCredentials) s3client.ObjectClient {
+			return s3client.NewObjectClient(endpoint, region, creds, logger)
+		}
+		mountNativeFS = func(fsys *nativefs.FS, dir string, mo nativefs.MountOptions) (nativeServer, error) {
+			return fsys.Mount(dir, mo)
+		}
+	}()
+
+	passwdFile := filepath.Join(t.TempDir(), ".passwd-native")
+	assert.NoError(t, os.WriteFile(passwdFile, []byte("access:secret"), 0600))
+
+	var (
+		gotEndpoint string
+		gotDir      string
+		gotOptions  nativefs.MountOptions
+	)
+	newNativeClient = func(endpoint, _ string, _ *s3client.ObjectStorageCredentials) s3client.ObjectClient {
+		gotEndpoint = endpoint
+		return s3client.NewFakeObjectClient(nil)
+	}
+	mountNativeFS = func(_ *nativefs.FS, dir string, mo nativefs.MountOptions) (nativeServer, error) {
+		gotDir, gotOptions = dir, mo
+		return fakeNativeServer{}, nil
+	}
+
+	err := runNativeMount([]string{testBucket, testTargetPath,
+		"--endpoint-url=" + testURL, "--passwd-file=" + passwdFile, "--allow-other=true", "--dir-mode=0750", "--metadata-ttl=5"})
+	assert.NoError(t, err)
+	assert.Equal(t, testURL, gotEndpoint)
+	assert.Equal(t, testTargetPath, gotDir)
+	assert.True(t, gotOptions.AllowOther)
+
+	mountNativeFS = func(*nativefs.FS, string, nativefs.MountOptions) (nativeServer, error) {
+		return nil, errors.New("fusermount failed")
+	}
+	err = runNativeMount([]string{testBucket, testTargetPath, "--passwd-file=" + passwdFile})
+	assert.ErrorContains(t, err, "fusermount failed")
+
+	err = runNativeMount([]string{testBucket})
+	assert.ErrorContains(t, err, "usage")
+
+	err = runNativeMount([]string{testBucket, testTargetPath, "--passwd-file=/nonexistent"})
+	assert.ErrorContains(t, err, "cannot read credentials")
+
+	err = runNativeMount([]string{testBucket, testTargetPath, "--unknown=true"})
+	assert.Error(t, err)
+}
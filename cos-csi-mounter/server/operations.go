@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+
+	mounterUtils "github.com/IBM/ibm-object-csi-driver/pkg/mounter/utils"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// completed operations can be polled for this long
+	operationRetention = 10 * time.Minute
+)
+
+var operationTracker = NewOperationTracker(operationRetention)
+
+type operation struct {
+	mounterapi.Operation
+	done chan struct{}
+}
+
+// OperationTracker runs mount and unmount operations asynchronously, at most one per path at a time
+type OperationTracker struct {
+	mu         sync.Mutex
+	operations map[string]*operation
+	// inFlight are the running operations by path
+	inFlight  map[string]*operation
+	retention time.Duration
+}
+
+func NewOperationTracker(retention time.Duration) *OperationTracker {
+	return &OperationTracker{
+		operations: map[string]*operation{},
+		inFlight:   map[string]*operation{},
+		retention:  retention,
+	}
+}
+
+// Start runs an operation of type kind on path in the background. If an operation of the same type is in
+// progress on path it is returned instead, so that retries attach to it, an operation of another type fails
+// the request.
+func (t *OperationTracker) Start(kind, path string, run func() *requestError) (mounterapi.Operation, *requestError) {
+	path = filepath.Clean(path)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.purge(time.Now())
+
+	if op, ok := t.inFlight[path]; ok {
+		if op.Type != kind {
+			logger.Warn("Rejecting request for a path with an operation in progress", zap.String("path", path),
+				zap.String("type", kind), zap.String("operation", op.ID), zap.String("operationType", op.Type))
+			return mounterapi.Operation{}, newRequestError(http.StatusConflict, mounterapi.CodeOperationInProgress,
+				"%s of %s is in progress, operation %s", op.Type, path, op.ID)
+		}
+		logger.Info("Attaching request to the operation in progress", zap.String("path", path), zap.String("operation", op.ID))
+		return op.Operation, nil
+	}
+
+	op := &operation{
+		Operation: mounterapi.Operation{
+			ID:        newOperationID(),
+			Type:      kind,
+			Path:      path,
+			State:     mounterapi.OperationRunning,
+			StartedAt: time.Now(),
+		},
+		done: make(chan struct{}),
+	}
+	t.operations[op.ID] = op
+	t.inFlight[path] = op
+	logger.Info("Started operation", zap.String("operation", op.ID), zap.String("type", kind), zap.String("path", path))
+
+	go func() {
+		failure := run()
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		finishedAt := time.Now()
+		op.FinishedAt = &finishedAt
+		op.State = mounterapi.OperationSucceeded
+		if failure != nil {
+			op.State = mounterapi.OperationFailed
+			op.Error = failure.message
+			op.Code = failure.code
+		}
+		delete(t.inFlight, path)
+		close(op.done)
+		logger.Info("Operation completed", zap.String("operation", op.ID), zap.String("state", string(op.State)))
+	}()
+	return op.Operation, nil
+}
+
+// Wait returns the operation id once it completed or after timeout. The operation is not found if it is unknown
+// or completed longer than the retention ago.
+func (t *OperationTracker) Wait(ctx context.Context, id string, timeout time.Duration) (mounterapi.Operation, bool) {
+	t.mu.Lock()
+	t.purge(time.Now())
+	op, ok := t.operations[id]
+	t.mu.Unlock()
+	if !ok {
+		return mounterapi.Operation{}, false
+	}
+
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-op.done:
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return op.Operation, true
+}
+
+// Drain waits for the operations in progress to complete, it fails if ctx is done first
+func (t *OperationTracker) Drain(ctx context.Context) error {
+	for {
+		t.mu.Lock()
+		pending := len(t.inFlight)
+		var op *operation
+		for _, op = range t.inFlight {
+			break
+		}
+		t.mu.Unlock()
+		if op == nil {
+			return nil
+		}
+
+		select {
+		case <-op.done:
+		case <-ctx.Done():
+			return fmt.Errorf("%d operations in progress: %w", pending, ctx.Err())
+		}
+	}
+}
+
+// purge forgets the operations completed longer than the retention ago, t.mu must be held
+func (t *OperationTracker) purge(now time.Time) {
+	for id, op := range t.operations {
+		if op.FinishedAt != nil && now.Sub(*op.FinishedAt) > t.retention {
+			delete(t.operations, id)
+		}
+	}
+}
+
+func newOperationID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b) // #nosec G104: crypto/rand.Read never returns an error
+	return hex.EncodeToString(b)
+}
+
+// handleMountOperation validates a mount request and starts the mount, it serves V2 clients
+func handleMountOperation(mounter mounterUtils.MounterUtils, parser MounterArgsParser, tracker *OperationTracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request MountRequest
+		start := time.Now()
+
+		if err := c.BindJSON(&request); err != nil {
+			logger.Error("invalid request: ", zap.Error(err))
+			failure := newRequestError(http.StatusBadRequest, mounterapi.CodeInvalidRequest, "invalid request")
+			mounterMetrics.observeRequest("mount", request.Mounter, start, failure.reason())
+			failure.respond(c)
+			return
+		}
+
+		logger.Info("New mount request with values:", zap.String("Bucket", request.Bucket), zap.String("Path", request.Path), zap.String("Mounter", request.Mounter), zap.Any("Args", request.Args))
+
+		args, env, failure := validateMount(&request, parser)
+		if failure != nil {
+			mounterMetrics.observeRequest("mount", request.Mounter, start, failure.reason())
+			failure.respond(c)
+			return
+		}
+
+		op, failure := tracker.Start(mounterapi.OperationMount, request.Path, func() *requestError {
+			failure := doMount(mounter, request, args, env)
+			mounterMetrics.observeRequest("mount", request.Mounter, start, failure.reason())
+			return failure
+		})
+		if failure != nil {
+			failure.respond(c)
+			return
+		}
+		c.JSON(http.StatusAccepted, op)
+	}
+}
+
+// handleUnmountOperation starts an unmount, it serves V2 clients
+func handleUnmountOperation(mounter mounterUtils.MounterUtils, tracker *OperationTracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request mounterapi.UnmountRequest
+		start := time.Now()
+
+		if err := c.BindJSON(&request); err != nil {
+			logger.Error("invalid request: ", zap.Error(err))
+			failure := newRequestError(http.StatusBadRequest, mounterapi.CodeInvalidRequest, "invalid request")
+			mounterMetrics.observeRequest("unmount", "", start, failure.reason())
+			failure.respond(c)
+			return
+		}
+
+		logger.Info("New unmount request with values: ", zap.String("Path", request.Path))
+		if failure := validateUnmount(&request); failure != nil {
+			mounterMetrics.observeRequest("unmount", "", start, failure.reason())
+			failure.respond(c)
+			return
+		}
+		mounterName := mounterOf(request.Path)
+
+		op, failure := tracker.Start(mounterapi.OperationUnmount, request.Path, func() *requestError {
+			failure := doUnmount(context.Background(), mounter, request.Path, request.Timeout.Duration)
+			mounterMetrics.observeRequest("unmount", mounterName, start, failure.reason())
+			return failure
+		})
+		if failure != nil {
+			failure.respond(c)
+			return
+		}
+		c.JSON(http.StatusAccepted, op)
+	}
+}
+
+// handleGetOperation returns an operation, waiting up to the wait query parameter for it to complete
+func handleGetOperation(tracker *OperationTracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var wait time.Duration
+		if value := c.Query("wait"); value != "" {
+			var err error
+			if wait, err = time.ParseDuration(value); err != nil || wait < 0 {
+				newRequestError(http.StatusBadRequest, mounterapi.CodeInvalidRequest, "invalid wait %q", value).respond(c)
+				return
+			}
+		}
+		wait = min(wait, config().MaxOperationWait.Duration)
+
+		id := c.Param("id")
+		op, ok := tracker.Wait(c.Request.Context(), id, wait)
+		if !ok {
+			newRequestError(http.StatusNotFound, mounterapi.CodeNotFound, "no operation %s", id).respond(c)
+			return
+		}
+		c.JSON(http.StatusOK, op)
+	}
+}
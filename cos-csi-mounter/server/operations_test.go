@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
+	mounterUtils "github.com/IBM/ibm-object-csi-driver/pkg/mounter/utils"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestOperationTracker(t *testing.T) {
+	tracker := NewOperationTracker(time.Minute)
+	release := make(chan struct{})
+	runs := 0
+	run := func() *requestError {
+		runs++
+		<-release
+		return nil
+	}
+
+	op, failure := tracker.Start(mounterapi.OperationMount, testMountPath, run)
+	assert.Nil(t, failure)
+	assert.Equal(t, mounterapi.OperationRunning, op.State)
+	assert.NotEmpty(t, op.ID)
+
+	// a retry attaches to the operation in progress
+	retry, failure := tracker.Start(mounterapi.OperationMount, testMountPath+"/", run)
+	assert.Nil(t, failure)
+	assert.Equal(t, op.ID, retry.ID)
+
+	// an unmount of the path is rejected until the mount completes
+	_, failure = tracker.Start(mounterapi.OperationUnmount, testMountPath, run)
+	assert.NotNil(t, failure)
+	assert.Equal(t, http.StatusConflict, failure.status)
+	assert.Equal(t, mounterapi.CodeOperationInProgress, failure.code)
+
+	got, ok := tracker.Wait(context.Background(), op.ID, 10*time.Millisecond)
+	assert.True(t, ok)
+	assert.Equal(t, mounterapi.OperationRunning, got.State)
+
+	close(release)
+	got, ok = tracker.Wait(context.Background(), op.ID, time.Minute)
+	assert.True(t, ok)
+	assert.Equal(t, mounterapi.OperationSucceeded, got.State)
+	assert.NotNil(t, got.FinishedAt)
+	assert.Equal(t, 1, runs)
+
+	// completed operations are forgotten after the retention
+	tracker.purge(time.Now().Add(2 * time.Minute))
+	_, ok = tracker.Wait(context.Background(), op.ID, 0)
+	assert.False(t, ok)
+}
+
+func TestOperationTracker_Failure(t *testing.T) {
+	tracker := NewOperationTracker(time.Minute)
+
+	op, _ := tracker.Start(mounterapi.OperationUnmount, testMountPath, func() *requestError {
+		return newRequestError(http.StatusInternalServerError, mounterapi.CodeUnmountFailed, "unmount failed :%s", "busy")
+	})
+	got, ok := tracker.Wait(context.Background(), op.ID, time.Minute)
+	assert.True(t, ok)
+	assert.Equal(t, mounterapi.OperationFailed, got.State)
+	assert.Equal(t, mounterapi.CodeUnmountFailed, got.Code)
+	assert.Equal(t, "unmount failed :busy", got.Error)
+
+	// the path is free again
+	_, failure := tracker.Start(mounterapi.OperationMount, testMountPath, func() *requestError { return nil })
+	assert.Nil(t, failure)
+}
+
+func TestHandleOperations(t *testing.T) {
+	stubMountChecks(t, 42, true, nil)
+	tracker := NewOperationTracker(time.Minute)
+
+	mockMounter := new(MockMounterUtils)
+	mockParser := new(MockMounterArgsParser)
+	mockParser.On("Parse", mock.Anything).Return([]string{"--endpoint=https://s3.test"}, nil)
+	mockMounter.On("FuseMountWithOptions", testMountPath, constants.RClone, []string{"--endpoint=https://s3.test"}, mounterUtils.MountOptions{}).Return(nil)
+	mockMounter.On("FuseUnmountWithContext", mock.Anything, testMountPath, 0).Return(errors.New("device or resource busy"))
+
+	router := gin.Default()
+	router.POST("/mount", handleMountOperation(mockMounter, mockParser, tracker))
+	router.POST("/unmount", handleUnmountOperation(mockMounter, tracker))
+	router.GET("/operations/:id", handleGetOperation(tracker))
+
+	poll := func(id string) mounterapi.Operation {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/operations/"+id+"?wait=1m", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var op mounterapi.Operation
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &op))
+		return op
+	}
+
+	body, _ := json.Marshal(MountRequest{Path: testMountPath, Bucket: testBucket, Mounter: constants.RClone, Args: json.RawMessage(`{}`)})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/mount", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	var op mounterapi.Operation
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &op))
+	assert.Equal(t, mounterapi.OperationMount, op.Type)
+	assert.Equal(t, mounterapi.OperationSucceeded, poll(op.ID).State)
+	mountRegistry.Remove(testMountPath)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/unmount", bytes.NewReader([]byte(`{"path":"`+testMountPath+`"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &op))
+	op = poll(op.ID)
+	assert.Equal(t, mounterapi.OperationFailed, op.State)
+	assert.Equal(t, mounterapi.CodeUnmountFailed, op.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/operations/unknown", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/operations/"+op.ID+"?wait=soon", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandleMountOperation_InvalidMounter(t *testing.T) {
+	tracker := NewOperationTracker(time.Minute)
+	router := gin.Default()
+	router.POST("/mount", handleMountOperation(new(MockMounterUtils), new(MockMounterArgsParser), tracker))
+
+	body, _ := json.Marshal(MountRequest{Path: testMountPath, Bucket: testBucket, Mounter: "goofys"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/mount", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response mounterapi.Response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, mounterapi.CodeInvalidMounter, response.Code)
+	assert.Empty(t, tracker.operations)
+}
+
+func TestOperationTracker_Drain(t *testing.T) {
+	tracker := NewOperationTracker(time.Minute)
+	assert.NoError(t, tracker.Drain(context.Background()))
+
+	release := make(chan struct{})
+	_, failure := tracker.Start(mounterapi.OperationMount, testMountPath, func() *requestError {
+		<-release
+		return nil
+	})
+	assert.Nil(t, failure)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, tracker.Drain(ctx), context.DeadlineExceeded)
+
+	close(release)
+	assert.NoError(t, tracker.Drain(context.Background()))
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type peerCredKey struct{}
+
+// PeerCredentials identify the process connected to the unix socket, as reported by SO_PEERCRED
+type PeerCredentials struct {
+	PID int32
+	UID uint32
+	GID uint32
+}
+
+var (
+	getPeerCredentials = peerCredentials
+	readProcCgroup     = func(pid int32) ([]byte, error) {
+		return os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	}
+)
+
+// peerCredentials returns the credentials of the process at the other end of a unix socket connection
+func peerCredentials(conn net.Conn) (*PeerCredentials, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return nil, fmt.Errorf("connection is not a unix socket connection: %T", conn)
+	}
+	rawConn, err := unixConn.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	var ucred *syscall.Ucred
+	var credErr error
+	if err = rawConn.Control(func(fd uintptr) {
+		ucred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil {
+		return nil, err
+	}
+	if credErr != nil {
+		return nil, credErr
+	}
+	return &PeerCredentials{PID: ucred.Pid, UID: ucred.Uid, GID: ucred.Gid}, nil
+}
+
+// peerCredContext is the ConnContext of the http server, it stores the credentials of the peer in the context
+// of every request received on the connection. Requests of peers whose credentials cannot be read are rejected.
+func peerCredContext(ctx context.Context, conn net.Conn) context.Context {
+	creds, err := getPeerCredentials(conn)
+	if err != nil {
+		logger.Warn("Cannot read peer credentials of connection", zap.Error(err))
+		return ctx
+	}
+	return context.WithValue(ctx, peerCredKey{}, creds)
+}
+
+// PeerAuthorizer decides which processes may call the mounter API
+type PeerAuthorizer struct {
+	// AllowedUIDs and AllowedGIDs are the accepted user and group IDs, a peer must match one of either list
+	AllowedUIDs map[uint32]bool
+	AllowedGIDs map[uint32]bool
+	// Cgroup, if set, must match a line of /proc/<pid>/cgroup of the peer, e.g. the cgroup of the node plugin container
+	Cgroup *regexp.Regexp
+}
+
+// NewPeerAuthorizer creates an authorizer from comma separated lists of user and group IDs and a cgroup regexp
+func NewPeerAuthorizer(uids, gids, cgroup string) (*PeerAuthorizer, error) {
+	authz := &PeerAuthorizer{}
+	var err error
+	if authz.AllowedUIDs, err = parseIDList(uids); err != nil {
+		return nil, fmt.Errorf("invalid allowed uids %q: %v", uids, err)
+	}
+	if authz.AllowedGIDs, err = parseIDList(gids); err != nil {
+		return nil, fmt.Errorf("invalid allowed gids %q: %v", gids, err)
+	}
+	if cgroup != "" {
+		if authz.Cgroup, err = regexp.Compile(cgroup); err != nil {
+			return nil, fmt.Errorf("invalid allowed cgroup %q: %v", cgroup, err)
+		}
+	}
+	return authz, nil
+}
+
+func parseIDList(list string) (map[uint32]bool, error) {
+	ids := map[uint32]bool{}
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(field, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		ids[uint32(id)] = true
+	}
+	return ids, nil
+}
+
+// Authorize returns an error describing why the peer is not allowed to call the mounter API
+func (a *PeerAuthorizer) Authorize(creds *PeerCredentials) error {
+	if creds == nil {
+		return errors.New("peer credentials are unknown")
+	}
+	if !a.AllowedUIDs[creds.UID] && !a.AllowedGIDs[creds.GID] {
+		return fmt.Errorf("uid %d and gid %d are not allowed", creds.UID, creds.GID)
+	}
+	if a.Cgroup == nil {
+		return nil
+	}
+	data, err := readProcCgroup(creds.PID)
+	if err != nil {
+		return fmt.Errorf("cannot read cgroup of pid %d: %v", creds.PID, err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" && a.Cgroup.MatchString(line) {
+			return nil
+		}
+	}
+	return fmt.Errorf("pid %d is not in an allowed cgroup", creds.PID)
+}
+
+// authorizePeer rejects requests of processes the authorizer does not allow before they reach the handlers
+func authorizePeer(authz *PeerAuthorizer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		creds, _ := c.Request.Context().Value(peerCredKey{}).(*PeerCredentials)
+		if err := authz.Authorize(creds); err != nil {
+			fields := []zap.Field{zap.String("method", c.Request.Method), zap.String("path", c.Request.URL.Path), zap.Error(err)}
+			if creds != nil {
+				fields = append(fields, zap.Int32("pid", creds.PID), zap.Uint32("uid", creds.UID), zap.Uint32("gid", creds.GID))
+			}
+			logger.Warn("Rejected unauthorized request", fields...)
+			c.AbortWithStatusJSON(http.StatusForbidden, mounterapi.ErrorResponse(mounterapi.CodeForbidden, "forbidden"))
+			return
+		}
+		logger.Debug("Authorized request", zap.String("path", c.Request.URL.Path),
+			zap.Int32("pid", creds.PID), zap.Uint32("uid", creds.UID), zap.Uint32("gid", creds.GID))
+		c.Next()
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeerCredentials_UnixSocket(t *testing.T) {
+	listener, err := net.Listen("unix", filepath.Join(t.TempDir(), "test.sock"))
+	assert.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+
+	client, err := net.Dial("unix", listener.Addr().String())
+	assert.NoError(t, err)
+	defer func() { _ = client.Close() }()
+
+	conn, err := listener.Accept()
+	assert.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	creds, err := peerCredentials(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(os.Getpid()), creds.PID)
+	assert.Equal(t, uint32(os.Getuid()), creds.UID)
+	assert.Equal(t, uint32(os.Getgid()), creds.GID)
+
+	ctx := peerCredContext(context.Background(), conn)
+	assert.Equal(t, creds, ctx.Value(peerCredKey{}))
+}
+
+func TestPeerCredentials_NotUnixSocket(t *testing.T) {
+	server, client := net.Pipe()
+	defer func() { _ = server.Close(); _ = client.Close() }()
+
+	_, err := peerCredentials(server)
+	assert.Error(t, err)
+
+	ctx := peerCredContext(context.Background(), server)
+	assert.Nil(t, ctx.Value(peerCredKey{}))
+}
+
+func TestNewPeerAuthorizer(t *testing.T) {
+	authz, err := NewPeerAuthorizer("0, 1000", "", "kubepods")
+	assert.NoError(t, err)
+	assert.Equal(t, map[uint32]bool{0: true, 1000: true}, authz.AllowedUIDs)
+	assert.Empty(t, authz.AllowedGIDs)
+	assert.NotNil(t, authz.Cgroup)
+
+	_, err = NewPeerAuthorizer("root", "", "")
+	assert.Error(t, err)
+	_, err = NewPeerAuthorizer("0", "-1", "")
+	assert.Error(t, err)
+	_, err = NewPeerAuthorizer("0", "", "[")
+	assert.Error(t, err)
+}
+
+func TestPeerAuthorizer_Authorize(t *testing.T) {
+	original := readProcCgroup
+	defer func() { readProcCgroup = original }()
+	readProcCgroup = func(pid int32) ([]byte, error) {
+		if pid == 1 {
+			return nil, errors.New("no such file or directory")
+		}
+		return []byte("0::/kubepods.slice/kubepods-besteffort.slice/cri-containerd-abc.scope\n"), nil
+	}
+
+	authz, err := NewPeerAuthorizer("0", "2000", "")
+	assert.NoError(t, err)
+	assert.NoError(t, authz.Authorize(&PeerCredentials{PID: 10, UID: 0, GID: 0}))
+	assert.NoError(t, authz.Authorize(&PeerCredentials{PID: 10, UID: 1000, GID: 2000}))
+	assert.Error(t, authz.Authorize(&PeerCredentials{PID: 10, UID: 1000, GID: 1000}))
+	assert.Error(t, authz.Authorize(nil))
+
+	authz, err = NewPeerAuthorizer("0", "", "cri-containerd-abc")
+	assert.NoError(t, err)
+	assert.NoError(t, authz.Authorize(&PeerCredentials{PID: 10}))
+	assert.Error(t, authz.Authorize(&PeerCredentials{PID: 1}))
+
+	authz, err = NewPeerAuthorizer("0", "", "cri-containerd-def")
+	assert.NoError(t, err)
+	assert.Error(t, authz.Authorize(&PeerCredentials{PID: 10}))
+}
+
+func TestAuthorizePeer(t *testing.T) {
+	authz, err := NewPeerAuthorizer("0", "", "")
+	assert.NoError(t, err)
+
+	router := gin.Default()
+	router.POST("/mount", authorizePeer(authz), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "success"})
+	})
+
+	testCases := []struct {
+		name         string
+		creds        *PeerCredentials
+		expectedCode int
+	}{
+		{name: "allowed uid", creds: &PeerCredentials{PID: 10, UID: 0}, expectedCode: http.StatusOK},
+		{name: "denied uid", creds: &PeerCredentials{PID: 10, UID: 1000, GID: 1000}, expectedCode: http.StatusForbidden},
+		{name: "unknown peer", expectedCode: http.StatusForbidden},
+	}
+	for _, tc := range testCases {
+		ctx := context.Background()
+		if tc.creds != nil {
+			ctx = context.WithValue(ctx, peerCredKey{}, tc.creds)
+		}
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequestWithContext(ctx, "POST", "/mount", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tc.expectedCode, w.Code, tc.name)
+	}
+}
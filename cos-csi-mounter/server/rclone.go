@@ -12,7 +12,9 @@ type RCloneArgs struct {
 	AllowRoot             string `json:"allow-root,omitempty"`
 	AsyncRead             string `json:"async-read,omitempty"`
 	AttrTimeout           string `json:"attr-timeout,omitempty"`
+	CacheDir              string `json:"cache-dir,omitempty"`
 	ConfigPath            string `json:"config,omitempty"`
+	Context               string `json:"context,omitempty"`
 	Daemon                string `json:"daemon,omitempty"`
 	DaemonTimeout         string `json:"daemon-timeout,omitempty"`
 	DaemonWait            string `json:"daemon-wait,omitempty"`
@@ -44,6 +46,11 @@ type RCloneArgs struct {
 }
 
 func (args RCloneArgs) PopulateArgsSlice(bucket, targetPath string) ([]string, error) {
+	// rclone logs to the log of the mount, whatever file the request names
+	if args.LogFile != "" {
+		args.LogFile = mountLogs.File(targetPath)
+	}
+
 	// Marshal to JSON
 	raw, err := json.Marshal(args)
 	if err != nil {
@@ -59,6 +66,11 @@ func (args RCloneArgs) PopulateArgsSlice(bucket, targetPath string) ([]string, e
 	// Convert to key=value slice
 	result := []string{"mount", bucket, targetPath}
 	for k, v := range m {
+		if k == "context" {
+			// the SELinux context is a libfuse option, rclone passes --option values to libfuse
+			result = append(result, "--option="+fuseContextOption(v))
+			continue
+		}
 		result = append(result, fmt.Sprintf("--%s=%v", k, v)) // --key=value
 	}
 
@@ -94,6 +106,16 @@ func (args RCloneArgs) Validate(targetPath string) error {
 		}
 	}
 
+	// Check if cache-dir is an absolute path
+	if err := cacheDirValidator("cache-dir", args.CacheDir); err != nil {
+		return err
+	}
+
+	// Check if context parameter is a valid SELinux context
+	if err := seLinuxContextValidator(args.Context); err != nil {
+		return err
+	}
+
 	// Check if rclone config file exists or not
 	if exists, err := FileExists(args.ConfigPath); err != nil {
 		logger.Error("error checking rclone config file existence")
@@ -19,6 +19,17 @@ func TestRClonePopulateArgsSlice_Success(t *testing.T) {
 	assert.Equal(t, expectedVal, resp)
 }
 
+func TestRClonePopulateArgsSlice_Context(t *testing.T) {
+	args := RCloneArgs{
+		Context: `"system_u:object_r:container_file_t:s0:c15,c26"`,
+	}
+
+	resp, err := args.PopulateArgsSlice(testBucket, testTargetPath)
+	assert.NoError(t, err)
+	expectedVal := []string{"mount", testBucket, testTargetPath, `--option=context="system_u:object_r:container_file_t:s0:c15\,c26"`}
+	assert.Equal(t, expectedVal, resp)
+}
+
 func TestRCloneValidate_Success(t *testing.T) {
 	FileExists = func(path string) (bool, error) {
 		return true, nil
@@ -41,6 +52,8 @@ func TestRCloneValidate_InvalidS3FSParamValues(t *testing.T) {
 		"AllowOther",
 		"AllowRoot",
 		"AsyncRead",
+		"CacheDir",
+		"Context",
 		"Daemon",
 		"DirectIO",
 		"NoModificationTime",
@@ -14,11 +14,14 @@ type S3FSArgs struct {
 	AutoCache               string `json:"auto_cache,omitempty"`
 	CipherSuites            string `json:"cipher_suites,omitempty"`
 	ConnectTimeoutSeconds   string `json:"connect_timeout,omitempty"`
+	Context                 string `json:"context,omitempty"`
 	CurlDebug               string `json:"curldbg,omitempty"`
 	DebugLevel              string `json:"dbglevel,omitempty"`
 	DefaultACL              string `json:"default_acl,omitempty"`
+	DelCache                string `json:"del_cache,omitempty"`
 	DisableNoobjCache       string `json:"disable_noobj_cache,omitempty"`
 	EndPoint                string `json:"endpoint,omitempty"`
+	EnsureDiskFree          string `json:"ensure_diskfree,omitempty"`
 	GID                     string `json:"gid,omitempty"`
 	IBMIamAuth              string `json:"ibm_iam_auth,omitempty"`
 	IBMIamEndpoint          string `json:"ibm_iam_endpoint,omitempty"`
@@ -38,9 +41,11 @@ type S3FSArgs struct {
 	SigV2                   string `json:"sigv2,omitempty"`
 	SigV4                   string `json:"sigv4,omitempty"`
 	StatCacheExpireSeconds  string `json:"stat_cache_expire,omitempty"`
+	TmpDir                  string `json:"tmpdir,omitempty"`
 	UID                     string `json:"uid,omitempty"`
 	Umask                   string `json:"umask,omitempty"`
 	URL                     string `json:"url,omitempty"`
+	UseCache                string `json:"use_cache,omitempty"`
 	UsePathRequestStyle     string `json:"use_path_request_style,omitempty"`
 	UseXattr                string `json:"use_xattr,omitempty"`
 	// AddMountParam allows passing additional s3fs mount options as comma-separated string
@@ -90,6 +95,10 @@ func (args S3FSArgs) PopulateArgsSlice(bucket, targetPath string) ([]string, err
 		if k == "cipher_suites" && strings.ToLower(strings.TrimSpace(v)) == "default" {
 			continue
 		}
+		if k == "context" {
+			result = append(result, "-o", fuseContextOption(v))
+			continue
+		}
 		result = append(result, "-o")
 		if strings.ToLower(strings.TrimSpace(v)) == "true" {
 			result = append(result, k) // -o, key
@@ -150,6 +159,14 @@ func (args S3FSArgs) Validate(targetPath string) error {
 		return fmt.Errorf("invalid value for 'curldbg' param. Should be either 'body' or 'normal': %v", args.CurlDebug)
 	}
 
+	// Check if value of del_cache is boolean "true" or "false"
+	if args.DelCache != "" {
+		if isBool := isBoolString(args.DelCache); !isBool {
+			logger.Error("cannot convert value of del_cache into boolean", zap.Any("del_cache", args.DelCache))
+			return fmt.Errorf("cannot convert value of del_cache into boolean: %v", args.DelCache)
+		}
+	}
+
 	// Check if value of disable_noobj_cache is boolean "true" or "false"
 	if args.DisableNoobjCache != "" {
 		if isBool := isBoolString(args.DisableNoobjCache); !isBool {
@@ -211,6 +228,15 @@ func (args S3FSArgs) Validate(targetPath string) error {
 		}
 	}
 
+	// Check if value of ensure_diskfree parameter can be converted to integer
+	if args.EnsureDiskFree != "" {
+		_, err := strconv.Atoi(args.EnsureDiskFree)
+		if err != nil {
+			logger.Error("cannot convert value of ensure_diskfree into integer", zap.Error(err))
+			return fmt.Errorf("cannot convert value of ensure_diskfree into integer: %v", err)
+		}
+	}
+
 	// Check if value of max_stat_cache_size parameter can be converted to integer
 	if args.MaxStatCacheSize != "" {
 		_, err := strconv.Atoi(args.MaxStatCacheSize)
@@ -331,6 +357,19 @@ func (args S3FSArgs) Validate(targetPath string) error {
 		}
 	}
 
+	// Check that the cache and temporary directories are absolute paths
+	if err := cacheDirValidator("use_cache", args.UseCache); err != nil {
+		return err
+	}
+	if err := cacheDirValidator("tmpdir", args.TmpDir); err != nil {
+		return err
+	}
+
+	// Check if context parameter is a valid SELinux context
+	if err := seLinuxContextValidator(args.Context); err != nil {
+		return err
+	}
+
 	// Check if value of use_xattr parameter is boolean "true" or "false"
 	if args.UseXattr != "" {
 		if isBool := isBoolString(args.UseXattr); !isBool {
@@ -12,7 +12,7 @@ import (
 
 var (
 	testBucket         = "testBucket"
-	testTargetPath     = "/var/data/kubelet/pods/"
+	testTargetPath     = "/var/data/kubelet/pods/pod-uid/volumes/kubernetes.io~csi/pv/mount"
 	testEndPoint       = "testEndPoint"
 	testIAMEndpoint    = "https://test.iam.cloud.ibm.com"
 	testPasswdFilePath = "testPasswdFilePath"
@@ -35,6 +35,17 @@ func TestS3FSPopulateArgsSlice_Success(t *testing.T) {
 	assert.Equal(t, expectedVal, resp)
 }
 
+func TestS3FSPopulateArgsSlice_Context(t *testing.T) {
+	args := S3FSArgs{
+		Context: `"system_u:object_r:container_file_t:s0:c15,c26"`,
+	}
+
+	resp, err := args.PopulateArgsSlice(testBucket, testTargetPath)
+	assert.NoError(t, err)
+	expectedVal := []string{testBucket, testTargetPath, "-o", `context="system_u:object_r:container_file_t:s0:c15\,c26"`}
+	assert.Equal(t, expectedVal, resp)
+}
+
 func TestS3FSValidate_Success(t *testing.T) {
 	FileExists = func(path string) (bool, error) {
 		return true, nil
@@ -60,7 +71,10 @@ func TestS3FSValidate_InvalidS3FSParamValues(t *testing.T) {
 		"AllowOther",
 		"AutoCache",
 		"ConnectTimeoutSeconds",
+		"Context",
 		"CurlDebug",
+		"DelCache",
+		"EnsureDiskFree",
 		"GID",
 		"IBMIamAuth",
 		"IBMIamEndpoint",
@@ -77,7 +91,9 @@ func TestS3FSValidate_InvalidS3FSParamValues(t *testing.T) {
 		"SigV2",
 		"SigV4",
 		"StatCacheExpireSeconds",
+		"TmpDir",
 		"UID",
+		"UseCache",
 		"UsePathRequestStyle",
 		"UseXattr",
 		"URL",
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"net"
@@ -14,6 +16,7 @@ import (
 
 	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
 	mounterUtils "github.com/IBM/ibm-object-csi-driver/pkg/mounter/utils"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -29,6 +32,23 @@ var (
 
 	Version   = "dev"
 	GitCommit = "none"
+
+	// Callers of the mounter API are authorized with the credentials of their socket connection
+	allowedUIDs   = flag.String("allowed-uids", "0", "Comma separated user IDs of processes allowed to call the mounter API")
+	allowedGIDs   = flag.String("allowed-gids", "", "Comma separated group IDs of processes allowed to call the mounter API")
+	allowedCgroup = flag.String("allowed-cgroup", "", "Regular expression a line of /proc/<pid>/cgroup of the caller must match, e.g. the cgroup of the node plugin container")
+
+	metricsAddress = flag.String("metrics-address", "", "Address of the Prometheus metrics listener, e.g. :9101, metrics are not served if empty")
+
+	// The output of the mounter process of each mount is kept in a file of mount-log-dir
+	mountLogDir        = flag.String("mount-log-dir", defaultMountLogDir, "Directory of the logs of the mounter processes")
+	mountLogMaxSize    = flag.Int64("mount-log-max-size", defaultMountLogMaxSize, "Size in bytes above which the log of a mount is rotated")
+	mountLogMaxBackups = flag.Int("mount-log-max-backups", defaultMountLogMaxBackups, "Number of rotated logs kept per mount")
+	mountLogRetention  = flag.Duration("mount-log-retention", defaultMountLogRetention, "How long the log of an unmounted path is kept after it was last written")
+
+	// The credentials written by the node server for the mounters are kept in memory
+	configTmpfs     = flag.Bool("config-tmpfs", true, "Mount a tmpfs on the directory of the mounter credentials so that they are not written to disk")
+	configTmpfsSize = flag.String("config-tmpfs-size", defaultConfigTmpfsSize, "Size of the tmpfs of the mounter credentials")
 )
 
 func init() {
@@ -43,7 +63,6 @@ func init() {
 
 func setUpLogger() *zap.Logger {
 	// Prepare a new logger
-	atom := zap.NewAtomicLevel()
 	encoderCfg := zap.NewProductionEncoderConfig()
 	encoderCfg.TimeKey = "timestamp"
 	encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
@@ -51,9 +70,8 @@ func setUpLogger() *zap.Logger {
 	logger := zap.New(zapcore.NewCore(
 		zapcore.NewJSONEncoder(encoderCfg),
 		zapcore.Lock(os.Stdout),
-		atom,
+		logLevel,
 	), zap.AddCaller()).With(zap.String("ServiceName", "cos-csi-mounter"))
-	atom.SetLevel(zap.InfoLevel)
 	return logger
 }
 
@@ -63,11 +81,12 @@ func isInvalidSync(err error) bool {
 }
 
 func setupSocket() (net.Listener, error) {
-	socketPath := filepath.Join(constants.SocketDir, constants.SocketFile)
+	socketPath := config().SocketPath
+	socketDir := filepath.Dir(socketPath)
 
 	// Ensure the socket directory exists
-	if err := MakeDir(constants.SocketDir, 0750); err != nil {
-		logger.Error("Failed to create socket directory", zap.String("dir", constants.SocketDir), zap.Error(err))
+	if err := MakeDir(socketDir, 0750); err != nil {
+		logger.Error("Failed to create socket directory", zap.String("dir", socketDir), zap.Error(err))
 		return nil, err
 	}
 
@@ -89,34 +108,72 @@ func setupSocket() (net.Listener, error) {
 	return listener, nil
 }
 
-func handleSignals() {
-	// Handle SIGINT and SIGTERM signals to gracefully shut down the server
+// handleSignals shuts the server down gracefully on SIGINT and SIGTERM, cancelling the requests waiting for
+// operations and closing done once the service is drained
+func handleSignals(server *http.Server, cancelRequests context.CancelFunc, done chan<- struct{}) {
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
 
 	go func() {
-		<-signals
-		socketPath := filepath.Join(constants.SocketDir, constants.SocketFile)
-		if err := os.Remove(socketPath); err != nil {
-			// Handle it properly: log it, retry, return, etc.
-			logger.Warn("Failed to remove socket on exit", zap.String("path", socketPath), zap.Error(err))
-		}
-		os.Exit(0)
+		sig := <-signals
+		logger.Info("Shutting down cos-csi-mounter", zap.String("signal", sig.String()))
+		shutdown(server, cancelRequests, operationTracker, mountRegistry)
+		close(done)
 	}()
 }
 
-func newRouter() *gin.Engine {
-	utils := &mounterUtils.MounterOptsUtils{}
+// shutdown stops accepting requests, waits for the requests and operations in progress for at most the shutdown
+// timeout and saves the mounts. The mounter processes are left running, the mounts outlive the service.
+func shutdown(server *http.Server, cancelRequests context.CancelFunc, tracker *OperationTracker, registry *MountRegistry) {
+	cfg := config()
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout.Duration)
+	defer cancel()
+
+	// polls of operations return their current state rather than waiting for them
+	cancelRequests()
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Warn("Requests still in progress at shutdown", zap.Error(err))
+	}
+	if err := tracker.Drain(ctx); err != nil {
+		logger.Warn("Operations still in progress at shutdown", zap.Error(err))
+	}
+	if err := registry.Save(cfg.StateFile); err != nil {
+		logger.Error("Failed to save the mounts", zap.String("file", cfg.StateFile), zap.Error(err))
+	}
+	if err := os.Remove(cfg.SocketPath); err != nil && !os.IsNotExist(err) {
+		logger.Warn("Failed to remove socket on exit", zap.String("path", cfg.SocketPath), zap.Error(err))
+	}
+	logger.Info("cos-csi-mounter stopped", zap.Int("mounts", len(registry.List())))
+}
+
+func newRouter(authz *PeerAuthorizer) *gin.Engine {
+	utils := &mounterUtils.MounterOptsUtils{Output: mountLogs.Open}
 	parser := &DefaultMounterArgsParser{}
 
 	// Create gin router
 	router := gin.Default()
-	router.POST("/api/cos/mount", handleCosMount(utils, parser))
-	router.POST("/api/cos/unmount", handleCosUnmount(utils))
+	router.GET(mounterapi.VersionPath, authorizePeer(authz), handleVersion)
+	router.GET(mounterapi.HealthPath, authorizePeer(authz), handleHealth)
+
+	// V1 mounts and unmounts synchronously, its clients ignore the error codes of the responses
+	v1 := router.Group(mounterapi.V1BasePath, authorizePeer(authz))
+	v1.POST("/mount", handleCosMount(utils, parser))
+	v1.POST("/unmount", handleCosUnmount(utils))
+	v1.GET("/mounts", handleListMounts(utils, mountRegistry))
+	v1.GET("/mounts/*path", handleGetMount(utils, mountRegistry))
+
+	v2 := router.Group(mounterapi.V2BasePath, authorizePeer(authz))
+	v2.POST("/mount", handleMountOperation(utils, parser, operationTracker))
+	v2.POST("/unmount", handleUnmountOperation(utils, operationTracker))
+	v2.GET("/operations/:id", handleGetOperation(operationTracker))
+	v2.GET("/mounts", handleListMounts(utils, mountRegistry))
+	v2.GET("/mounts/*path", handleGetMount(utils, mountRegistry))
+	v2.GET("/logs/*path", handleGetMountLogs(mountLogs))
 	return router
 }
 
-func startService(setupSocketFunc func() (net.Listener, error), router http.Handler, handleSignalsFunc func()) error {
+func startService(setupSocketFunc func() (net.Listener, error), router http.Handler,
+	handleSignalsFunc func(*http.Server, context.CancelFunc, chan<- struct{})) error {
 	listener, err := setupSocketFunc()
 	if err != nil {
 		logger.Error("Failed to create socket", zap.Error(err))
@@ -124,24 +181,30 @@ func startService(setupSocketFunc func() (net.Listener, error), router http.Hand
 	}
 	// Close the listener at the end
 	defer func() {
-		if err := listener.Close(); err != nil {
+		if err := listener.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
 			fmt.Fprintf(os.Stderr, "failed to close listener: %v\n", err)
 		}
 	}()
 
-	handleSignalsFunc()
-
 	logger.Info("Starting cos-csi-mounter service...")
 
 	// Serve HTTP requests over Unix socket
+	requestsCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
 	server := &http.Server{
 		Handler:           router,
-		ReadHeaderTimeout: 3 * time.Second,
+		ReadHeaderTimeout: config().ReadHeaderTimeout.Duration,
+		ConnContext:       peerCredContext,
+		BaseContext:       func(net.Listener) context.Context { return requestsCtx },
 	}
-	if err := server.Serve(listener); err != nil {
+	done := make(chan struct{})
+	handleSignalsFunc(server, cancelRequests, done)
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		logger.Error("Error while serving HTTP requests:", zap.Error(err))
 		return err
 	}
+	<-done
 	return nil
 }
 
@@ -150,80 +213,278 @@ func main() {
 		fmt.Printf("Version: %s\nGit Commit: %s\n", Version, GitCommit)
 		return
 	}
-	err := startService(setupSocket, newRouter(), handleSignals)
+	if len(os.Args) > 1 && os.Args[1] == constants.Native {
+		if err := runNativeMount(os.Args[2:]); err != nil {
+			logger.Error("native mount exited with error", zap.Error(err))
+			os.Exit(1)
+		}
+		return
+	}
+	flag.Parse()
+	flagsSet := setFlags()
+	cfg, err := loadConfig(*configFile, flagsSet)
+	if err != nil {
+		logger.Error("cannot load the configuration", zap.Error(err))
+		os.Exit(1)
+	}
+	applyConfig(cfg)
+	credentialsRoot = cfg.ConfigDir
+	handleReloads(*configFile, flagsSet)
+
+	authz, err := NewPeerAuthorizer(*allowedUIDs, *allowedGIDs, *allowedCgroup)
+	if err != nil {
+		logger.Error("invalid authorization settings", zap.Error(err))
+		os.Exit(1)
+	}
+	if err = prepareCredentialsRoot(credentialsRoot, *configTmpfs, *configTmpfsSize); err != nil {
+		logger.Error("cannot prepare the directory of the mounter credentials", zap.Error(err))
+		os.Exit(1)
+	}
+	if err = mountRegistry.Restore(cfg.StateFile); err != nil {
+		logger.Warn("cannot restore the mounts of the previous run", zap.Error(err))
+	}
+	if err = mountRegistry.Persist(cfg.StateFile); err != nil {
+		logger.Warn("cannot save the mounts", zap.String("file", cfg.StateFile), zap.Error(err))
+	}
+	mounterUtils.OnUnmountAttempt = mounterMetrics.observeUnmountAttempt
+	if err = becomeSubreaper(); err != nil {
+		logger.Warn("cannot become the subreaper of the mounters, their processes are not reaped", zap.Error(err))
+	}
+	go mountRegistry.Reap(time.Minute)
+	mountLogs = NewMountLogs(*mountLogDir, *mountLogMaxSize, *mountLogMaxBackups, *mountLogRetention)
+	go mountLogs.Maintain(mountRegistry, time.Minute)
+	if *metricsAddress != "" {
+		serveMetrics(*metricsAddress, metricsRegistry)
+	}
+	err = startService(setupSocket, newRouter(authz), handleSignals)
 	if err != nil {
 		logger.Error("cos-csi-mounter exited with error", zap.Error(err))
 		os.Exit(1)
 	}
 }
 
-func handleCosMount(mounter mounterUtils.MounterUtils, parser MounterArgsParser) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		var request MountRequest
+// handleVersion lets clients negotiate the API version
+func handleVersion(c *gin.Context) {
+	c.JSON(http.StatusOK, mounterapi.VersionInfo{
+		APIVersions: mounterapi.SupportedVersions,
+		Version:     Version,
+		GitCommit:   GitCommit,
+	})
+}
 
-		if err := c.BindJSON(&request); err != nil {
-			logger.Error("invalid request: ", zap.Error(err))
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
-			return
-		}
+// requestError is the failure of a request, answered with status and a mounterapi.Response
+type requestError struct {
+	status  int
+	code    mounterapi.ErrorCode
+	message string
+}
 
-		logger.Info("New mount request with values:", zap.String("Bucket", request.Bucket), zap.String("Path", request.Path), zap.String("Mounter", request.Mounter), zap.Any("Args", request.Args))
+func newRequestError(status int, code mounterapi.ErrorCode, format string, args ...any) *requestError {
+	return &requestError{status: status, code: code, message: fmt.Sprintf(format, args...)}
+}
 
-		if request.Mounter != constants.S3FS && request.Mounter != constants.RClone {
-			logger.Error("invalid mounter", zap.Any("mounter", request.Mounter))
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid mounter"})
-			return
+// reason labels the failure metrics, it is empty if the request did not fail
+func (e *requestError) reason() string {
+	if e == nil {
+		return ""
+	}
+	return string(e.code)
+}
+
+func (e *requestError) respond(c *gin.Context) {
+	c.JSON(e.status, mounterapi.Response{Error: e.message, Code: e.code})
+}
+
+// validateMount checks a mount request and returns the arguments and environment of the mounter
+func validateMount(request *MountRequest, parser MounterArgsParser) ([]string, []string, *requestError) {
+	if request.Mounter != constants.S3FS && request.Mounter != constants.RClone && request.Mounter != constants.MountpointS3 &&
+		request.Mounter != constants.Native {
+		logger.Error("invalid mounter", zap.Any("mounter", request.Mounter))
+		return nil, nil, newRequestError(http.StatusBadRequest, mounterapi.CodeInvalidMounter, "invalid mounter")
+	}
+
+	if !config().MounterAllowed(request.Mounter) {
+		logger.Error("mounter not permitted", zap.Any("mounter", request.Mounter))
+		return nil, nil, newRequestError(http.StatusForbidden, mounterapi.CodeForbidden, "mounter %s is not permitted on this node", request.Mounter)
+	}
+
+	if request.Bucket == "" {
+		logger.Error("missing bucket in request")
+		return nil, nil, newRequestError(http.StatusBadRequest, mounterapi.CodeInvalidRequest, "missing bucket")
+	}
+
+	if request.Readiness != nil {
+		if err := request.Readiness.Validate(); err != nil {
+			logger.Error("invalid mount readiness", zap.Any("readiness", request.Readiness), zap.Error(err))
+			return nil, nil, newRequestError(http.StatusBadRequest, mounterapi.CodeInvalidRequest, "invalid readiness: %v", err)
 		}
+	}
 
-		if request.Bucket == "" {
-			logger.Error("missing bucket in request")
-			c.JSON(http.StatusBadRequest, gin.H{"error": "missing bucket"})
-			return
+	// validate mounter args
+	args, err := parser.Parse(*request)
+	if err != nil {
+		logger.Error("failed to parse mounter args", zap.Any("mounter", request.Mounter), zap.Error(err))
+		return nil, nil, newRequestError(http.StatusBadRequest, mounterapi.CodeInvalidArgs, "invalid args for mounter: %v", err)
+	}
+
+	var env []string
+	if request.Mounter == constants.MountpointS3 {
+		env, err = request.MounterEnv()
+		if err != nil {
+			logger.Error("failed to build mounter environment", zap.Any("mounter", request.Mounter), zap.Error(err))
+			return nil, nil, newRequestError(http.StatusBadRequest, mounterapi.CodeInvalidArgs, "invalid args for mounter: %v", err)
 		}
+	}
+	return args, env, nil
+}
 
-		// validate mounter args
-		args, err := parser.Parse(request)
+// doMount mounts the bucket of a validated request
+func doMount(mounter mounterUtils.MounterUtils, request MountRequest, args, env []string) *requestError {
+	comm := request.Mounter
+	if request.Mounter == constants.MountpointS3 {
+		comm = constants.MountpointS3Binary
+	} else if request.Mounter == constants.Native {
+		var err error
+		comm, err = nativeMounterBinary()
 		if err != nil {
-			logger.Error("failed to parse mounter args", zap.Any("mounter", request.Mounter), zap.Error(err))
+			logger.Error("failed to find the native mounter binary", zap.Error(err))
+			wipeCredentials(request.Path)
+			return newRequestError(http.StatusInternalServerError, mounterapi.CodeMounterNotFound, "mount failed: %v", err)
+		}
+	}
+	opts := mounterUtils.MountOptions{Env: env}
+	if request.Readiness != nil {
+		opts.Readiness = *request.Readiness
+	}
+	err := mounter.FuseMountWithOptions(request.Path, comm, args, opts)
+	if err != nil {
+		logger.Error("mount failed: ", zap.Error(err))
+		wipeCredentials(request.Path)
+		code, message := mounterapi.CodeMountFailed, fmt.Sprintf("mount failed: %v", err)
+		output, tailErr := mountLogs.Tail(request.Path, mountFailureLogLines)
+		if tailErr != nil {
+			logger.Warn("Cannot read mounter output", zap.String("path", request.Path), zap.Error(tailErr))
+		}
+		if failure := mounterapi.ClassifyMountOutput(lastMountAttempt(output) + "\n" + err.Error()); failure != nil {
+			logger.Info("Classified mount failure", zap.String("path", request.Path), zap.String("code", string(failure.Code)))
+			code, message = failure.Code, fmt.Sprintf("mount failed, %s: %v", failure.Hint, err)
+		}
+		if output != "" {
+			message += "\nmounter output:\n" + output
+		}
+		return newRequestError(http.StatusInternalServerError, code, "%s", message)
+	}
+
+	mountRegistry.Add(request.Path, request.Bucket, request.Mounter, args)
+	logger.Info("bucket mount is successful", zap.Any("bucket", request.Bucket), zap.Any("path", request.Path))
+	return nil
+}
+
+// doUnmount unmounts path and stops its mounter process, for at most the unmount timeout or the timeout of the
+// request if it is shorter
+func doUnmount(ctx context.Context, mounter mounterUtils.MounterUtils, path string, timeout time.Duration) *requestError {
+	ctx, cancel := context.WithTimeout(ctx, unmountTimeout(timeout))
+	defer cancel()
+	var pid int
+	if record := mountRegistry.Get(path); record != nil {
+		pid = record.PID
+	}
+	err := mounter.FuseUnmountWithContext(ctx, path, pid)
+	if err != nil {
+		logger.Error("unmount failed: ", zap.Error(err))
+		return newRequestError(http.StatusInternalServerError, mounterapi.CodeUnmountFailed, "unmount failed :%v", err)
+	}
+
+	mountRegistry.Remove(path)
+	wipeCredentials(path)
+	logger.Info("bucket unmount is successful", zap.Any("path", path))
+	return nil
+}
+
+// unmountTimeout returns the timeout of the request if it is set and shorter than the unmount timeout of the
+// config, else the latter
+func unmountTimeout(requested time.Duration) time.Duration {
+	timeout := config().UnmountTimeout.Duration
+	if requested > 0 {
+		return min(requested, timeout)
+	}
+	return timeout
+}
+
+// validateUnmount checks that the path of an unmount request is the mount directory of a CSI volume
+func validateUnmount(request *mounterapi.UnmountRequest) *requestError {
+	if err := unmountPathValidator(request.Path); err != nil {
+		logger.Error("invalid unmount path", zap.String("path", request.Path), zap.Error(err))
+		return newRequestError(http.StatusBadRequest, mounterapi.CodeInvalidRequest, "invalid path: %v", err)
+	}
+	return nil
+}
+
+// mounterOf returns the mounter of the recorded mount of path, if any
+func mounterOf(path string) string {
+	if record := mountRegistry.Get(path); record != nil {
+		return record.Mounter
+	}
+	return ""
+}
+
+// handleCosMount mounts synchronously, it serves V1 clients
+func handleCosMount(mounter mounterUtils.MounterUtils, parser MounterArgsParser) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request MountRequest
+		var failure *requestError
+		start := time.Now()
+		defer func() { mounterMetrics.observeRequest("mount", request.Mounter, start, failure.reason()) }()
 
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid args for mounter: %v", err)})
+		if err := c.BindJSON(&request); err != nil {
+			logger.Error("invalid request: ", zap.Error(err))
+			failure = newRequestError(http.StatusBadRequest, mounterapi.CodeInvalidRequest, "invalid request")
+			failure.respond(c)
 			return
 		}
 
-		err = mounter.FuseMount(request.Path, request.Mounter, args)
-		if err != nil {
-			logger.Error("mount failed: ", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("mount failed: %v", err)})
+		logger.Info("New mount request with values:", zap.String("Bucket", request.Bucket), zap.String("Path", request.Path), zap.String("Mounter", request.Mounter), zap.Any("Args", request.Args))
+
+		var args, env []string
+		args, env, failure = validateMount(&request, parser)
+		if failure == nil {
+			failure = doMount(mounter, request, args, env)
+		}
+		if failure != nil {
+			failure.respond(c)
 			return
 		}
-
-		logger.Info("bucket mount is successful", zap.Any("bucket", request.Bucket), zap.Any("path", request.Path))
-		c.JSON(http.StatusOK, gin.H{"status": "success"})
+		c.JSON(http.StatusOK, mounterapi.Response{Status: "success"})
 	}
 }
 
+// handleCosUnmount unmounts synchronously, it serves V1 clients
 func handleCosUnmount(mounter mounterUtils.MounterUtils) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var request struct {
-			Path string `json:"path"`
-		}
+		var request mounterapi.UnmountRequest
+		var failure *requestError
+		mounterName := ""
+		start := time.Now()
+		defer func() { mounterMetrics.observeRequest("unmount", mounterName, start, failure.reason()) }()
 
 		if err := c.BindJSON(&request); err != nil {
 			logger.Error("invalid request: ", zap.Error(err))
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			failure = newRequestError(http.StatusBadRequest, mounterapi.CodeInvalidRequest, "invalid request")
+			failure.respond(c)
 			return
 		}
 
 		logger.Info("New unmount request with values: ", zap.String("Path", request.Path))
-
-		err := mounter.FuseUnmount(request.Path)
-		if err != nil {
-			logger.Error("unmount failed: ", zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("unmount failed :%v", err)})
+		if failure = validateUnmount(&request); failure != nil {
+			failure.respond(c)
 			return
 		}
-
-		logger.Info("bucket unmount is successful", zap.Any("path", request.Path))
-		c.JSON(http.StatusOK, gin.H{"status": "success"})
+		mounterName = mounterOf(request.Path)
+		// the mounter process is not killed early when the client goes away
+		if failure = doUnmount(context.WithoutCancel(c.Request.Context()), mounter, request.Path, request.Timeout.Duration); failure != nil {
+			failure.respond(c)
+			return
+		}
+		c.JSON(http.StatusOK, mounterapi.Response{Status: "success"})
 	}
 }
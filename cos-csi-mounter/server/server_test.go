@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,16 +15,19 @@ import (
 	"time"
 
 	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
+	mounterUtils "github.com/IBM/ibm-object-csi-driver/pkg/mounter/utils"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestSetupSocket_CreatesSocket(t *testing.T) {
 	// Use temp dir for socket dir
 	tmpDir := t.TempDir()
-	constants.SocketDir = tmpDir
-	constants.SocketFile = "test.sock"
-	socketPath := filepath.Join(constants.SocketDir, constants.SocketFile)
+	socketPath := filepath.Join(tmpDir, "test.sock")
+	useConfig(t, func(c *Config) { c.SocketPath = socketPath })
 
 	listener, err := setupSocket()
 	defer func() {
@@ -52,9 +56,8 @@ func TestSetupSocket_MkdirAllFails(t *testing.T) {
 
 func TestSetupSocket_FailsToCreateSocket(t *testing.T) {
 	tmpDir := t.TempDir()
-	constants.SocketDir = tmpDir
-	constants.SocketFile = "existing.sock"
-	socketPath := filepath.Join(tmpDir, constants.SocketFile)
+	socketPath := filepath.Join(tmpDir, "existing.sock")
+	useConfig(t, func(c *Config) { c.SocketPath = socketPath })
 
 	// Create a fake socket file
 	err := os.WriteFile(socketPath, []byte("fake"), 0600)
@@ -82,14 +85,7 @@ func TestSetupSocket_FailsToCreateSocket(t *testing.T) {
 
 func TestSetupSocket_StatSocketFileFails(t *testing.T) {
 	tmpDir := t.TempDir()
-	originalSocketDir := constants.SocketDir
-	originalSocketFile := constants.SocketFile
-	constants.SocketDir = tmpDir
-	constants.SocketFile = string([]byte{0x00}) // Invalid filename on Unix
-	defer func() {
-		constants.SocketDir = originalSocketDir
-		constants.SocketFile = originalSocketFile
-	}()
+	useConfig(t, func(c *Config) { c.SocketPath = filepath.Join(tmpDir, string([]byte{0x00})) }) // Invalid filename on Unix
 
 	// Call setupSocket and expect an error
 	listener, err := setupSocket()
@@ -98,10 +94,40 @@ func TestSetupSocket_StatSocketFileFails(t *testing.T) {
 }
 
 func TestNewRouter_HasExpectedRoutes(t *testing.T) {
-	router := newRouter()
+	router := newRouter(&PeerAuthorizer{})
 	assert.NotNil(t, router)
 }
 
+func TestNewRouter_ServesAPIVersions(t *testing.T) {
+	authz, err := NewPeerAuthorizer("0", "", "")
+	assert.NoError(t, err)
+	router := newRouter(authz)
+	ctx := context.WithValue(context.Background(), peerCredKey{}, &PeerCredentials{PID: 10, UID: 0})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, "GET", mounterapi.VersionPath, nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var info mounterapi.VersionInfo
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.Equal(t, []string{mounterapi.V2, mounterapi.V1}, info.APIVersions)
+	assert.Equal(t, Version, info.Version)
+
+	for _, basePath := range []string{mounterapi.V1BasePath, mounterapi.V2BasePath} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequestWithContext(ctx, "POST", basePath+"/unmount", bytes.NewBufferString("invalid-json"))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, basePath)
+
+		var response mounterapi.Response
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), basePath)
+		assert.Equal(t, mounterapi.CodeInvalidRequest, response.Code, basePath)
+		assert.Equal(t, "invalid request", response.Error, basePath)
+	}
+}
+
 func TestStartService(t *testing.T) {
 	// Create a channel to receive the error from the goroutine
 	errCh := make(chan error, 1)
@@ -232,7 +258,7 @@ func TestHandleCosMount_FuseMountFails(t *testing.T) {
 	expectedArgs := []string{"--endpoint=https://s3.example.com"}
 
 	mockParser.On("Parse", request).Return(expectedArgs, nil)
-	mockMounter.On("FuseMount", request.Path, request.Mounter, expectedArgs).Return(fmt.Errorf("mount error"))
+	mockMounter.On("FuseMountWithOptions", request.Path, request.Mounter, expectedArgs, mounterUtils.MountOptions{}).Return(fmt.Errorf("mount error"))
 
 	router := gin.Default()
 	router.POST("/mount", handleCosMount(mockMounter, mockParser))
@@ -265,7 +291,113 @@ func TestHandleCosMount_Success(t *testing.T) {
 	expectedArgs := []string{"--endpoint=https://s3.example.com"}
 
 	mockParser.On("Parse", request).Return(expectedArgs, nil)
-	mockMounter.On("FuseMount", request.Path, request.Mounter, expectedArgs).Return(nil)
+	mockMounter.On("FuseMountWithOptions", request.Path, request.Mounter, expectedArgs, mounterUtils.MountOptions{}).Return(nil)
+
+	router := gin.Default()
+	router.POST("/mount", handleCosMount(mockMounter, mockParser))
+
+	body, _ := json.Marshal(request)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/mount", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "success")
+
+	mockMounter.AssertExpectations(t)
+	mockParser.AssertExpectations(t)
+}
+
+func TestHandleCosMount_Readiness(t *testing.T) {
+	mockMounter := new(MockMounterUtils)
+	mockParser := new(MockMounterArgsParser)
+	router := gin.Default()
+	router.POST("/mount", handleCosMount(mockMounter, mockParser))
+	mount := func(request MountRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(request)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/mount", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	readiness := mounterapi.Readiness{Timeout: metav1.Duration{Duration: time.Minute}, Probe: mounterapi.ProbeList}
+	request := MountRequest{
+		Bucket:    "my-bucket",
+		Path:      "/mnt/test",
+		Mounter:   constants.S3FS,
+		Args:      json.RawMessage(`["--endpoint=https://s3.example.com"]`),
+		Readiness: &readiness,
+	}
+	expectedArgs := []string{"--endpoint=https://s3.example.com"}
+	mockParser.On("Parse", request).Return(expectedArgs, nil)
+	mockMounter.On("FuseMountWithOptions", request.Path, request.Mounter, expectedArgs, mounterUtils.MountOptions{Readiness: readiness}).Return(nil)
+
+	w := mount(request)
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockMounter.AssertExpectations(t)
+
+	request.Readiness = &mounterapi.Readiness{Probe: "write"}
+	w = mount(request)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid readiness")
+}
+
+func TestHandleCosMount_MountpointS3_Success(t *testing.T) {
+	mockMounter := new(MockMounterUtils)
+	mockParser := new(MockMounterArgsParser)
+
+	request := MountRequest{
+		Bucket:  "my-bucket",
+		Path:    "/mnt/test",
+		Mounter: constants.MountpointS3,
+		Args:    json.RawMessage(`{"credentials-file":"/var/lib/coscsi-config/abc/credentials"}`),
+	}
+
+	expectedArgs := []string{"my-bucket", "/mnt/test"}
+	expectedEnv := []string{"AWS_SHARED_CREDENTIALS_FILE=/var/lib/coscsi-config/abc/credentials", "AWS_PROFILE=default"}
+
+	mockParser.On("Parse", request).Return(expectedArgs, nil)
+	mockMounter.On("FuseMountWithOptions", request.Path, constants.MountpointS3Binary, expectedArgs, mounterUtils.MountOptions{Env: expectedEnv}).Return(nil)
+
+	router := gin.Default()
+	router.POST("/mount", handleCosMount(mockMounter, mockParser))
+
+	body, _ := json.Marshal(request)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/mount", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "success")
+
+	mockMounter.AssertExpectations(t)
+	mockParser.AssertExpectations(t)
+}
+
+func TestHandleCosMount_Native_Success(t *testing.T) {
+	defer func() { nativeMounterBinary = os.Executable }()
+	nativeMounterBinary = func() (string, error) { return "/usr/bin/cos-csi-mounter-server", nil }
+
+	mockMounter := new(MockMounterUtils)
+	mockParser := new(MockMounterArgsParser)
+
+	request := MountRequest{
+		Bucket:  "my-bucket",
+		Path:    "/mnt/test",
+		Mounter: constants.Native,
+		Args:    json.RawMessage(`{"auth-type":"hmac"}`),
+	}
+
+	expectedArgs := []string{constants.Native, "my-bucket", "/mnt/test", "--auth-type=hmac"}
+
+	mockParser.On("Parse", request).Return(expectedArgs, nil)
+	mockMounter.On("FuseMountWithOptions", request.Path, "/usr/bin/cos-csi-mounter-server", expectedArgs, mounterUtils.MountOptions{}).Return(nil)
 
 	router := gin.Default()
 	router.POST("/mount", handleCosMount(mockMounter, mockParser))
@@ -300,13 +432,13 @@ func TestHandleCosUnmount_InvalidJSON(t *testing.T) {
 }
 
 func TestHandleCosUnmount_UnmountFailure(t *testing.T) {
-	mock := new(MockMounterUtils)
-	mock.On("FuseUnmount", "/mnt/fail").Return(errors.New("mock failure"))
+	mockMounter := new(MockMounterUtils)
+	mockMounter.On("FuseUnmountWithContext", mock.Anything, testMountPath, 0).Return(errors.New("mock failure"))
 
 	router := gin.Default()
-	router.POST("/unmount", handleCosUnmount(mock))
+	router.POST("/unmount", handleCosUnmount(mockMounter))
 
-	reqBody := map[string]string{"path": "/mnt/fail"}
+	reqBody := map[string]string{"path": testMountPath}
 	body, _ := json.Marshal(reqBody)
 
 	w := httptest.NewRecorder()
@@ -317,17 +449,17 @@ func TestHandleCosUnmount_UnmountFailure(t *testing.T) {
 
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 	assert.Contains(t, w.Body.String(), "unmount failed")
-	mock.AssertExpectations(t)
+	mockMounter.AssertExpectations(t)
 }
 
 func TestHandleCosUnmount_Success(t *testing.T) {
-	mock := new(MockMounterUtils)
-	mock.On("FuseUnmount", "/mnt/success").Return(nil)
+	mockMounter := new(MockMounterUtils)
+	mockMounter.On("FuseUnmountWithContext", mock.Anything, testMountPath, 0).Return(nil)
 
 	router := gin.Default()
-	router.POST("/unmount", handleCosUnmount(mock))
+	router.POST("/unmount", handleCosUnmount(mockMounter))
 
-	reqBody := map[string]string{"path": "/mnt/success"}
+	reqBody := map[string]string{"path": testMountPath}
 	body, _ := json.Marshal(reqBody)
 
 	w := httptest.NewRecorder()
@@ -338,5 +470,103 @@ func TestHandleCosUnmount_Success(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "success")
-	mock.AssertExpectations(t)
+	mockMounter.AssertExpectations(t)
+}
+
+func TestHandleCosUnmount_InvalidPath(t *testing.T) {
+	mockMounter := new(MockMounterUtils)
+	router := gin.Default()
+	router.POST("/unmount", handleCosUnmount(mockMounter))
+
+	for _, path := range []string{"/", "/var/lib/kubelet", "/var/lib/kubelet/pods/pod-uid/volumes/kubernetes.io~csi/pv/mount/../../../../../.."} {
+		body, _ := json.Marshal(map[string]string{"path": path})
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/unmount", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, path)
+		assert.Contains(t, w.Body.String(), "invalid path", path)
+	}
+	mockMounter.AssertNotCalled(t, "FuseUnmountWithContext", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDoUnmount_MounterProcess(t *testing.T) {
+	stubMountChecks(t, 42, true, nil)
+	useConfig(t, func(c *Config) {
+		c.UnmountTimeout.Duration = 10 * time.Second
+	})
+	mountRegistry.Add(testMountPath, testBucket, constants.S3FS, nil)
+	t.Cleanup(func() { mountRegistry.Remove(testMountPath) })
+
+	// the unmount is bounded by the unmount timeout and stops the recorded mounter process
+	bounded := mock.MatchedBy(func(ctx context.Context) bool {
+		deadline, ok := ctx.Deadline()
+		return ok && time.Until(deadline) <= 10*time.Second
+	})
+	mockMounter := new(MockMounterUtils)
+	mockMounter.On("FuseUnmountWithContext", bounded, testMountPath, 42).Return(nil)
+
+	assert.Nil(t, doUnmount(context.Background(), mockMounter, testMountPath, 0))
+	assert.Nil(t, mountRegistry.Get(testMountPath))
+	mockMounter.AssertExpectations(t)
+}
+
+func TestDoUnmount_RequestTimeout(t *testing.T) {
+	stubMountChecks(t, 0, true, nil)
+	useConfig(t, func(c *Config) {
+		c.UnmountTimeout.Duration = time.Minute
+	})
+
+	// a shorter timeout of the request bounds the unmount
+	bounded := mock.MatchedBy(func(ctx context.Context) bool {
+		deadline, ok := ctx.Deadline()
+		return ok && time.Until(deadline) <= 5*time.Second
+	})
+	mockMounter := new(MockMounterUtils)
+	mockMounter.On("FuseUnmountWithContext", bounded, testMountPath, 0).Return(nil)
+	assert.Nil(t, doUnmount(context.Background(), mockMounter, testMountPath, 5*time.Second))
+	mockMounter.AssertExpectations(t)
+
+	// a longer one does not raise the unmount timeout
+	assert.Equal(t, time.Minute, unmountTimeout(time.Hour))
+	assert.Equal(t, time.Minute, unmountTimeout(0))
+}
+
+func TestShutdown(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "coscsi.sock")
+	stateFile := filepath.Join(t.TempDir(), "mounts.json")
+	useConfig(t, func(c *Config) {
+		c.SocketPath = socketPath
+		c.StateFile = stateFile
+		c.ShutdownTimeout.Duration = time.Minute
+	})
+	stubMountChecks(t, 42, true, nil)
+
+	listener, err := net.Listen("unix", socketPath)
+	assert.NoError(t, err)
+	server := &http.Server{Handler: fakeRouter(), ReadHeaderTimeout: time.Second}
+	go func() { _ = server.Serve(listener) }()
+
+	registry := NewMountRegistry()
+	tracker := NewOperationTracker(time.Minute)
+	mounted := false
+	_, failure := tracker.Start(mounterapi.OperationMount, testMountPath, func() *requestError {
+		time.Sleep(50 * time.Millisecond)
+		registry.Add(testMountPath, testBucket, constants.S3FS, nil)
+		mounted = true
+		return nil
+	})
+	assert.Nil(t, failure)
+
+	cancelled := false
+	shutdown(server, func() { cancelled = true }, tracker, registry)
+
+	// the mount in progress completed and was saved
+	assert.True(t, cancelled)
+	assert.True(t, mounted)
+	assert.NoFileExists(t, socketPath)
+	data, err := os.ReadFile(stateFile)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), testMountPath)
 }
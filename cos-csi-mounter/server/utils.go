@@ -6,27 +6,27 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"syscall"
 
 	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
+	mounterUtils "github.com/IBM/ibm-object-csi-driver/pkg/mounter/utils"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
+	"go.uber.org/zap"
 )
 
-// MountRequest ...
-type MountRequest struct {
-	Path    string          `json:"path"`
-	Bucket  string          `json:"bucket"`
-	Mounter string          `json:"mounter"`
-	Args    json.RawMessage `json:"args"`
-}
+// MountRequest is the mount request shared with the node server, see mounterapi.MountRequest
+type MountRequest mounterapi.MountRequest
 
 var (
-	// Directories where bucket can be mounted
-	safeMountDirs = []string{"/var/data/kubelet/pods", "/var/lib/kubelet/pods"}
-	// Directories where s3fs/rclone configuration files need to be present
-	safeMounterConfigDir = "/var/lib/coscsi-config"
-
 	FileExists      = fileExists
 	absPathResolver = filepath.Abs
+	evalSymlinks    = filepath.EvalSymlinks
+
+	// user:role:type followed by an optional MLS/MCS range such as s0:c15,c26 or s0-s0:c0.c1023
+	seLinuxContextRegex = regexp.MustCompile(`^[A-Za-z0-9_.]+:[A-Za-z0-9_.]+:[A-Za-z0-9_.]+` +
+		`(:s[0-9]+(:c[0-9]+([.,]c[0-9]+)*)?(-s[0-9]+(:c[0-9]+([.,]c[0-9]+)*)?)?)?$`)
 )
 
 // MounterArgs ...
@@ -45,17 +45,136 @@ func strictDecodeForUnknownFields(data json.RawMessage, v interface{}) error {
 	return dec.Decode(v)
 }
 
+// pathValidator checks that, once symlinks are resolved, the target path is the mount directory of a CSI volume of
+// a pod, <kubelet root>/pods/<pod uid>/volumes/kubernetes.io~csi/<pv>/mount
 func pathValidator(targetPath string) error {
+	resolved, err := resolvePath(targetPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve absolute mount path: %v", err)
+	}
+	return volumeMountDirValidator(targetPath, resolved)
+}
+
+// unmountPathValidator checks the target path of an unmount like pathValidator. Only the parent of the target path
+// is resolved, a broken FUSE mount on the target cannot be stat'ed, and the target may not be a symlink.
+func unmountPathValidator(targetPath string) error {
 	absPath, err := absPathResolver(targetPath)
 	if err != nil {
 		return fmt.Errorf("failed to resolve absolute mount path: %v", err)
 	}
-	if !strings.HasPrefix(absPath, safeMountDirs[0]) && !strings.HasPrefix(absPath, safeMountDirs[1]) {
-		return fmt.Errorf("bad value for target path \"%v\"", targetPath)
+	if info, err := os.Lstat(absPath); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf("target path \"%v\" is a symlink", targetPath)
+	}
+	parent, err := resolvePath(filepath.Dir(absPath))
+	if err != nil {
+		return fmt.Errorf("failed to resolve absolute mount path: %v", err)
+	}
+	return volumeMountDirValidator(targetPath, filepath.Join(parent, filepath.Base(absPath)))
+}
+
+// volumeMountDirValidator checks that resolved, the resolved targetPath, is the mount directory of a CSI volume
+func volumeMountDirValidator(targetPath, resolved string) error {
+	for _, dir := range config().MountDirs() {
+		podsDir, err := resolvePath(dir)
+		if err != nil {
+			logger.Warn("Cannot resolve mount directory", zap.String("dir", dir), zap.Error(err))
+			continue
+		}
+		if rel, ok := withinDir(podsDir, resolved); ok && isVolumeMountDir(rel) {
+			return nil
+		}
+	}
+	return fmt.Errorf("bad value for target path \"%v\"", targetPath)
+}
+
+// isVolumeMountDir tells whether rel, relative to the pods directory of kubelet, is the mount directory of a CSI volume
+func isVolumeMountDir(rel string) bool {
+	parts := strings.Split(rel, string(filepath.Separator))
+	return len(parts) == 5 && parts[1] == "volumes" && parts[2] == "kubernetes.io~csi" && parts[4] == "mount"
+}
+
+// resolvePath returns the absolute path of path with the symlinks of its existing part resolved, the part that does
+// not exist yet is appended as is
+func resolvePath(path string) (string, error) {
+	absPath, err := absPathResolver(path)
+	if err != nil {
+		return "", err
+	}
+	missing := ""
+	for dir := absPath; ; {
+		resolved, err := evalSymlinks(dir)
+		if err == nil {
+			return filepath.Join(resolved, missing), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return absPath, nil
+		}
+		missing = filepath.Join(filepath.Base(dir), missing)
+		dir = parent
+	}
+}
+
+// withinDir returns path relative to dir if path is below dir, comparing whole path components
+func withinDir(dir, path string) (string, bool) {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+// cacheDirValidator checks that a cache directory option is an absolute, clean path which is below the cache root
+// once symlinks are resolved. The mounters write to the directory and s3fs deletes in it.
+func cacheDirValidator(name, dir string) error {
+	if dir == "" {
+		return nil
+	}
+	if !filepath.IsAbs(dir) || filepath.Clean(dir) != dir {
+		logger.Error("bad value for "+name, zap.String(name, dir))
+		return fmt.Errorf("bad value for %s \"%v\": must be an absolute path", name, dir)
+	}
+	resolved, err := resolvePath(dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s \"%v\": %v", name, dir, err)
+	}
+	cacheRoot := config().CacheRoot
+	root, err := resolvePath(cacheRoot)
+	if err != nil {
+		return fmt.Errorf("failed to resolve cache root %s: %v", cacheRoot, err)
+	}
+	if _, ok := withinDir(root, resolved); !ok {
+		logger.Error("bad value for "+name, zap.String(name, dir), zap.String("cacheRoot", cacheRoot))
+		return fmt.Errorf("bad value for %s \"%v\": must be below %s", name, dir, cacheRoot)
+	}
+	return nil
+}
+
+// seLinuxContextValidator checks that the context option, quoted or not, is an SELinux context. Anything else
+// would be passed to the kernel as additional mount options.
+func seLinuxContextValidator(context string) error {
+	if context == "" {
+		return nil
+	}
+	unquoted := context
+	if len(unquoted) >= 2 && strings.HasPrefix(unquoted, `"`) && strings.HasSuffix(unquoted, `"`) {
+		unquoted = unquoted[1 : len(unquoted)-1]
+	}
+	if !seLinuxContextRegex.MatchString(unquoted) {
+		logger.Error("bad value for context", zap.String("context", context))
+		return fmt.Errorf("bad value for context \"%v\": not an SELinux context", context)
 	}
 	return nil
 }
 
+// fuseContextOption returns the context option for libfuse, whose option parser splits on unescaped commas
+func fuseContextOption(context string) string {
+	return "context=" + strings.ReplaceAll(context, ",", `\,`)
+}
+
 // --- Parser for Mounter Arguments ---
 
 type DefaultMounterArgsParser struct{}
@@ -65,6 +184,9 @@ func (p *DefaultMounterArgsParser) Parse(request MountRequest) ([]string, error)
 }
 
 func (req *MountRequest) ParseMounterArgs() ([]string, error) {
+	if err := config().CheckOptions(req.Mounter, req.Args); err != nil {
+		return nil, err
+	}
 	switch req.Mounter {
 	case constants.S3FS:
 		var args S3FSArgs
@@ -86,32 +208,99 @@ func (req *MountRequest) ParseMounterArgs() ([]string, error) {
 		}
 		return args.PopulateArgsSlice(req.Bucket, req.Path)
 
+	case constants.MountpointS3:
+		var args MountpointS3Args
+		if err := strictDecodeForUnknownFields(req.Args, &args); err != nil {
+			return nil, fmt.Errorf("invalid mountpoint-s3 args decode error: %w", err)
+		}
+		if err := args.Validate(req.Path); err != nil {
+			return nil, fmt.Errorf("mountpoint-s3 args validation failed: %w", err)
+		}
+		return args.PopulateArgsSlice(req.Bucket, req.Path)
+
+	case constants.Native:
+		var args NativeArgs
+		if err := strictDecodeForUnknownFields(req.Args, &args); err != nil {
+			return nil, fmt.Errorf("invalid native args decode error: %w", err)
+		}
+		if err := args.Validate(req.Path); err != nil {
+			return nil, fmt.Errorf("native args validation failed: %w", err)
+		}
+		return args.PopulateArgsSlice(req.Bucket, req.Path)
+
 	default:
 		return nil, fmt.Errorf("unknown mounter: %s", req.Mounter)
 	}
 }
 
+// MounterEnv returns the environment variables the mounter needs in addition to its arguments.
+// It must only be called once the request args have been validated by ParseMounterArgs.
+func (req *MountRequest) MounterEnv() ([]string, error) {
+	if req.Mounter != constants.MountpointS3 {
+		return nil, nil
+	}
+	var args MountpointS3Args
+	if err := strictDecodeForUnknownFields(req.Args, &args); err != nil {
+		return nil, fmt.Errorf("invalid mountpoint-s3 args decode error: %w", err)
+	}
+	return mounterUtils.MountpointS3Env(args.CredentialsFile), nil
+}
+
 // isBoolString checks if a string is "true" or "false" (case-insensitive)
 func isBoolString(s string) bool {
 	s = strings.TrimSpace(strings.ToLower(s))
 	return s == "true" || s == "false"
 }
 
-// fileExists checks whether the given file path exists and is not a directory.
+// fileExists checks whether the given file path exists and is not a directory. Since the file holds credentials
+// handed to a mounter, it must be in the config directory once symlinks are resolved, and only the owner of the
+// service may have written it.
 func fileExists(path string) (bool, error) {
-	absPath, err := absPathResolver(path)
+	resolved, err := resolvePath(path)
 	if err != nil {
 		return false, fmt.Errorf("failed to resolve absolute path: %v", err)
 	}
-	if !strings.HasPrefix(absPath, safeMounterConfigDir) {
-		return false, fmt.Errorf("path %v is outside the safe directory", absPath)
+	root, err := resolvePath(credentialsRoot)
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve absolute path: %v", err)
+	}
+	if _, ok := withinDir(root, resolved); !ok {
+		return false, fmt.Errorf("path %v is outside the safe directory", resolved)
 	}
-	info, err := os.Stat(absPath)
+	info, err := os.Stat(resolved)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return false, nil
 		}
 		return false, err
 	}
-	return !info.IsDir(), nil
+	if info.IsDir() {
+		return false, nil
+	}
+	if err = checkSecretFile(resolved, info); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// checkSecretFile checks that a credentials file and its directory are owned by the user of the service and
+// that other users can neither write them nor read the file
+func checkSecretFile(path string, info os.FileInfo) error {
+	if !info.Mode().IsRegular() || info.Mode().Perm()&0077 != 0 || !ownedByService(info) {
+		return fmt.Errorf("%s must be a regular file owned by uid %d with mode 0600 or stricter, found %v", path, os.Geteuid(), info.Mode())
+	}
+	dir := filepath.Dir(path)
+	dirInfo, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if dirInfo.Mode().Perm()&0022 != 0 || !ownedByService(dirInfo) {
+		return fmt.Errorf("%s must be owned by uid %d and not writable by other users, found %v", dir, os.Geteuid(), dirInfo.Mode())
+	}
+	return nil
+}
+
+func ownedByService(info os.FileInfo) bool {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return ok && int(stat.Uid) == os.Geteuid()
 }
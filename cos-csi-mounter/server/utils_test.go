@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	"errors"
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -62,6 +63,84 @@ func TestParseMounterArgs_RClone_Valid(t *testing.T) {
 	assert.NotNil(t, args)
 }
 
+func TestParseMounterArgs_MountpointS3_Valid(t *testing.T) {
+	FileExists = func(path string) (bool, error) {
+		return true, nil
+	}
+
+	req := MountRequest{
+		Path:    testTargetPath,
+		Bucket:  testBucket,
+		Mounter: constants.MountpointS3,
+	}
+	argsStruct := MountpointS3Args{
+		CredentialsFile: "/var/lib/coscsi-config/abc/credentials",
+		EndpointURL:     testURL,
+	}
+	b, _ := json.Marshal(argsStruct)
+	req.Args = b
+
+	args, err := req.ParseMounterArgs()
+	assert.NoError(t, err)
+	assert.NotNil(t, args)
+
+	env, err := req.MounterEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"AWS_SHARED_CREDENTIALS_FILE=/var/lib/coscsi-config/abc/credentials", "AWS_PROFILE=default"}, env)
+}
+
+func TestParseMounterArgs_MountpointS3_UnknownField(t *testing.T) {
+	req := MountRequest{
+		Path:    testTargetPath,
+		Bucket:  testBucket,
+		Mounter: constants.MountpointS3,
+		Args:    json.RawMessage(`{"cache-xz":"true"}`),
+	}
+
+	args, err := req.ParseMounterArgs()
+	assert.Nil(t, args)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid mountpoint-s3 args decode error")
+}
+
+func TestParseMounterArgs_Native_Valid(t *testing.T) {
+	FileExists = func(path string) (bool, error) {
+		return true, nil
+	}
+
+	req := MountRequest{
+		Path:    testTargetPath,
+		Bucket:  testBucket,
+		Mounter: constants.Native,
+		Args:    json.RawMessage(`{"auth-type":"hmac","endpoint-url":"` + testURL + `","passwd-file":"/var/lib/coscsi-config/abc/.passwd-native"}`),
+	}
+
+	args, err := req.ParseMounterArgs()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{constants.Native, testBucket, testTargetPath}, args[:3])
+}
+
+func TestParseMounterArgs_Native_UnknownField(t *testing.T) {
+	req := MountRequest{
+		Path:    testTargetPath,
+		Bucket:  testBucket,
+		Mounter: constants.Native,
+		Args:    json.RawMessage(`{"cache-xz":"true"}`),
+	}
+
+	args, err := req.ParseMounterArgs()
+	assert.Nil(t, args)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid native args decode error")
+}
+
+func TestMounterEnv_OtherMounters(t *testing.T) {
+	req := MountRequest{Mounter: constants.S3FS}
+	env, err := req.MounterEnv()
+	assert.NoError(t, err)
+	assert.Nil(t, env)
+}
+
 func TestParseMounterArgs_S3FS_InvalidJSON(t *testing.T) {
 	req := MountRequest{
 		Path:    testTargetPath,
@@ -121,7 +200,7 @@ func TestParseMounterArgs_RClone_ValidationFails(t *testing.T) {
 }
 
 func TestFileExists_FileDoesNotExist(t *testing.T) {
-	path := filepath.Join(safeMounterConfigDir, "nonexistent-file")
+	path := filepath.Join(credentialsRoot, "nonexistent-file")
 	exists, err := fileExists(path)
 	assert.False(t, exists)
 	assert.NoError(t, err)
@@ -148,3 +227,122 @@ func TestFileExists_AbsFails(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to resolve absolute path")
 }
+
+func TestPathValidator(t *testing.T) {
+	root := t.TempDir()
+	useConfig(t, func(c *Config) { c.KubeletRootDirs = []string{filepath.Join(root, "kubelet")} })
+	volumes := filepath.Join(root, "kubelet", "pods", "pod-uid", "volumes", "kubernetes.io~csi")
+	assert.NoError(t, os.MkdirAll(volumes, 0750))
+	assert.NoError(t, os.Symlink("/etc", filepath.Join(volumes, "evil")))
+	// kubelet is often reached through a symlinked root directory
+	assert.NoError(t, os.Symlink(filepath.Join(root, "kubelet"), filepath.Join(root, "kubelet-link")))
+
+	testCases := map[string]bool{
+		filepath.Join(volumes, "pv", "mount"):                                                     true,
+		filepath.Join(root, "kubelet-link", "pods", "pod-uid/volumes/kubernetes.io~csi/pv/mount"): true,
+		filepath.Join(root, "kubelet", "pods-evil", "pod-uid/volumes/kubernetes.io~csi/pv/mount"): false,
+		filepath.Join(root, "kubelet", "pods", "pod-uid", "mount"):                                false,
+		filepath.Join(volumes, "pv", "mount", "..", "..", "..", "..", "..", "..", "etc"):          false,
+		filepath.Join(volumes, "evil", "volumes", "kubernetes.io~csi", "pv", "mount"):             false,
+		filepath.Join(volumes, "evil"):                                                            false,
+		"relative/pods/pod-uid/volumes/kubernetes.io~csi/pv/mount":                                false,
+	}
+	for path, valid := range testCases {
+		if valid {
+			assert.NoError(t, pathValidator(path), path)
+		} else {
+			assert.Error(t, pathValidator(path), path)
+		}
+	}
+
+	// the root directory itself may be the symlink
+	useConfig(t, func(c *Config) { c.KubeletRootDirs = []string{filepath.Join(root, "kubelet-link")} })
+	assert.NoError(t, pathValidator(filepath.Join(volumes, "pv", "mount")))
+	assert.NoError(t, pathValidator(filepath.Join(root, "kubelet-link", "pods", "pod-uid/volumes/kubernetes.io~csi/pv/mount")))
+}
+
+func TestUnmountPathValidator(t *testing.T) {
+	root := t.TempDir()
+	useConfig(t, func(c *Config) { c.KubeletRootDirs = []string{filepath.Join(root, "kubelet")} })
+	volume := filepath.Join(root, "kubelet", "pods", "pod-uid", "volumes", "kubernetes.io~csi", "pv")
+	assert.NoError(t, os.MkdirAll(filepath.Join(volume, "mount"), 0750))
+	linked := filepath.Join(root, "kubelet", "pods", "pod-uid", "volumes", "kubernetes.io~csi", "linked")
+	assert.NoError(t, os.MkdirAll(linked, 0750))
+	assert.NoError(t, os.Symlink("/", filepath.Join(linked, "mount")))
+
+	assert.NoError(t, unmountPathValidator(filepath.Join(volume, "mount")))
+	assert.Error(t, unmountPathValidator(filepath.Join(linked, "mount")))
+	assert.Error(t, unmountPathValidator(volume))
+	assert.Error(t, unmountPathValidator("/"))
+}
+
+func TestFileExists_Permissions(t *testing.T) {
+	original := credentialsRoot
+	t.Cleanup(func() { credentialsRoot = original })
+	credentialsRoot = t.TempDir()
+	dir := filepath.Join(credentialsRoot, "volume")
+	assert.NoError(t, os.Mkdir(dir, 0700))
+
+	file := filepath.Join(dir, ".passwd-s3fs")
+	assert.NoError(t, os.WriteFile(file, []byte("key:secret"), 0600))
+	exists, err := fileExists(file)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	assert.NoError(t, os.Chmod(file, 0644))
+	_, err = fileExists(file)
+	assert.ErrorContains(t, err, "mode 0600 or stricter")
+	assert.NoError(t, os.Chmod(file, 0600))
+
+	assert.NoError(t, os.Chmod(dir, 0777))
+	_, err = fileExists(file)
+	assert.ErrorContains(t, err, "not writable by other users")
+	assert.NoError(t, os.Chmod(dir, 0700))
+
+	// a symlink in the config directory cannot point outside of it
+	outside := filepath.Join(t.TempDir(), "secret")
+	assert.NoError(t, os.WriteFile(outside, []byte("key:secret"), 0600))
+	assert.NoError(t, os.Symlink(outside, filepath.Join(dir, "link")))
+	_, err = fileExists(filepath.Join(dir, "link"))
+	assert.ErrorContains(t, err, "outside the safe directory")
+
+	// a directory whose name only starts with the config directory is outside of it
+	_, err = fileExists(credentialsRoot + "-evil/volume/.passwd-s3fs")
+	assert.ErrorContains(t, err, "outside the safe directory")
+}
+
+func TestCacheDirValidator(t *testing.T) {
+	assert.NoError(t, cacheDirValidator("cache-dir", ""))
+	assert.NoError(t, cacheDirValidator("cache-dir", "/var/lib/coscsi-cache/abc"))
+	assert.Error(t, cacheDirValidator("cache-dir", "cache"))
+	assert.Error(t, cacheDirValidator("cache-dir", "/var/lib/coscsi-cache/../../etc"))
+
+	// only directories below the cache root are accepted
+	assert.Error(t, cacheDirValidator("use_cache", "/etc"))
+	assert.Error(t, cacheDirValidator("tmpdir", "/var/lib/kubelet"))
+	assert.Error(t, cacheDirValidator("cache-dir", "/var/lib/coscsi-cache"))
+	assert.Error(t, cacheDirValidator("cache-dir", "/var/lib/coscsi-cache-evil/abc"))
+
+	// a symlink below the cache root cannot point outside of it
+	root := t.TempDir()
+	useConfig(t, func(c *Config) { c.CacheRoot = root })
+	assert.NoError(t, os.Symlink(t.TempDir(), filepath.Join(root, "link")))
+	assert.NoError(t, cacheDirValidator("cache-dir", filepath.Join(root, "abc")))
+	assert.ErrorContains(t, cacheDirValidator("cache-dir", filepath.Join(root, "link", "abc")), "must be below")
+}
+
+func TestSELinuxContextValidator(t *testing.T) {
+	assert.NoError(t, seLinuxContextValidator(""))
+	assert.NoError(t, seLinuxContextValidator(`"system_u:object_r:container_file_t:s0:c15,c26"`))
+	assert.NoError(t, seLinuxContextValidator("system_u:object_r:container_file_t:s0-s0:c0.c1023"))
+	assert.NoError(t, seLinuxContextValidator("system_u:object_r:container_file_t"))
+	assert.Error(t, seLinuxContextValidator("container_file_t"))
+	assert.Error(t, seLinuxContextValidator(`"system_u:object_r:container_file_t:s0"",allow_root`))
+	assert.Error(t, seLinuxContextValidator("system_u:object_r:container_file_t:s0 -o allow_root"))
+	assert.Error(t, seLinuxContextValidator("system_u:object_r:container_file_t:s0:c15,allow_root"))
+}
+
+func TestFuseContextOption(t *testing.T) {
+	assert.Equal(t, `context="system_u:object_r:container_file_t:s0:c15\,c26"`,
+		fuseContextOption(`"system_u:object_r:container_file_t:s0:c15,c26"`))
+}
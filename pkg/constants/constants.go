@@ -12,10 +12,18 @@ const (
 	// plugin MUST NOT set negative values here.
 	DefaultVolumesPerNode = 0
 
+	// DefaultMaxConcurrentMounts is the number of mounts the node server starts at the same time
+	DefaultMaxConcurrentMounts = 10
+
 	KPEncryptionAlgorithm = "AES256" // https://github.com/IBM/ibm-cos-sdk-go/blob/master/service/s3/api.go#L9130-L9136
 
-	S3FS             = "s3fs"
-	RClone           = "rclone"
+	S3FS               = "s3fs"
+	RClone             = "rclone"
+	MountpointS3       = "mountpoint-s3"
+	MountpointS3Binary = "mount-s3"
+	Native             = "native"
+	// NativeFsName is the filesystem name of native mounts, the mount table shows "fuse.cos-native"
+	NativeFsName     = "cos-native"
 	DefaultNamespace = "default"
 
 	IAMEP                   = "https://private.iam.cloud.ibm.com/identity/token"
@@ -35,9 +43,19 @@ const (
 	MounterConfigPathOnHost      = "/var/lib/coscsi-config"
 	MounterConfigPathOnPodS3fs   = "/var/lib/ibmc-s3fs"
 	MounterConfigPathOnPodRclone = "/root/.config/rclone"
+	MounterConfigPathOnPodMntS3  = "/var/lib/ibmc-mountpoint-s3"
+	// DefaultCacheRoot is the node directory holding the per-volume cache directories
+	DefaultCacheRoot = "/var/lib/coscsi-cache"
 	// Interval to wait till next loop
 	Interval = 500 * time.Millisecond
 
+	// Keys set by kubelet in the volume context, the pod keys require podInfoOnMount in the CSIDriver
+	EphemeralKey          = "csi.storage.k8s.io/ephemeral"
+	PodNameKey            = "csi.storage.k8s.io/pod.name"
+	PodNamespaceKey       = "csi.storage.k8s.io/pod.namespace"
+	PodUIDKey             = "csi.storage.k8s.io/pod.uid"
+	ServiceAccountNameKey = "csi.storage.k8s.io/serviceAccount.name"
+
 	PVCNameKey         = "csi.storage.k8s.io/pvc/name"
 	PVCNamespaceKey    = "csi.storage.k8s.io/pvc/namespace"
 	SecretNameKey      = "cos.csi.driver/secret"           // #nosec G101 -- false positive, this is not a credential
@@ -50,6 +68,27 @@ const (
 	IsNodeServer         = "IS_NODE_SERVER"
 	KubeNodeName         = "KUBE_NODE_NAME"
 	MaxVolumesPerNodeEnv = "MAX_VOLUMES_PER_NODE"
+	CacheRootEnv         = "CACHE_ROOT"
+	CacheNodeBudgetEnv   = "CACHE_NODE_BUDGET"
+	CacheVolumeBudgetEnv = "CACHE_VOLUME_BUDGET"
+	// EphemeralNamespacesEnv is the comma separated list of namespaces allowed to use inline volumes, "*" allows all
+	EphemeralNamespacesEnv = "EPHEMERAL_ALLOWED_NAMESPACES"
+	// MaxConcurrentMountsEnv is the number of mounts started at the same time on the node, 0 does not limit them
+	MaxConcurrentMountsEnv = "MAX_CONCURRENT_MOUNTS"
+	// MountOptionPolicyEnv is the YAML file of the administrator policy on the mount options of the secrets
+	MountOptionPolicyEnv = "MOUNT_OPTION_POLICY"
+	// VerifyBucketAccessEnv disables the check of the bucket access before mounting when set to false
+	VerifyBucketAccessEnv = "VERIFY_BUCKET_ACCESS"
+	// ProbeEndpointsEnv disables the check that the IAM and COS endpoints resolve in the probes when set to false
+	ProbeEndpointsEnv = "PROBE_ENDPOINTS"
+
+	// CacheSizeKey is the volume attribute or secret key requesting a local cache of the given quantity, e.g. 10Gi
+	CacheSizeKey = "cacheSize"
+	// MountTimeoutKey is the storage class parameter bounding the wait for a mount to be ready, e.g. 60s
+	MountTimeoutKey = "mountTimeout"
+	// MountProbeKey is the storage class parameter telling how a mount is checked to be ready: mountpoint, stat
+	// or list
+	MountProbeKey = "mountProbe"
 
 	CipherSuitesKey = "cipher_suites"
 )
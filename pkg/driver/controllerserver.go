@@ -80,6 +80,9 @@ func (cs *controllerServer) CreateVolume(_ context.Context, req *csi.CreateVolum
 			return nil, status.Error(codes.InvalidArgument, "Volume type block Volume not supported")
 		}
 	}
+	if !isValidVolumeCapabilities(caps) {
+		return nil, status.Error(codes.InvalidArgument, "Volume access mode not supported")
+	}
 
 	params := req.GetParameters()
 	if params == nil {
@@ -87,6 +90,15 @@ func (cs *controllerServer) CreateVolume(_ context.Context, req *csi.CreateVolum
 	}
 	klog.Info("CreateVolume Parameters:\n\t", params)
 
+	// kubelet does not pass the PVC to NodePublishVolume, the volume context of the PV records the PVC passed by the
+	// external-provisioner with --extra-create-metadata for the ${pvc.name} and ${pvc.namespace} variables of objectPath
+	pvcName = params[constants.PVCNameKey]
+	pvcNamespace = params[constants.PVCNamespaceKey]
+	if pvcName != "" && pvcNamespace == "" {
+		pvcNamespace = constants.DefaultNamespace
+		params[constants.PVCNamespaceKey] = pvcNamespace
+	}
+
 	secretMap := req.GetSecrets()
 	klog.Info("req.GetSecrets() length:\t", len(secretMap))
 
@@ -94,17 +106,10 @@ func (cs *controllerServer) CreateVolume(_ context.Context, req *csi.CreateVolum
 	if len(secretMap) == 0 {
 		klog.Info("Did not find the secret that matches pvc name. Fetching custom secret from PVC annotations")
 
-		pvcName = params[constants.PVCNameKey]
-		pvcNamespace = params[constants.PVCNamespaceKey]
-
 		if pvcName == "" {
 			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("pvcName not specified, could not fetch the secret %v", err))
 		}
 
-		if pvcNamespace == "" {
-			pvcNamespace = constants.DefaultNamespace
-		}
-
 		pvcRes, err := cs.Stats.GetPVC(pvcName, pvcNamespace)
 		if err != nil {
 			return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("PVC resource not found %v", err))
@@ -675,6 +680,9 @@ func sanitizeVolumeID(volumeID string) (string, error) {
 
 func isValidVolumeCapabilities(volCaps []*csi.VolumeCapability) bool {
 	hasSupport := func(capacity *csi.VolumeCapability) bool {
+		if capacity.GetBlock() != nil || capacity.GetAccessMode() == nil {
+			return false
+		}
 		for _, c := range volumeCapabilities {
 			volumeCap := csi.VolumeCapability_AccessMode{
 				Mode: c,
@@ -141,6 +141,38 @@ func TestCreateVolume(t *testing.T) {
 			},
 			expectedErr: nil,
 		},
+		{
+			testCaseName: "Positive: PVC recorded in the volume context",
+			req: &csi.CreateVolumeRequest{
+				Name: testVolumeName,
+				VolumeCapabilities: []*csi.VolumeCapability{
+					{
+						AccessMode: &csi.VolumeCapability_AccessMode{
+							Mode: volumeCapabilities[0],
+						},
+					},
+				},
+				Parameters: map[string]string{
+					constants.PVCNameKey: testPVCName,
+				},
+				Secrets: testSecret,
+			},
+			cosSession: &s3client.FakeCOSSessionFactory{},
+			expectedResp: &csi.CreateVolumeResponse{
+				Volume: &csi.Volume{
+					VolumeId: testVolumeName,
+					VolumeContext: map[string]string{
+						"bucketName":              bucketName,
+						"userProvidedBucket":      "true",
+						constants.PVCNameKey:      testPVCName,
+						constants.PVCNamespaceKey: constants.DefaultNamespace,
+						"locationConstraint":      "test-region",
+						"cosEndpoint":             "test-endpoint",
+					},
+				},
+			},
+			expectedErr: nil,
+		},
 		{
 			testCaseName: "Positive: Secret and PVC Names Different",
 			req: &csi.CreateVolumeRequest{
@@ -232,6 +264,22 @@ func TestCreateVolume(t *testing.T) {
 			expectedResp: nil,
 			expectedErr:  errors.New("Volume type block Volume not supported"),
 		},
+		{
+			testCaseName: "Negative: Unsupported access mode",
+			req: &csi.CreateVolumeRequest{
+				Name: testVolumeName,
+				VolumeCapabilities: []*csi.VolumeCapability{
+					{
+						AccessMode: &csi.VolumeCapability_AccessMode{
+							Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_SINGLE_WRITER,
+						},
+					},
+				},
+			},
+			cosSession:   &s3client.FakeCOSSessionFactory{},
+			expectedResp: nil,
+			expectedErr:  errors.New("Volume access mode not supported"),
+		},
 		{
 			testCaseName: "Negative: API Key is present in secret but not service ID",
 			req: &csi.CreateVolumeRequest{
@@ -806,6 +854,30 @@ func TestCreateVolume(t *testing.T) {
 	}
 }
 
+func TestCreateVolume_PVCObjectPath(t *testing.T) {
+	controllerServer := &controllerServer{
+		S3Driver:   &S3Driver{iamEndpoint: constants.PublicIAMEndpoint},
+		cosSession: &s3client.FakeCOSSessionFactory{},
+	}
+	resp, err := controllerServer.CreateVolume(ctx, &csi.CreateVolumeRequest{
+		Name: testVolumeName,
+		VolumeCapabilities: []*csi.VolumeCapability{
+			{AccessMode: &csi.VolumeCapability_AccessMode{Mode: volumeCapabilities[0]}},
+		},
+		Parameters: map[string]string{
+			constants.PVCNameKey:      testPVCName,
+			constants.PVCNamespaceKey: testPVCNs,
+		},
+		Secrets: testSecret,
+	})
+	assert.NoError(t, err)
+
+	// kubelet publishes the volume with the volume context of the PV
+	resolved, err := resolveObjectPath("${pvc.namespace}/${pvc.name}", resp.Volume.VolumeContext)
+	assert.NoError(t, err)
+	assert.Equal(t, testPVCNs+"/"+testPVCName, resolved)
+}
+
 func TestDeleteVolume(t *testing.T) {
 	testCases := []struct {
 		testCaseName     string
@@ -1089,6 +1161,27 @@ func TestValidateVolumeCapabilities(t *testing.T) {
 			expectedResp: nil,
 			expectedErr:  errors.New("Volume capabilities missing in request"),
 		},
+		{
+			testCaseName: "Positive: Successfully validated multi node volume Capabilities",
+			req: &csi.ValidateVolumeCapabilitiesRequest{
+				VolumeId: testVolumeID,
+				VolumeCapabilities: []*csi.VolumeCapability{
+					{AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER}},
+					{AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY}},
+					{AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_SINGLE_WRITER}},
+				},
+			},
+			expectedResp: &csi.ValidateVolumeCapabilitiesResponse{
+				Confirmed: &csi.ValidateVolumeCapabilitiesResponse_Confirmed{
+					VolumeCapabilities: []*csi.VolumeCapability{
+						{AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER}},
+						{AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY}},
+						{AccessMode: &csi.VolumeCapability_AccessMode{Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_SINGLE_WRITER}},
+					},
+				},
+			},
+			expectedErr: nil,
+		},
 		{
 			testCaseName: "Negative: Invalid Volume Capabilities",
 			req: &csi.ValidateVolumeCapabilitiesRequest{
@@ -1096,7 +1189,7 @@ func TestValidateVolumeCapabilities(t *testing.T) {
 				VolumeCapabilities: []*csi.VolumeCapability{
 					{
 						AccessMode: &csi.VolumeCapability_AccessMode{
-							Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
+							Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_SINGLE_WRITER,
 						},
 					},
 				},
@@ -0,0 +1,91 @@
+/*******************************************************************************
+ * IBM Confidential
+ * OCO Source Materials
+ * IBM Cloud Kubernetes Service, 5737-D43
+ * (C) Copyright IBM Corp. 2023 All Rights Reserved.
+ * The source code for this program is not published or otherwise divested of
+ * its trade secrets, irrespective of what has been deposited with
+ * the U.S. Copyright Office.
+ ******************************************************************************/
+
+package driver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
+)
+
+// healthCheckTimeout bounds the checks of a probe, a check that does not complete in time fails
+const healthCheckTimeout = 5 * time.Second
+
+var lookupHost = net.DefaultResolver.LookupHost
+
+// healthChecker checks the storage path the driver depends on
+type healthChecker struct {
+	// mounterHealth returns the health of cos-csi-mounter, which checks the mounter binaries and the FUSE device
+	// of the host. It is nil in controller mode.
+	mounterHealth func(ctx context.Context) (*mounterapi.Health, error)
+	// endpoints are the IAM and COS endpoints whose hosts must resolve
+	endpoints []string
+}
+
+// liveness returns why the driver cannot mount on this node, nothing if it can. It only checks local conditions,
+// restarting the driver fixes them.
+func (h *healthChecker) liveness(ctx context.Context) []string {
+	if h.mounterHealth == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	health, err := h.mounterHealth(ctx)
+	switch {
+	case err != nil:
+		return []string{fmt.Sprintf("cos-csi-mounter does not respond: %v", err)}
+	case health.Ready:
+		return nil
+	case len(health.Reasons) == 0:
+		return []string{"cos-csi-mounter is not ready"}
+	}
+	reasons := make([]string, 0, len(health.Reasons))
+	for _, reason := range health.Reasons {
+		reasons = append(reasons, "cos-csi-mounter: "+reason)
+	}
+	return reasons
+}
+
+// readiness returns why the driver is not ready, nothing if it is. Besides the checks of liveness the endpoints
+// must resolve, which may fail for a while without the driver being broken.
+func (h *healthChecker) readiness(ctx context.Context) []string {
+	reasons := h.liveness(ctx)
+
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	for _, endpoint := range h.endpoints {
+		host := endpointHost(endpoint)
+		if host == "" {
+			continue
+		}
+		if _, err := lookupHost(ctx, host); err != nil {
+			reasons = append(reasons, fmt.Sprintf("endpoint %s does not resolve: %v", endpoint, err))
+		}
+	}
+	return reasons
+}
+
+// endpointHost returns the host of an endpoint given as a URL or a host name
+func endpointHost(endpoint string) string {
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		return u.Hostname()
+	}
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return endpoint
+	}
+	return host
+}
@@ -0,0 +1,74 @@
+/**
+ * Copyright 2026 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package driver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestHealthChecker(t *testing.T) {
+	origLookupHost := lookupHost
+	t.Cleanup(func() { lookupHost = origLookupHost })
+	var looked []string
+	lookupHost = func(_ context.Context, host string) ([]string, error) {
+		looked = append(looked, host)
+		if host == "private.iam.cloud.ibm.com" {
+			return nil, errors.New("no such host")
+		}
+		return []string{"10.0.0.1"}, nil
+	}
+
+	h := &healthChecker{endpoints: []string{"https://iam.cloud.ibm.com", "https://config.direct.cloud-object-storage.cloud.ibm.com/v1", ""}}
+	assert.Empty(t, h.readiness(context.Background()))
+	assert.Equal(t, []string{"iam.cloud.ibm.com", "config.direct.cloud-object-storage.cloud.ibm.com"}, looked)
+
+	h = &healthChecker{
+		mounterHealth: func(context.Context) (*mounterapi.Health, error) {
+			return nil, status.Error(codes.Unavailable, "connection refused")
+		},
+		endpoints: []string{"https://private.iam.cloud.ibm.com"},
+	}
+	reasons := h.readiness(context.Background())
+	assert.Len(t, reasons, 2)
+	assert.Contains(t, reasons[0], "cos-csi-mounter does not respond")
+	assert.Contains(t, reasons[1], "endpoint https://private.iam.cloud.ibm.com does not resolve")
+
+	// the liveness does not depend on the network
+	looked = nil
+	reasons = h.liveness(context.Background())
+	assert.Len(t, reasons, 1)
+	assert.Contains(t, reasons[0], "cos-csi-mounter does not respond")
+	assert.Empty(t, looked)
+
+	h = &healthChecker{mounterHealth: func(context.Context) (*mounterapi.Health, error) {
+		return &mounterapi.Health{Reasons: []string{"FUSE device is not available"}}, nil
+	}}
+	assert.Equal(t, []string{"cos-csi-mounter: FUSE device is not available"}, h.liveness(context.Background()))
+}
+
+func TestEndpointHost(t *testing.T) {
+	assert.Equal(t, "iam.cloud.ibm.com", endpointHost("https://iam.cloud.ibm.com"))
+	assert.Equal(t, "s3.direct.us-south.cloud-object-storage.appdomain.cloud", endpointHost("s3.direct.us-south.cloud-object-storage.appdomain.cloud:443"))
+	assert.Equal(t, "config.example.com", endpointHost("config.example.com"))
+}
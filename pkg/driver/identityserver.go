@@ -12,10 +12,12 @@ package driver
 
 import (
 	"context"
+	"strings"
 
 	csi "github.com/container-storage-interface/spec/lib/go/csi"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 	"k8s.io/klog/v2"
 )
 
@@ -66,7 +68,17 @@ func (csiIdentity *identityServer) GetPluginCapabilities(_ context.Context, req
 	}, nil
 }
 
-func (csiIdentity *identityServer) Probe(_ context.Context, req *csi.ProbeRequest) (*csi.ProbeResponse, error) {
+// Probe reports the driver as not ready when it cannot mount on this node, e.g. cos-csi-mounter does not respond or
+// lacks the mounter binaries, so that the liveness probe restarts it. The endpoints are only checked by the
+// readiness endpoint, restarting the driver does not fix an outage of the network.
+func (csiIdentity *identityServer) Probe(ctx context.Context, req *csi.ProbeRequest) (*csi.ProbeResponse, error) {
 	klog.V(3).Infof("identityServer-Probe: Request %+v", req)
-	return &csi.ProbeResponse{}, nil
+	if csiIdentity.S3Driver == nil {
+		return &csi.ProbeResponse{}, nil
+	}
+	if reasons := csiIdentity.Liveness(ctx); len(reasons) > 0 {
+		klog.Warningf("identityServer-Probe: driver is not ready: %s", strings.Join(reasons, "; "))
+		return &csi.ProbeResponse{Ready: wrapperspb.Bool(false)}, nil
+	}
+	return &csi.ProbeResponse{Ready: wrapperspb.Bool(true)}, nil
 }
@@ -17,13 +17,16 @@
 package driver
 
 import (
+	"context"
 	"errors"
 	"reflect"
 	"testing"
 
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 func TestGetPluginInfo(t *testing.T) {
@@ -132,6 +135,7 @@ func TestProbe(t *testing.T) {
 	testCases := []struct {
 		testCaseName string
 		req          *csi.ProbeRequest
+		s3Driver     *S3Driver
 		expectedResp *csi.ProbeResponse
 		expectedErr  error
 	}{
@@ -141,12 +145,41 @@ func TestProbe(t *testing.T) {
 			expectedResp: &csi.ProbeResponse{},
 			expectedErr:  nil,
 		},
+		{
+			testCaseName: "Positive: Storage path healthy",
+			req:          &csi.ProbeRequest{},
+			s3Driver: &S3Driver{health: &healthChecker{
+				mounterHealth: func(context.Context) (*mounterapi.Health, error) {
+					return &mounterapi.Health{Ready: true}, nil
+				},
+			}},
+			expectedResp: &csi.ProbeResponse{Ready: wrapperspb.Bool(true)},
+			expectedErr:  nil,
+		},
+		{
+			testCaseName: "Positive: Endpoints not checked",
+			req:          &csi.ProbeRequest{},
+			s3Driver:     &S3Driver{health: &healthChecker{endpoints: []string{"https://iam.invalid"}}},
+			expectedResp: &csi.ProbeResponse{Ready: wrapperspb.Bool(true)},
+			expectedErr:  nil,
+		},
+		{
+			testCaseName: "Negative: cos-csi-mounter not healthy",
+			req:          &csi.ProbeRequest{},
+			s3Driver: &S3Driver{health: &healthChecker{
+				mounterHealth: func(context.Context) (*mounterapi.Health, error) {
+					return &mounterapi.Health{Reasons: []string{"s3fs not found in PATH"}}, nil
+				},
+			}},
+			expectedResp: &csi.ProbeResponse{Ready: wrapperspb.Bool(false)},
+			expectedErr:  nil,
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Log("Testcase being executed", zap.String("testcase", tc.testCaseName))
 
-		identityServer := &identityServer{}
+		identityServer := &identityServer{S3Driver: tc.s3Driver}
 		actualResp, actualErr := identityServer.Probe(ctx, tc.req)
 
 		if tc.expectedErr != nil {
@@ -12,18 +12,44 @@ package driver
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
 	"github.com/IBM/ibm-object-csi-driver/pkg/mounter"
 	mounterUtils "github.com/IBM/ibm-object-csi-driver/pkg/mounter/utils"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mountpolicy"
 	"github.com/IBM/ibm-object-csi-driver/pkg/utils"
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/klog/v2"
 )
 
+var (
+	cleanupMountConfig = mounter.CleanupMountConfig
+	removeDir          = os.Remove
+	volumeMountPoints  = mounterUtils.VolumeMountPoints
+
+	objectPathVariable = regexp.MustCompile(`\$\{([^}]*)\}`)
+	// objectPathVariables maps the variables of a templated objectPath to their volume context key
+	objectPathVariables = map[string]string{
+		"pod.name":            constants.PodNameKey,
+		"pod.namespace":       constants.PodNamespaceKey,
+		"pod.uid":             constants.PodUIDKey,
+		"serviceAccount.name": constants.ServiceAccountNameKey,
+		"pvc.name":            constants.PVCNameKey,
+		"pvc.namespace":       constants.PVCNamespaceKey,
+	}
+)
+
 // Implements Node Server csi.NodeServer
 type nodeServer struct {
 	*S3Driver
@@ -32,6 +58,12 @@ type nodeServer struct {
 	NodeServerConfig
 	Mounter      mounter.NewMounterFactory
 	MounterUtils mounterUtils.MounterUtils
+	// singleWriters maps the ID of each SINGLE_NODE_SINGLE_WRITER volume published on the node to its target path
+	singleWriters sync.Map
+	// targetLocks are the target paths with a publish or unpublish in progress
+	targetLocks utils.KeyLocks
+	// mountSlots limits the mounts started at the same time, nil does not limit them
+	mountSlots chan struct{}
 }
 
 type NodeServerConfig struct {
@@ -40,7 +72,16 @@ type NodeServerConfig struct {
 	Zone              string
 	NodeID            string
 	TLSCipherSuite    string
-	KnownS3FSOptions  *utils.Set // Set of known (to cos-csi-mounter systemd service) s3fs mount option names used to classify options as known vs unknown
+	KnownS3FSOptions  *utils.Set            // Set of known (to cos-csi-mounter systemd service) s3fs mount option names used to classify options as known vs unknown
+	Cache             *mounter.CacheManager // Allocates the local cache of volumes requesting one, nil disables caching
+	// EphemeralNamespaces are the namespaces whose pods may use inline volumes, nil or empty disables inline volumes
+	EphemeralNamespaces *utils.Set
+	// MaxConcurrentMounts is the number of mounts started at the same time on the node, 0 does not limit them
+	MaxConcurrentMounts int
+	// OptionPolicy restricts the mount options users set in their secrets, nil allows all options
+	OptionPolicy *mountpolicy.Policy
+	// VerifyBucketAccess checks the access to the bucket before the s3fs and rclone mounters start
+	VerifyBucketAccess bool
 }
 
 func (ns *nodeServer) NodeStageVolume(_ context.Context, req *csi.NodeStageVolumeRequest) (*csi.NodeStageVolumeResponse, error) {
@@ -75,7 +116,7 @@ func (ns *nodeServer) NodeUnstageVolume(_ context.Context, req *csi.NodeUnstageV
 	return &csi.NodeUnstageVolumeResponse{}, nil
 }
 
-func (ns *nodeServer) NodePublishVolume(_ context.Context, req *csi.NodePublishVolumeRequest) (*csi.NodePublishVolumeResponse, error) {
+func (ns *nodeServer) NodePublishVolume(ctx context.Context, req *csi.NodePublishVolumeRequest) (*csi.NodePublishVolumeResponse, error) {
 	modifiedRequest, err := utils.ReplaceAndReturnCopy(req)
 	if err != nil {
 		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Error in modifying requests %v", err))
@@ -99,27 +140,40 @@ func (ns *nodeServer) NodePublishVolume(_ context.Context, req *csi.NodePublishV
 		return nil, status.Error(codes.InvalidArgument, "Volume capability missing in request")
 	}
 
+	if !ns.targetLocks.TryAcquire(targetPath) {
+		return nil, status.Errorf(codes.Aborted, "An operation on target path %s is already in progress", targetPath)
+	}
+	defer ns.targetLocks.Release(targetPath)
+
 	err = ns.Stats.CheckMount(targetPath)
 	if err != nil {
 		klog.Errorf("Can not validate target mount point: %s %v", targetPath, err)
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
+	mountInfo, err := ns.MounterUtils.GetMountInfo(targetPath)
+	if err != nil {
+		klog.Errorf("Can not check existing mount at target path: %s %v", targetPath, err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
 	deviceID := ""
 	if req.GetPublishContext() != nil {
 		deviceID = req.GetPublishContext()[deviceID]
 	}
 
-	readOnly := false
-	// Get access mode from volume capability
-	// | Kubernetes PVC    | CSI Driver Constant         | readOnly |
-	// |-------------------|-----------------------------|----------|
-	// | ReadWriteOnce     | SINGLE_NODE_WRITER          | false    |
-	// | ReadWriteMany     | MULTI_NODE_MULTI_WRITER     | false    |
-	// | ReadOnlyMany      | MULTI_NODE_READER_ONLY      | true     |
+	// Get access mode from volume capability, the pod may also request a read-only mount of a writable volume
+	// | Kubernetes PVC    | CSI Driver Constant         | readOnly          |
+	// |-------------------|-----------------------------|-------------------|
+	// | ReadWriteOnce     | SINGLE_NODE_WRITER          | req.GetReadonly() |
+	// | ReadWriteOncePod  | SINGLE_NODE_SINGLE_WRITER   | req.GetReadonly() |
+	// | ReadWriteMany     | MULTI_NODE_MULTI_WRITER     | req.GetReadonly() |
+	// | ReadOnlyMany      | MULTI_NODE_READER_ONLY      | true              |
 	accessMode := req.GetVolumeCapability().GetAccessMode().GetMode()
 
-	if accessMode == csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY {
+	readOnly := req.GetReadonly()
+	if accessMode == csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY ||
+		accessMode == csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY {
 		readOnly = true
 	}
 	attrib := req.GetVolumeContext()
@@ -127,6 +181,30 @@ func (ns *nodeServer) NodePublishVolume(_ context.Context, req *csi.NodePublishV
 	klog.V(2).Infof("-NodePublishVolume-: targetPath: %v\ndeviceID: %v\nreadonly: %v\nvolumeId: %v\nattributes: %v\nmountFlags: %v\n",
 		targetPath, deviceID, readOnly, volumeID, attrib, mountFlags)
 
+	if mountInfo != nil {
+		// Target is already published, succeed only if it was published the same way
+		mounterName := mounter.GetMounterName(attrib, req.GetSecrets())
+		if err = checkExistingMount(mountInfo, mounterName, readOnly); err != nil {
+			klog.Errorf("-NodePublishVolume-: target path %s is already mounted with different parameters: %v", targetPath, err)
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		}
+		klog.Infof("-NodePublishVolume-: target path %s is already mounted using '%s' mounter, nothing to do", targetPath, mounterName)
+		return &csi.NodePublishVolumeResponse{}, nil
+	}
+
+	published := false
+	if accessMode == csi.VolumeCapability_AccessMode_SINGLE_NODE_SINGLE_WRITER {
+		if err = ns.claimSingleWriter(volumeID, targetPath); err != nil {
+			klog.Errorf("-NodePublishVolume-: %v", err)
+			return nil, err
+		}
+		defer func() {
+			if !published {
+				ns.singleWriters.CompareAndDelete(volumeID, targetPath)
+			}
+		}()
+	}
+
 	secretMap := req.GetSecrets()
 	klog.V(2).Infof("-NodePublishVolume-: length of req.GetSecrets() length: %v", len(secretMap))
 	secretMapCopy := make(map[string]string)
@@ -139,6 +217,13 @@ func (ns *nodeServer) NodePublishVolume(_ context.Context, req *csi.NodePublishV
 	}
 	klog.V(2).Infof("-NodePublishVolume-: secretMap: %v", secretMapCopy)
 
+	if attrib[constants.EphemeralKey] == "true" {
+		if err = ns.resolveEphemeralVolume(attrib, secretMap); err != nil {
+			klog.Errorf("-NodePublishVolume-: inline volume %s rejected: %v", volumeID, err)
+			return nil, err
+		}
+	}
+
 	if len(secretMap["cosEndpoint"]) == 0 {
 		secretMap["cosEndpoint"] = attrib["cosEndpoint"]
 	}
@@ -171,31 +256,78 @@ func (ns *nodeServer) NodePublishVolume(_ context.Context, req *csi.NodePublishV
 		secretMap["bucketName"] = tempBucketName
 	}
 
+	createObjectPath := false
+	if objectPath := secretMap["objectPath"]; strings.Contains(objectPath, "${") {
+		resolved, err := resolveObjectPath(objectPath, attrib)
+		if err != nil {
+			klog.Errorf("-NodePublishVolume-: cannot resolve objectPath %q: %v", objectPath, err)
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		klog.Infof("-NodePublishVolume-: objectPath %q resolved to %q", objectPath, resolved)
+		secretMap["objectPath"] = resolved
+		// every pod gets its own prefix, which does not exist before its first mount
+		createObjectPath = true
+	}
+
 	var defaultParamsMap = map[string]string{
 		constants.CipherSuitesKey: ns.TLSCipherSuite,
 	}
 
-	mounterObj := ns.Mounter.NewMounter(mounter.MounterParams{
-		Attrib:           attrib,
-		SecretMap:        secretMap,
-		MountFlags:       mountFlags,
-		KnownS3FSOptions: ns.KnownS3FSOptions,
-		DefaultMOMap:     defaultParamsMap,
-		Gid:              volumeMountGroup,
-		ReadOnly:         readOnly,
+	readiness, err := mountReadiness(attrib)
+	if err != nil {
+		klog.Errorf("-NodePublishVolume-: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	volumeCache, err := ns.allocateCache(targetPath, attrib, secretMap)
+	if err != nil {
+		klog.Errorf("-NodePublishVolume-: cannot allocate cache for %s: %v", targetPath, err)
+		return nil, err
+	}
+
+	mounterObj, err := ns.Mounter.NewMounter(mounter.MounterParams{
+		Attrib:             attrib,
+		SecretMap:          secretMap,
+		MountFlags:         mountFlags,
+		KnownS3FSOptions:   ns.KnownS3FSOptions,
+		DefaultMOMap:       defaultParamsMap,
+		Gid:                volumeMountGroup,
+		ReadOnly:           readOnly,
+		VolumeCache:        volumeCache,
+		Cache:              ns.Cache,
+		CreateObjectPath:   createObjectPath,
+		OptionPolicy:       ns.OptionPolicy,
+		VerifyBucketAccess: ns.VerifyBucketAccess,
+		Namespace:          attrib[constants.PodNamespaceKey],
+		Readiness:          readiness,
 	})
+	if err != nil {
+		klog.Errorf("-NodePublishVolume-: %v", err)
+		ns.Cache.Release(targetPath)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 
+	release, err := ns.acquireMountSlot(ctx)
+	if err != nil {
+		ns.Cache.Release(targetPath)
+		return nil, err
+	}
 	klog.Info("-NodePublishVolume-: Mount")
-	if err = mounterObj.Mount("", targetPath); err != nil {
+	err = mounterObj.Mount(ctx, "", targetPath)
+	release()
+	if err != nil {
 		klog.Info("-Mount-: Error: ", err)
+		ns.Cache.Release(targetPath)
+		ns.reportMountFailure(attrib, err)
 		return nil, err
 	}
 
+	published = true
 	klog.Infof("s3: bucket %s successfully mounted to %s", secretMap["bucketName"], targetPath)
 	return &csi.NodePublishVolumeResponse{}, nil
 }
 
-func (ns *nodeServer) NodeUnpublishVolume(_ context.Context, req *csi.NodeUnpublishVolumeRequest) (*csi.NodeUnpublishVolumeResponse, error) {
+func (ns *nodeServer) NodeUnpublishVolume(ctx context.Context, req *csi.NodeUnpublishVolumeRequest) (*csi.NodeUnpublishVolumeResponse, error) {
 	klog.V(2).Infof("CSINodeServer-NodeUnpublishVolume: Request: %+v", req)
 
 	volumeID := req.GetVolumeId()
@@ -209,22 +341,61 @@ func (ns *nodeServer) NodeUnpublishVolume(_ context.Context, req *csi.NodeUnpubl
 	}
 	klog.Infof("Unmounting target path %s", targetPath)
 
-	attrib, err := ns.Stats.GetPVAttributes(volumeID)
+	if !ns.targetLocks.TryAcquire(targetPath) {
+		return nil, status.Errorf(codes.Aborted, "An operation on target path %s is already in progress", targetPath)
+	}
+	defer ns.targetLocks.Release(targetPath)
+
+	mountInfo, err := ns.MounterUtils.GetMountInfo(targetPath)
 	if err != nil {
-		return nil, status.Error(codes.NotFound, "Failed to get PV details")
+		klog.Errorf("Can not check existing mount at target path: %s %v", targetPath, err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if mountInfo == nil {
+		// Nothing is mounted, either the target was never published or it has already been unpublished.
+		// Clean up whatever an earlier, partially completed publish/unpublish may have left behind.
+		klog.Infof("-NodeUnpublishVolume-: target path %s is not a mountpoint, skipping unmount", targetPath)
+		cleanupMountConfig(targetPath)
+		ns.Cache.Release(targetPath)
+		ns.singleWriters.CompareAndDelete(volumeID, targetPath)
+		if err = removeTargetPath(targetPath); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		return &csi.NodeUnpublishVolumeResponse{}, nil
 	}
 
-	mounterObj := ns.Mounter.NewMounter(mounter.MounterParams{
+	// The mounter is identified from the mount table, so that inline volumes, which have no PV, and volumes
+	// whose PV is already gone can be unpublished. The PV is only consulted for unrecognised mounts.
+	attrib := map[string]string{"mounter": mounterNameFromMountInfo(mountInfo)}
+	if attrib["mounter"] == "" {
+		attrib, err = ns.Stats.GetPVAttributes(volumeID)
+		if err != nil {
+			return nil, status.Error(codes.NotFound, "Failed to get PV details")
+		}
+	}
+
+	mounterObj, err := ns.Mounter.NewMounter(mounter.MounterParams{
 		Attrib: attrib,
+		Cache:  ns.Cache,
 	})
+	if err != nil {
+		klog.Errorf("-NodeUnpublishVolume-: %v", err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 
 	klog.Info("-NodeUnpublishVolume-: Unmount")
-	if err = mounterObj.Unmount(targetPath); err != nil {
-		//TODO: Need to handle the case with non existing mount separately - https://github.com/IBM/ibm-object-csi-driver/issues/46
+	if err = mounterObj.Unmount(ctx, targetPath); err != nil {
 		klog.Infof("UNMOUNT ERROR: %v", err)
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
+	ns.singleWriters.CompareAndDelete(volumeID, targetPath)
+
+	if err = removeTargetPath(targetPath); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
 	klog.Infof("Successfully unmounted  target path %s", targetPath)
 	return &csi.NodeUnpublishVolumeResponse{}, nil
 }
@@ -334,3 +505,204 @@ func (ns *nodeServer) NodeGetInfo(_ context.Context, req *csi.NodeGetInfoRequest
 	klog.V(2).Info("NodeGetInfo: ", resp)
 	return resp, nil
 }
+
+// checkExistingMount verifies that the mount found at a target path matches what a publish request asks for
+func checkExistingMount(mountInfo *mounterUtils.MountInfo, mounterName string, readOnly bool) error {
+	expectedFsType := "fuse." + constants.S3FS
+	switch mounterName {
+	case constants.RClone:
+		expectedFsType = "fuse." + constants.RClone
+	case constants.Native:
+		expectedFsType = "fuse." + constants.NativeFsName
+	case constants.MountpointS3:
+		// mount-s3 registers a plain fuse filesystem and identifies itself through the mount source
+		expectedFsType = "fuse"
+		if mountInfo.Source != constants.MountpointS3 {
+			return fmt.Errorf("existing mount has source %q, expected %q", mountInfo.Source, constants.MountpointS3)
+		}
+	}
+	if mountInfo.FsType != expectedFsType {
+		return fmt.Errorf("existing mount has filesystem type %q, expected %q", mountInfo.FsType, expectedFsType)
+	}
+	if mountInfo.ReadOnly != readOnly {
+		return fmt.Errorf("existing mount has readonly=%v, requested readonly=%v", mountInfo.ReadOnly, readOnly)
+	}
+	return nil
+}
+
+// mounterNameFromMountInfo returns the mounter serving an existing mount, or "" if the filesystem is not
+// one of the driver's mounters. It is the inverse of checkExistingMount.
+func mounterNameFromMountInfo(mountInfo *mounterUtils.MountInfo) string {
+	switch mountInfo.FsType {
+	case "fuse." + constants.S3FS:
+		return constants.S3FS
+	case "fuse." + constants.RClone:
+		return constants.RClone
+	case "fuse." + constants.NativeFsName:
+		return constants.Native
+	case "fuse":
+		if mountInfo.Source == constants.MountpointS3 {
+			return constants.MountpointS3
+		}
+	}
+	return ""
+}
+
+// removeTargetPath deletes the (unmounted) target directory created during NodePublishVolume
+func removeTargetPath(targetPath string) error {
+	err := removeDir(targetPath)
+	if err != nil && !os.IsNotExist(err) {
+		klog.Errorf("Failed to remove target path %s: %v", targetPath, err)
+		return fmt.Errorf("failed to remove target path %s: %v", targetPath, err)
+	}
+	return nil
+}
+
+// reportMountFailure records a warning event on the pod of the volume when cos-csi-mounter recognized the cause
+// of a failed mount, so that it can be told apart from the other FailedMount events of kubelet
+func (ns *nodeServer) reportMountFailure(attrib map[string]string, err error) {
+	var apiErr *mounterapi.Error
+	if !errors.As(err, &apiErr) {
+		return
+	}
+	failure := mounterapi.MountFailureOf(apiErr.Code)
+	pod := utils.PodRef{Namespace: attrib[constants.PodNamespaceKey], Name: attrib[constants.PodNameKey], UID: attrib[constants.PodUIDKey]}
+	if failure == nil || pod.Name == "" {
+		return
+	}
+	source := utils.EventSource{Component: ns.S3Driver.name, Host: ns.NodeID}
+	if err := ns.Stats.RecordPodEvent(pod, source, failure.Reason, apiErr.Message); err != nil {
+		klog.Warningf("Cannot record %s event on pod %s/%s: %v", failure.Reason, pod.Namespace, pod.Name, err)
+	}
+}
+
+// acquireMountSlot waits for one of the MaxConcurrentMounts slots, so that the mounts of all the volumes of a
+// rebooted node are not started at once. The returned function frees the slot.
+func (ns *nodeServer) acquireMountSlot(ctx context.Context) (func(), error) {
+	if ns.mountSlots == nil {
+		return func() {}, nil
+	}
+	select {
+	case ns.mountSlots <- struct{}{}:
+		return func() { <-ns.mountSlots }, nil
+	default:
+	}
+	klog.Infof("-NodePublishVolume-: waiting for one of the %d mount slots", cap(ns.mountSlots))
+	select {
+	case ns.mountSlots <- struct{}{}:
+		return func() { <-ns.mountSlots }, nil
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// claimSingleWriter records targetPath as the only publication of a SINGLE_NODE_SINGLE_WRITER volume. It fails with
+// FailedPrecondition until the volume is unpublished from the target path it is recorded with.
+func (ns *nodeServer) claimSingleWriter(volumeID, targetPath string) error {
+	if _, ok := ns.singleWriters.Load(volumeID); !ok {
+		// the claims are kept in memory, publications made before the driver restarted are found in the mount table
+		mountPoints, err := volumeMountPoints(volumeID)
+		if err != nil {
+			return status.Errorf(codes.Internal, "cannot check the publications of volume %s: %v", volumeID, err)
+		}
+		for _, mountPoint := range mountPoints {
+			if mountPoint != targetPath {
+				klog.Infof("claimSingleWriter: volume %s is already mounted at %s", volumeID, mountPoint)
+				ns.singleWriters.LoadOrStore(volumeID, mountPoint)
+				break
+			}
+		}
+	}
+	if existing, loaded := ns.singleWriters.LoadOrStore(volumeID, targetPath); loaded && existing != targetPath {
+		return status.Errorf(codes.FailedPrecondition, "volume %s is already published at %s with access mode SINGLE_NODE_SINGLE_WRITER", volumeID, existing)
+	}
+	return nil
+}
+
+// allocateCache reserves the local cache requested by the cacheSize key of the secret or volume attributes.
+// It returns nil when the volume does not request a cache.
+func (ns *nodeServer) allocateCache(targetPath string, attrib, secretMap map[string]string) (*mounter.VolumeCache, error) {
+	cacheSize := secretMap[constants.CacheSizeKey]
+	if cacheSize == "" {
+		cacheSize = attrib[constants.CacheSizeKey]
+	}
+	if cacheSize == "" {
+		return nil, nil
+	}
+	if ns.Cache == nil {
+		return nil, status.Error(codes.FailedPrecondition, "volume requests a cache but caching is not enabled on the node")
+	}
+
+	quantity, err := resource.ParseQuantity(cacheSize)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid %s %q: %v", constants.CacheSizeKey, cacheSize, err)
+	}
+	return ns.Cache.Allocate(targetPath, quantity.Value())
+}
+
+// mountReadiness returns the readiness of the mount set by the mountTimeout and mountProbe parameters of the
+// storage class
+func mountReadiness(attrib map[string]string) (mounterapi.Readiness, error) {
+	var readiness mounterapi.Readiness
+	if timeout := attrib[constants.MountTimeoutKey]; timeout != "" {
+		duration, err := time.ParseDuration(timeout)
+		if err != nil {
+			return readiness, fmt.Errorf("invalid %s %q: %v", constants.MountTimeoutKey, timeout, err)
+		}
+		readiness.Timeout.Duration = duration
+	}
+	readiness.Probe = attrib[constants.MountProbeKey]
+	if err := readiness.Validate(); err != nil {
+		return readiness, fmt.Errorf("invalid mount readiness: %v", err)
+	}
+	return readiness, nil
+}
+
+// resolveEphemeralVolume checks that the pod of an inline volume may use one and completes the secret, which
+// kubelet reads from the nodePublishSecretRef of the volume, with the bucket details of the volume attributes.
+// Inline volumes have no PV, so the bucket cannot be looked up later.
+func (ns *nodeServer) resolveEphemeralVolume(attrib, secretMap map[string]string) error {
+	podNamespace := attrib[constants.PodNamespaceKey]
+	if ns.EphemeralNamespaces == nil || (!ns.EphemeralNamespaces.Contains("*") && !ns.EphemeralNamespaces.Contains(podNamespace)) {
+		return status.Errorf(codes.PermissionDenied, "inline volumes are not allowed in namespace %q", podNamespace)
+	}
+
+	if len(secretMap) == 0 {
+		return status.Error(codes.InvalidArgument, "inline volume requires credentials in a nodePublishSecretRef secret")
+	}
+	for _, key := range []string{"bucketName", "objectPath"} {
+		if secretMap[key] == "" && attrib[key] != "" {
+			secretMap[key] = attrib[key]
+		}
+	}
+	if secretMap["bucketName"] == "" {
+		return status.Error(codes.InvalidArgument, "inline volume requires bucketName in its volume attributes or secret")
+	}
+	return nil
+}
+
+// resolveObjectPath replaces the ${pod.name}, ${pod.namespace}, ${pod.uid}, ${serviceAccount.name}, ${pvc.name}
+// and ${pvc.namespace} variables of objectPath with their values in the volume context
+func resolveObjectPath(objectPath string, attrib map[string]string) (string, error) {
+	var resolveErr error
+	resolved := objectPathVariable.ReplaceAllStringFunc(objectPath, func(match string) string {
+		name := objectPathVariable.FindStringSubmatch(match)[1]
+		key, ok := objectPathVariables[name]
+		if !ok {
+			if resolveErr == nil {
+				resolveErr = fmt.Errorf("unknown variable %s in objectPath", match)
+			}
+			return match
+		}
+		val := attrib[key]
+		if val == "" && resolveErr == nil {
+			resolveErr = fmt.Errorf("variable %s of objectPath is not set in the volume context (%s)", match, key)
+			return match
+		}
+		return val
+	})
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+	return resolved, nil
+}
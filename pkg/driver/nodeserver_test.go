@@ -17,12 +17,17 @@
 package driver
 
 import (
+	"context"
 	"errors"
+	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
 	"github.com/IBM/ibm-object-csi-driver/pkg/mounter"
+	mounterUtils "github.com/IBM/ibm-object-csi-driver/pkg/mounter/utils"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
 	"github.com/IBM/ibm-object-csi-driver/pkg/utils"
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"github.com/stretchr/testify/assert"
@@ -136,90 +141,664 @@ func TestNodeUnstageVolume(t *testing.T) {
 
 func TestNodePublishVolume(t *testing.T) {
 	testCases := []struct {
-		testCaseName     string
-		req              *csi.NodePublishVolumeRequest
-		driverStatsUtils utils.StatsUtils
-		Mounter          mounter.NewMounterFactory
-		expectedResp     *csi.NodePublishVolumeResponse
-		expectedErr      error
+		testCaseName        string
+		req                 *csi.NodePublishVolumeRequest
+		driverStatsUtils    utils.StatsUtils
+		Mounter             mounter.NewMounterFactory
+		mountInfo           *mounterUtils.MountInfo
+		cache               *mounter.CacheManager
+		ephemeralNamespaces *utils.Set
+		expectedResp        *csi.NodePublishVolumeResponse
+		expectedErr         error
 	}{
 		{
-			testCaseName: "Positive: Successful",
+			testCaseName: "Positive: Successful",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:   testVolumeID,
+				TargetPath: testTargetPath,
+				VolumeCapability: &csi.VolumeCapability{
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: volumeCapabilities[0],
+					},
+				},
+				Secrets: testSecret,
+			},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				CheckMountFn: func(targetPath string) error {
+					return nil
+				},
+				GetBucketNameFromPVFn: func(volumeID string) (string, error) {
+					return bucketName, nil
+				},
+			}),
+			Mounter: &mounter.FakeMounterFactory{
+				Mounter: constants.S3FS,
+			},
+			expectedResp: &csi.NodePublishVolumeResponse{},
+			expectedErr:  nil,
+		},
+		{
+			testCaseName:     "Negative: Volume ID is missing",
+			req:              &csi.NodePublishVolumeRequest{},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{}),
+			Mounter:          &mounter.FakeMounterFactory{},
+			expectedResp:     nil,
+			expectedErr:      errors.New("Volume ID missing in request"),
+		},
+		{
+			testCaseName: "Negative: Volume target path is missing",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId: testVolumeID,
+			},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{}),
+			Mounter:          &mounter.FakeMounterFactory{},
+			expectedResp:     nil,
+			expectedErr:      errors.New("Target path missing in request"),
+		},
+		{
+			testCaseName: "Negative: Missing Volume Capabilities",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:   testVolumeID,
+				TargetPath: testTargetPath,
+			},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{}),
+			Mounter:          &mounter.FakeMounterFactory{},
+			expectedResp:     nil,
+			expectedErr:      errors.New("Volume capability missing in request"),
+		},
+		{
+			testCaseName: "Negative: Failed to check Mount",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:   testVolumeID,
+				TargetPath: testTargetPath,
+				VolumeCapability: &csi.VolumeCapability{
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: volumeCapabilities[0],
+					},
+				},
+			},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				CheckMountFn: func(targetPath string) error {
+					return errors.New("failed to valid mount")
+				},
+			}),
+			Mounter:      &mounter.FakeMounterFactory{},
+			expectedResp: nil,
+			expectedErr:  errors.New("failed to valid mount"),
+		},
+		{
+			testCaseName: "Negative: s3 endpoint not provided",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:   testVolumeID,
+				TargetPath: testTargetPath,
+				VolumeCapability: &csi.VolumeCapability{
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: volumeCapabilities[0],
+					},
+				},
+				Secrets: map[string]string{
+					"accessKey":    "testAccessKey",
+					"secretKey":    "testSecretKey",
+					"apiKey":       "testApiKey", // pragma: allowlist secret
+					"serviceId":    "testServiceId",
+					"kpRootKeyCRN": "testKpRootKeyCRN",
+					"iamEndpoint":  "testIamEndpoint",
+					"bucketName":   bucketName,
+				},
+			},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				CheckMountFn: func(targetPath string) error {
+					return nil
+				},
+			}),
+			Mounter:      &mounter.FakeMounterFactory{},
+			expectedResp: nil,
+			expectedErr:  errors.New("S3 Service endpoint not provided"),
+		},
+		{
+			testCaseName: "Negative: Failed to fetch PV",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:   testVolumeID,
+				TargetPath: testTargetPath,
+				VolumeCapability: &csi.VolumeCapability{
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: volumeCapabilities[0],
+					},
+				},
+				Secrets: map[string]string{
+					"accessKey":          "testAccessKey",
+					"secretKey":          "testSecretKey",
+					"locationConstraint": "test-region",
+					"cosEndpoint":        "test-endpoint",
+				},
+			},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				CheckMountFn: func(targetPath string) error {
+					return nil
+				},
+				GetBucketNameFromPVFn: func(volumeID string) (string, error) {
+					return "", errors.New("failed to get pv")
+				},
+			}),
+			Mounter: &mounter.FakeMounterFactory{
+				Mounter: constants.S3FS,
+			},
+			expectedResp: nil,
+			expectedErr:  errors.New("failed to get pv"),
+		},
+		{
+			testCaseName: "Negative: Failed to get bucket Name from PV",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:   testVolumeID,
+				TargetPath: testTargetPath,
+				VolumeCapability: &csi.VolumeCapability{
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: volumeCapabilities[0],
+					},
+				},
+				Secrets: map[string]string{
+					"accessKey":          "testAccessKey",
+					"secretKey":          "testSecretKey",
+					"locationConstraint": "test-region",
+					"cosEndpoint":        "test-endpoint",
+				},
+			},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				CheckMountFn: func(targetPath string) error {
+					return nil
+				},
+				GetBucketNameFromPVFn: func(volumeID string) (string, error) {
+					return "", nil
+				},
+			}),
+			Mounter: &mounter.FakeMounterFactory{
+				Mounter: constants.S3FS,
+			},
+			expectedResp: nil,
+			expectedErr:  errors.New("unable to fetch bucket name from pv"),
+		},
+		{
+			testCaseName: "Negative: Mount failed",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:   testVolumeID,
+				TargetPath: testTargetPath,
+				VolumeCapability: &csi.VolumeCapability{
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: volumeCapabilities[0],
+					},
+				},
+				Secrets: map[string]string{
+					"accessKey":          "testAccessKey",
+					"secretKey":          "testSecretKey",
+					"locationConstraint": "test-region",
+					"cosEndpoint":        "test-endpoint",
+				},
+			},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				CheckMountFn: func(targetPath string) error {
+					return nil
+				},
+				GetBucketNameFromPVFn: func(volumeID string) (string, error) {
+					return bucketName, nil
+				},
+			}),
+			Mounter: &mounter.FakeMounterFactory{
+				Mounter:       constants.S3FS,
+				IsFailedMount: true,
+			},
+			expectedResp: nil,
+			expectedErr:  errors.New("failed to mount s3fs"),
+		},
+		{
+			testCaseName: "Positive: Target already mounted with same parameters",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:   testVolumeID,
+				TargetPath: testTargetPath,
+				VolumeCapability: &csi.VolumeCapability{
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: volumeCapabilities[0],
+					},
+				},
+				Secrets: testSecret,
+			},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				CheckMountFn: func(targetPath string) error {
+					return nil
+				},
+			}),
+			Mounter: &mounter.FakeMounterFactory{
+				Mounter:       constants.S3FS,
+				IsFailedMount: true,
+			},
+			mountInfo:    &mounterUtils.MountInfo{Source: constants.S3FS, FsType: "fuse.s3fs"},
+			expectedResp: &csi.NodePublishVolumeResponse{},
+			expectedErr:  nil,
+		},
+		{
+			testCaseName: "Negative: Target already mounted with different mounter",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:   testVolumeID,
+				TargetPath: testTargetPath,
+				VolumeCapability: &csi.VolumeCapability{
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: volumeCapabilities[0],
+					},
+				},
+				Secrets: testSecret,
+			},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				CheckMountFn: func(targetPath string) error {
+					return nil
+				},
+			}),
+			Mounter: &mounter.FakeMounterFactory{
+				Mounter: constants.S3FS,
+			},
+			mountInfo:    &mounterUtils.MountInfo{Source: "ibmcos:bucket", FsType: "fuse.rclone"},
+			expectedResp: nil,
+			expectedErr:  status.Error(codes.AlreadyExists, "existing mount has filesystem type \"fuse.rclone\", expected \"fuse.s3fs\""),
+		},
+		{
+			testCaseName: "Negative: Target already mounted read-only",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:   testVolumeID,
+				TargetPath: testTargetPath,
+				VolumeCapability: &csi.VolumeCapability{
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: volumeCapabilities[0],
+					},
+				},
+				Secrets: testSecret,
+			},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				CheckMountFn: func(targetPath string) error {
+					return nil
+				},
+			}),
+			Mounter: &mounter.FakeMounterFactory{
+				Mounter: constants.S3FS,
+			},
+			mountInfo:    &mounterUtils.MountInfo{Source: constants.S3FS, FsType: "fuse.s3fs", ReadOnly: true},
+			expectedResp: nil,
+			expectedErr:  status.Error(codes.AlreadyExists, "existing mount has readonly=true, requested readonly=false"),
+		},
+		{
+			testCaseName: "Positive: Target already mounted read-only for a pod requesting readonly",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:   testVolumeID,
+				TargetPath: testTargetPath,
+				VolumeCapability: &csi.VolumeCapability{
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
+					},
+				},
+				Readonly: true,
+				Secrets:  testSecret,
+			},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				CheckMountFn: func(targetPath string) error {
+					return nil
+				},
+			}),
+			Mounter: &mounter.FakeMounterFactory{
+				Mounter: constants.S3FS,
+			},
+			mountInfo:    &mounterUtils.MountInfo{Source: constants.S3FS, FsType: "fuse.s3fs", ReadOnly: true},
+			expectedResp: &csi.NodePublishVolumeResponse{},
+			expectedErr:  nil,
+		},
+		{
+			testCaseName: "Positive: Target already mounted read-only for SINGLE_NODE_READER_ONLY",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:   testVolumeID,
+				TargetPath: testTargetPath,
+				VolumeCapability: &csi.VolumeCapability{
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY,
+					},
+				},
+				VolumeContext: map[string]string{"mounter": constants.RClone},
+				Secrets:       testSecret,
+			},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				CheckMountFn: func(targetPath string) error {
+					return nil
+				},
+			}),
+			Mounter: &mounter.FakeMounterFactory{
+				Mounter: constants.RClone,
+			},
+			mountInfo:    &mounterUtils.MountInfo{Source: "ibmcos:bucket", FsType: "fuse.rclone", ReadOnly: true},
+			expectedResp: &csi.NodePublishVolumeResponse{},
+			expectedErr:  nil,
+		},
+		{
+			testCaseName: "Positive: Target already mounted using mountpoint-s3",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:   testVolumeID,
+				TargetPath: testTargetPath,
+				VolumeCapability: &csi.VolumeCapability{
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: volumeCapabilities[0],
+					},
+				},
+				VolumeContext: map[string]string{"mounter": constants.MountpointS3},
+				Secrets:       testSecret,
+			},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				CheckMountFn: func(targetPath string) error {
+					return nil
+				},
+			}),
+			Mounter: &mounter.FakeMounterFactory{
+				Mounter:       constants.MountpointS3,
+				IsFailedMount: true,
+			},
+			mountInfo:    &mounterUtils.MountInfo{Source: constants.MountpointS3, FsType: "fuse"},
+			expectedResp: &csi.NodePublishVolumeResponse{},
+			expectedErr:  nil,
+		},
+		{
+			testCaseName: "Positive: Target already mounted using native mounter",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:   testVolumeID,
+				TargetPath: testTargetPath,
+				VolumeCapability: &csi.VolumeCapability{
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: volumeCapabilities[0],
+					},
+				},
+				VolumeContext: map[string]string{"mounter": constants.Native},
+				Secrets:       testSecret,
+			},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				CheckMountFn: func(targetPath string) error {
+					return nil
+				},
+			}),
+			Mounter: &mounter.FakeMounterFactory{
+				Mounter:       constants.Native,
+				IsFailedMount: true,
+			},
+			mountInfo:    &mounterUtils.MountInfo{Source: constants.NativeFsName, FsType: "fuse." + constants.NativeFsName},
+			expectedResp: &csi.NodePublishVolumeResponse{},
+			expectedErr:  nil,
+		},
+		{
+			testCaseName: "Positive: Successful with cache",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:   testVolumeID,
+				TargetPath: testTargetPath,
+				VolumeCapability: &csi.VolumeCapability{
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: volumeCapabilities[0],
+					},
+				},
+				VolumeContext: map[string]string{constants.CacheSizeKey: "1Mi"},
+				Secrets:       testSecret,
+			},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				CheckMountFn: func(targetPath string) error {
+					return nil
+				},
+			}),
+			Mounter: &mounter.FakeMounterFactory{
+				Mounter: constants.S3FS,
+			},
+			cache:        mounter.NewCacheManager(t.TempDir(), 0, 0),
+			expectedResp: &csi.NodePublishVolumeResponse{},
+			expectedErr:  nil,
+		},
+		{
+			testCaseName: "Negative: Invalid cache size",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:   testVolumeID,
+				TargetPath: testTargetPath,
+				VolumeCapability: &csi.VolumeCapability{
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: volumeCapabilities[0],
+					},
+				},
+				VolumeContext: map[string]string{constants.CacheSizeKey: "ten"},
+				Secrets:       testSecret,
+			},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				CheckMountFn: func(targetPath string) error {
+					return nil
+				},
+			}),
+			Mounter: &mounter.FakeMounterFactory{
+				Mounter: constants.S3FS,
+			},
+			cache:        mounter.NewCacheManager(t.TempDir(), 0, 0),
+			expectedResp: nil,
+			expectedErr:  errors.New("invalid cacheSize"),
+		},
+		{
+			testCaseName: "Negative: Cache exceeds node budget",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:   testVolumeID,
+				TargetPath: testTargetPath,
+				VolumeCapability: &csi.VolumeCapability{
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: volumeCapabilities[0],
+					},
+				},
+				VolumeContext: map[string]string{constants.CacheSizeKey: "2Mi"},
+				Secrets:       testSecret,
+			},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				CheckMountFn: func(targetPath string) error {
+					return nil
+				},
+			}),
+			Mounter: &mounter.FakeMounterFactory{
+				Mounter: constants.S3FS,
+			},
+			cache:        mounter.NewCacheManager(t.TempDir(), 1024*1024, 0),
+			expectedResp: nil,
+			expectedErr:  errors.New("exceeds the node cache budget"),
+		},
+		{
+			testCaseName: "Negative: Caching not enabled on node",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:   testVolumeID,
+				TargetPath: testTargetPath,
+				VolumeCapability: &csi.VolumeCapability{
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: volumeCapabilities[0],
+					},
+				},
+				VolumeContext: map[string]string{constants.CacheSizeKey: "1Mi"},
+				Secrets:       testSecret,
+			},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				CheckMountFn: func(targetPath string) error {
+					return nil
+				},
+			}),
+			Mounter: &mounter.FakeMounterFactory{
+				Mounter: constants.S3FS,
+			},
+			expectedResp: nil,
+			expectedErr:  errors.New("caching is not enabled on the node"),
+		},
+		{
+			testCaseName: "Positive: Inline volume",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:   "csi-0123456789abcdef",
+				TargetPath: testTargetPath,
+				VolumeCapability: &csi.VolumeCapability{
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: volumeCapabilities[0],
+					},
+				},
+				VolumeContext: map[string]string{
+					constants.EphemeralKey:    "true",
+					constants.PodNamespaceKey: "team-a",
+					"bucketName":              "inline-bucket",
+				},
+				Secrets: map[string]string{"accessKey": "testAccessKey", "secretKey": "testSecretKey", "cosEndpoint": "test-endpoint"},
+			},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				CheckMountFn: func(targetPath string) error {
+					return nil
+				},
+			}),
+			Mounter: &mounter.FakeMounterFactory{
+				Mounter: constants.S3FS,
+			},
+			ephemeralNamespaces: utils.NewSetWithValues("team-a"),
+			expectedResp:        &csi.NodePublishVolumeResponse{},
+			expectedErr:         nil,
+		},
+		{
+			testCaseName: "Positive: Inline volume allowed in all namespaces",
 			req: &csi.NodePublishVolumeRequest{
-				VolumeId:   testVolumeID,
+				VolumeId:   "csi-0123456789abcdef",
 				TargetPath: testTargetPath,
 				VolumeCapability: &csi.VolumeCapability{
 					AccessMode: &csi.VolumeCapability_AccessMode{
 						Mode: volumeCapabilities[0],
 					},
 				},
-				Secrets: testSecret,
+				VolumeContext: map[string]string{
+					constants.EphemeralKey:    "true",
+					constants.PodNamespaceKey: "team-a",
+					"bucketName":              "inline-bucket",
+				},
+				Secrets: map[string]string{"accessKey": "testAccessKey", "secretKey": "testSecretKey", "cosEndpoint": "test-endpoint"},
 			},
 			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
 				CheckMountFn: func(targetPath string) error {
 					return nil
 				},
-				GetBucketNameFromPVFn: func(volumeID string) (string, error) {
-					return bucketName, nil
-				},
 			}),
 			Mounter: &mounter.FakeMounterFactory{
 				Mounter: constants.S3FS,
 			},
-			expectedResp: &csi.NodePublishVolumeResponse{},
-			expectedErr:  nil,
+			ephemeralNamespaces: utils.NewSetWithValues("*"),
+			expectedResp:        &csi.NodePublishVolumeResponse{},
+			expectedErr:         nil,
 		},
 		{
-			testCaseName:     "Negative: Volume ID is missing",
-			req:              &csi.NodePublishVolumeRequest{},
-			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{}),
-			Mounter:          &mounter.FakeMounterFactory{},
-			expectedResp:     nil,
-			expectedErr:      errors.New("Volume ID missing in request"),
+			testCaseName: "Negative: Inline volume namespace not allowed",
+			req: &csi.NodePublishVolumeRequest{
+				VolumeId:   "csi-0123456789abcdef",
+				TargetPath: testTargetPath,
+				VolumeCapability: &csi.VolumeCapability{
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: volumeCapabilities[0],
+					},
+				},
+				VolumeContext: map[string]string{
+					constants.EphemeralKey:    "true",
+					constants.PodNamespaceKey: "team-a",
+					"bucketName":              "inline-bucket",
+				},
+				Secrets: map[string]string{"accessKey": "testAccessKey", "secretKey": "testSecretKey", "cosEndpoint": "test-endpoint"},
+			},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				CheckMountFn: func(targetPath string) error {
+					return nil
+				},
+			}),
+			Mounter: &mounter.FakeMounterFactory{
+				Mounter: constants.S3FS,
+			},
+			ephemeralNamespaces: utils.NewSetWithValues("team-b"),
+			expectedResp:        nil,
+			expectedErr:         status.Error(codes.PermissionDenied, "inline volumes are not allowed in namespace \"team-a\""),
 		},
 		{
-			testCaseName: "Negative: Volume target path is missing",
+			testCaseName: "Negative: Inline volumes disabled",
 			req: &csi.NodePublishVolumeRequest{
-				VolumeId: testVolumeID,
+				VolumeId:   "csi-0123456789abcdef",
+				TargetPath: testTargetPath,
+				VolumeCapability: &csi.VolumeCapability{
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: volumeCapabilities[0],
+					},
+				},
+				VolumeContext: map[string]string{
+					constants.EphemeralKey:    "true",
+					constants.PodNamespaceKey: "team-a",
+					"bucketName":              "inline-bucket",
+				},
+				Secrets: map[string]string{"accessKey": "testAccessKey", "secretKey": "testSecretKey", "cosEndpoint": "test-endpoint"},
 			},
-			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{}),
-			Mounter:          &mounter.FakeMounterFactory{},
-			expectedResp:     nil,
-			expectedErr:      errors.New("Target path missing in request"),
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				CheckMountFn: func(targetPath string) error {
+					return nil
+				},
+			}),
+			Mounter: &mounter.FakeMounterFactory{
+				Mounter: constants.S3FS,
+			},
+			ephemeralNamespaces: nil,
+			expectedResp:        nil,
+			expectedErr:         status.Error(codes.PermissionDenied, "inline volumes are not allowed in namespace \"team-a\""),
 		},
 		{
-			testCaseName: "Negative: Missing Volume Capabilities",
+			testCaseName: "Negative: Inline volume without secret",
 			req: &csi.NodePublishVolumeRequest{
-				VolumeId:   testVolumeID,
+				VolumeId:   "csi-0123456789abcdef",
 				TargetPath: testTargetPath,
+				VolumeCapability: &csi.VolumeCapability{
+					AccessMode: &csi.VolumeCapability_AccessMode{
+						Mode: volumeCapabilities[0],
+					},
+				},
+				VolumeContext: map[string]string{
+					constants.EphemeralKey:    "true",
+					constants.PodNamespaceKey: "team-a",
+					"bucketName":              "inline-bucket",
+				},
+				Secrets: nil,
 			},
-			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{}),
-			Mounter:          &mounter.FakeMounterFactory{},
-			expectedResp:     nil,
-			expectedErr:      errors.New("Volume capability missing in request"),
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				CheckMountFn: func(targetPath string) error {
+					return nil
+				},
+			}),
+			Mounter: &mounter.FakeMounterFactory{
+				Mounter: constants.S3FS,
+			},
+			ephemeralNamespaces: utils.NewSetWithValues("team-a"),
+			expectedResp:        nil,
+			expectedErr:         status.Error(codes.InvalidArgument, "inline volume requires credentials in a nodePublishSecretRef secret"),
 		},
 		{
-			testCaseName: "Negative: Failed to check Mount",
+			testCaseName: "Negative: Inline volume without bucket",
 			req: &csi.NodePublishVolumeRequest{
-				VolumeId:   testVolumeID,
+				VolumeId:   "csi-0123456789abcdef",
 				TargetPath: testTargetPath,
 				VolumeCapability: &csi.VolumeCapability{
 					AccessMode: &csi.VolumeCapability_AccessMode{
 						Mode: volumeCapabilities[0],
 					},
 				},
+				VolumeContext: map[string]string{
+					constants.EphemeralKey:    "true",
+					constants.PodNamespaceKey: "team-a",
+					"bucketName":              "",
+				},
+				Secrets: map[string]string{"accessKey": "testAccessKey", "secretKey": "testSecretKey", "cosEndpoint": "test-endpoint"},
 			},
 			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
 				CheckMountFn: func(targetPath string) error {
-					return errors.New("failed to valid mount")
+					return nil
 				},
 			}),
-			Mounter:      &mounter.FakeMounterFactory{},
-			expectedResp: nil,
-			expectedErr:  errors.New("failed to valid mount"),
+			Mounter: &mounter.FakeMounterFactory{
+				Mounter: constants.S3FS,
+			},
+			ephemeralNamespaces: utils.NewSetWithValues("team-a"),
+			expectedResp:        nil,
+			expectedErr:         status.Error(codes.InvalidArgument, "inline volume requires bucketName in its volume attributes or secret"),
 		},
 		{
-			testCaseName: "Negative: s3 endpoint not provided",
+			testCaseName: "Positive: Templated objectPath",
 			req: &csi.NodePublishVolumeRequest{
 				VolumeId:   testVolumeID,
 				TargetPath: testTargetPath,
@@ -228,14 +807,14 @@ func TestNodePublishVolume(t *testing.T) {
 						Mode: volumeCapabilities[0],
 					},
 				},
+				VolumeContext: map[string]string{
+					constants.PodNameKey:      "app-0",
+					constants.PodNamespaceKey: "team-a",
+				},
 				Secrets: map[string]string{
-					"accessKey":    "testAccessKey",
-					"secretKey":    "testSecretKey",
-					"apiKey":       "testApiKey", // pragma: allowlist secret
-					"serviceId":    "testServiceId",
-					"kpRootKeyCRN": "testKpRootKeyCRN",
-					"iamEndpoint":  "testIamEndpoint",
-					"bucketName":   bucketName,
+					"cosEndpoint": "test-endpoint",
+					"bucketName":  bucketName,
+					"objectPath":  "${pod.namespace}/${pod.name}",
 				},
 			},
 			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
@@ -243,12 +822,14 @@ func TestNodePublishVolume(t *testing.T) {
 					return nil
 				},
 			}),
-			Mounter:      &mounter.FakeMounterFactory{},
-			expectedResp: nil,
-			expectedErr:  errors.New("S3 Service endpoint not provided"),
+			Mounter: &mounter.FakeMounterFactory{
+				Mounter: constants.S3FS,
+			},
+			expectedResp: &csi.NodePublishVolumeResponse{},
+			expectedErr:  nil,
 		},
 		{
-			testCaseName: "Negative: Failed to fetch PV",
+			testCaseName: "Negative: Templated objectPath with unknown variable",
 			req: &csi.NodePublishVolumeRequest{
 				VolumeId:   testVolumeID,
 				TargetPath: testTargetPath,
@@ -257,29 +838,29 @@ func TestNodePublishVolume(t *testing.T) {
 						Mode: volumeCapabilities[0],
 					},
 				},
+				VolumeContext: map[string]string{
+					constants.PodNameKey:      "app-0",
+					constants.PodNamespaceKey: "team-a",
+				},
 				Secrets: map[string]string{
-					"accessKey":          "testAccessKey",
-					"secretKey":          "testSecretKey",
-					"locationConstraint": "test-region",
-					"cosEndpoint":        "test-endpoint",
+					"cosEndpoint": "test-endpoint",
+					"bucketName":  bucketName,
+					"objectPath":  "${node.name}",
 				},
 			},
 			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
 				CheckMountFn: func(targetPath string) error {
 					return nil
 				},
-				GetBucketNameFromPVFn: func(volumeID string) (string, error) {
-					return "", errors.New("failed to get pv")
-				},
 			}),
 			Mounter: &mounter.FakeMounterFactory{
 				Mounter: constants.S3FS,
 			},
 			expectedResp: nil,
-			expectedErr:  errors.New("failed to get pv"),
+			expectedErr:  status.Error(codes.InvalidArgument, "unknown variable ${node.name} in objectPath"),
 		},
 		{
-			testCaseName: "Negative: Failed to get bucket Name from PV",
+			testCaseName: "Negative: Templated objectPath variable not set",
 			req: &csi.NodePublishVolumeRequest{
 				VolumeId:   testVolumeID,
 				TargetPath: testTargetPath,
@@ -288,29 +869,29 @@ func TestNodePublishVolume(t *testing.T) {
 						Mode: volumeCapabilities[0],
 					},
 				},
+				VolumeContext: map[string]string{
+					constants.PodNameKey:      "app-0",
+					constants.PodNamespaceKey: "team-a",
+				},
 				Secrets: map[string]string{
-					"accessKey":          "testAccessKey",
-					"secretKey":          "testSecretKey",
-					"locationConstraint": "test-region",
-					"cosEndpoint":        "test-endpoint",
+					"cosEndpoint": "test-endpoint",
+					"bucketName":  bucketName,
+					"objectPath":  "${pvc.name}",
 				},
 			},
 			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
 				CheckMountFn: func(targetPath string) error {
 					return nil
 				},
-				GetBucketNameFromPVFn: func(volumeID string) (string, error) {
-					return "", nil
-				},
 			}),
 			Mounter: &mounter.FakeMounterFactory{
 				Mounter: constants.S3FS,
 			},
 			expectedResp: nil,
-			expectedErr:  errors.New("unable to fetch bucket name from pv"),
+			expectedErr:  status.Error(codes.InvalidArgument, "variable ${pvc.name} of objectPath is not set"),
 		},
 		{
-			testCaseName: "Negative: Mount failed",
+			testCaseName: "Negative: Unsupported mounter",
 			req: &csi.NodePublishVolumeRequest{
 				VolumeId:   testVolumeID,
 				TargetPath: testTargetPath,
@@ -319,12 +900,8 @@ func TestNodePublishVolume(t *testing.T) {
 						Mode: volumeCapabilities[0],
 					},
 				},
-				Secrets: map[string]string{
-					"accessKey":          "testAccessKey",
-					"secretKey":          "testSecretKey",
-					"locationConstraint": "test-region",
-					"cosEndpoint":        "test-endpoint",
-				},
+				VolumeContext: map[string]string{"mounter": "goofys"},
+				Secrets:       testSecret,
 			},
 			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
 				CheckMountFn: func(targetPath string) error {
@@ -335,23 +912,30 @@ func TestNodePublishVolume(t *testing.T) {
 				},
 			}),
 			Mounter: &mounter.FakeMounterFactory{
-				Mounter:       constants.S3FS,
-				IsFailedMount: true,
+				Mounter:            "goofys",
+				IsFailedNewMounter: true,
 			},
 			expectedResp: nil,
-			expectedErr:  errors.New("failed to mount s3fs"),
+			expectedErr:  status.Error(codes.InvalidArgument, "unsupported mounter \"goofys\""),
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Log("Testcase being executed", zap.String("testcase", tc.testCaseName))
 
+		mountInfo := tc.mountInfo
 		nodeServer := nodeServer{
 			S3Driver: &S3Driver{
 				iamEndpoint: constants.PublicIAMEndpoint,
 			},
-			Stats:   tc.driverStatsUtils,
-			Mounter: tc.Mounter,
+			Stats:            tc.driverStatsUtils,
+			NodeServerConfig: NodeServerConfig{Cache: tc.cache, EphemeralNamespaces: tc.ephemeralNamespaces},
+			Mounter:          tc.Mounter,
+			MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{
+				GetMountInfoFn: func(path string) (*mounterUtils.MountInfo, error) {
+					return mountInfo, nil
+				},
+			}),
 		}
 		actualResp, actualErr := nodeServer.NodePublishVolume(ctx, tc.req)
 
@@ -368,14 +952,234 @@ func TestNodePublishVolume(t *testing.T) {
 	}
 }
 
+func TestNodePublishVolume_SingleNodeSingleWriter(t *testing.T) {
+	mounted := map[string]bool{}
+	volumeMountPoints = func(volumeID string) ([]string, error) {
+		assert.Equal(t, testVolumeID, volumeID)
+		var mountPoints []string
+		for path := range mounted {
+			mountPoints = append(mountPoints, path)
+		}
+		return mountPoints, nil
+	}
+	defer func() { volumeMountPoints = mounterUtils.VolumeMountPoints }()
+	newNodeServer := func() *nodeServer {
+		return &nodeServer{
+			S3Driver: &S3Driver{
+				iamEndpoint: constants.PublicIAMEndpoint,
+			},
+			Stats: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				CheckMountFn: func(targetPath string) error {
+					return nil
+				},
+				GetBucketNameFromPVFn: func(volumeID string) (string, error) {
+					return bucketName, nil
+				},
+			}),
+			Mounter: &mounter.FakeMounterFactory{
+				Mounter: constants.S3FS,
+			},
+			MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{
+				GetMountInfoFn: func(path string) (*mounterUtils.MountInfo, error) {
+					if mounted[path] {
+						return &mounterUtils.MountInfo{Source: constants.S3FS, FsType: "fuse.s3fs"}, nil
+					}
+					return nil, nil
+				},
+			}),
+		}
+	}
+	ns := newNodeServer()
+	publish := func(targetPath string) error {
+		_, err := ns.NodePublishVolume(ctx, &csi.NodePublishVolumeRequest{
+			VolumeId:   testVolumeID,
+			TargetPath: targetPath,
+			VolumeCapability: &csi.VolumeCapability{
+				AccessMode: &csi.VolumeCapability_AccessMode{
+					Mode: csi.VolumeCapability_AccessMode_SINGLE_NODE_SINGLE_WRITER,
+				},
+			},
+			Secrets: map[string]string{"cosEndpoint": "test-endpoint"},
+		})
+		if err == nil {
+			mounted[targetPath] = true
+		}
+		return err
+	}
+
+	assert.NoError(t, publish("/target/a"))
+	// publishing again at the same target path is idempotent
+	assert.NoError(t, publish("/target/a"))
+
+	err := publish("/target/b")
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// the volume can move to another target path once the first one is unpublished
+	delete(mounted, "/target/a")
+	_, err = ns.NodeUnpublishVolume(ctx, &csi.NodeUnpublishVolumeRequest{VolumeId: testVolumeID, TargetPath: "/target/a"})
+	assert.NoError(t, err)
+	assert.NoError(t, publish("/target/b"))
+
+	// a restarted driver finds the publication in the mount table
+	ns = newNodeServer()
+	err = publish("/target/a")
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.NoError(t, publish("/target/b"))
+}
+
+func TestNodePublishVolume_ReportsMountFailure(t *testing.T) {
+	var reasons []string
+	ns := &nodeServer{
+		NodeServerConfig: NodeServerConfig{NodeID: testNodeID},
+		S3Driver: &S3Driver{
+			name:        "test-driver",
+			iamEndpoint: constants.PublicIAMEndpoint,
+		},
+		Stats: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+			CheckMountFn: func(targetPath string) error {
+				return nil
+			},
+			GetBucketNameFromPVFn: func(volumeID string) (string, error) {
+				return bucketName, nil
+			},
+			RecordPodEventFn: func(pod utils.PodRef, source utils.EventSource, reason, message string) error {
+				assert.Equal(t, utils.PodRef{Namespace: "default", Name: "app", UID: "uid"}, pod)
+				assert.Equal(t, utils.EventSource{Component: "test-driver", Host: testNodeID}, source)
+				reasons = append(reasons, reason)
+				return errors.New("events are not allowed")
+			},
+		}),
+		Mounter: &mounter.FakeMounterFactory{
+			Mounter: constants.S3FS,
+			MountErr: &mounterapi.Error{Code: mounterapi.CodeBadCredentials, GRPCCode: codes.Unauthenticated,
+				Message: "mount failed, the credentials were rejected"},
+		},
+		MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{
+			GetMountInfoFn: func(path string) (*mounterUtils.MountInfo, error) {
+				return nil, nil
+			},
+		}),
+	}
+	publish := func(volumeContext map[string]string) error {
+		_, err := ns.NodePublishVolume(ctx, &csi.NodePublishVolumeRequest{
+			VolumeId:   testVolumeID,
+			TargetPath: testTargetPath,
+			VolumeCapability: &csi.VolumeCapability{
+				AccessMode: &csi.VolumeCapability_AccessMode{
+					Mode: volumeCapabilities[0],
+				},
+			},
+			VolumeContext: volumeContext,
+			Secrets:       map[string]string{"cosEndpoint": "test-endpoint"},
+		})
+		return err
+	}
+
+	err := publish(map[string]string{
+		constants.PodNamespaceKey: "default",
+		constants.PodNameKey:      "app",
+		constants.PodUIDKey:       "uid",
+	})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, []string{"COSBadCredentials"}, reasons)
+
+	// without podInfoOnMount the pod is not known and no event is recorded
+	err = publish(nil)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Len(t, reasons, 1)
+}
+
+func TestNodePublishVolume_TargetPathLocked(t *testing.T) {
+	ns := &nodeServer{}
+	assert.True(t, ns.targetLocks.TryAcquire(testTargetPath))
+
+	_, err := ns.NodePublishVolume(ctx, &csi.NodePublishVolumeRequest{
+		VolumeId:         testVolumeID,
+		TargetPath:       testTargetPath,
+		VolumeCapability: &csi.VolumeCapability{},
+	})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	_, err = ns.NodeUnpublishVolume(ctx, &csi.NodeUnpublishVolumeRequest{VolumeId: testVolumeID, TargetPath: testTargetPath})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+}
+
+func TestAcquireMountSlot(t *testing.T) {
+	ns := &nodeServer{}
+	release, err := ns.acquireMountSlot(ctx)
+	assert.NoError(t, err)
+	release()
+
+	ns.mountSlots = make(chan struct{}, 1)
+	release, err = ns.acquireMountSlot(ctx)
+	assert.NoError(t, err)
+
+	// the second mount waits for the first one
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = ns.acquireMountSlot(timeoutCtx)
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	release()
+	release, err = ns.acquireMountSlot(ctx)
+	assert.NoError(t, err)
+	release()
+}
+
+func TestResolveObjectPath(t *testing.T) {
+	attrib := map[string]string{
+		constants.PodNameKey:      "app-0",
+		constants.PodNamespaceKey: "team-a",
+		constants.PVCNameKey:      "data-app-0",
+	}
+
+	resolved, err := resolveObjectPath("/apps/${pod.namespace}/${pod.name}/", attrib)
+	assert.NoError(t, err)
+	assert.Equal(t, "/apps/team-a/app-0/", resolved)
+
+	resolved, err = resolveObjectPath("${pvc.name}", attrib)
+	assert.NoError(t, err)
+	assert.Equal(t, "data-app-0", resolved)
+
+	_, err = resolveObjectPath("${pod.uid}", attrib)
+	assert.ErrorContains(t, err, constants.PodUIDKey)
+
+	_, err = resolveObjectPath("${pod.labels}/${pod.name}", attrib)
+	assert.ErrorContains(t, err, "unknown variable ${pod.labels}")
+}
+
+func TestMountReadiness(t *testing.T) {
+	readiness, err := mountReadiness(map[string]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, mounterapi.Readiness{}, readiness)
+
+	readiness, err = mountReadiness(map[string]string{constants.MountTimeoutKey: "90s", constants.MountProbeKey: mounterapi.ProbeList})
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Second, readiness.MountTimeout())
+	assert.Equal(t, mounterapi.ProbeList, readiness.MountProbe())
+
+	_, err = mountReadiness(map[string]string{constants.MountTimeoutKey: "soon"})
+	assert.ErrorContains(t, err, constants.MountTimeoutKey)
+	_, err = mountReadiness(map[string]string{constants.MountTimeoutKey: "10m"})
+	assert.Error(t, err)
+	_, err = mountReadiness(map[string]string{constants.MountProbeKey: "write"})
+	assert.Error(t, err)
+}
+
 func TestNodeUnpublishVolume(t *testing.T) {
+	mounted := &mounterUtils.MountInfo{Source: constants.S3FS, FsType: "fuse.s3fs"}
+
 	testCases := []struct {
 		testCaseName     string
 		req              *csi.NodeUnpublishVolumeRequest
 		driverStatsUtils utils.StatsUtils
 		Mounter          mounter.NewMounterFactory
+		mountInfo        *mounterUtils.MountInfo
+		mountInfoErr     error
+		removeDirErr     error
 		expectedResp     *csi.NodeUnpublishVolumeResponse
 		expectedErr      error
+		expectedCleanup  bool
 	}{
 		{
 			testCaseName: "Positive: Successful",
@@ -394,6 +1198,7 @@ func TestNodeUnpublishVolume(t *testing.T) {
 				Mounter:         constants.S3FS,
 				IsFailedUnmount: false,
 			},
+			mountInfo:    mounted,
 			expectedResp: &csi.NodeUnpublishVolumeResponse{},
 			expectedErr:  nil,
 		},
@@ -422,9 +1227,28 @@ func TestNodeUnpublishVolume(t *testing.T) {
 					return nil, errors.New("pv not found")
 				},
 			}),
+			mountInfo:    &mounterUtils.MountInfo{Source: "goofys", FsType: "fuse.goofys"},
 			expectedResp: nil,
 			expectedErr:  errors.New("Failed to get PV details"),
 		},
+		{
+			testCaseName: "Positive: Inline volume unmounted without PV",
+			req: &csi.NodeUnpublishVolumeRequest{
+				VolumeId:   "csi-0123456789abcdef",
+				TargetPath: testTargetPath,
+			},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				GetPVAttributesFn: func(volumeID string) (map[string]string, error) {
+					return nil, errors.New("pv not found")
+				},
+			}),
+			Mounter: &mounter.FakeMounterFactory{
+				Mounter: constants.RClone,
+			},
+			mountInfo:    &mounterUtils.MountInfo{Source: "rclone-remote:bucket", FsType: "fuse.rclone"},
+			expectedResp: &csi.NodeUnpublishVolumeResponse{},
+			expectedErr:  nil,
+		},
 		{
 			testCaseName: "Negative: Unmount failed",
 			req: &csi.NodeUnpublishVolumeRequest{
@@ -442,19 +1266,90 @@ func TestNodeUnpublishVolume(t *testing.T) {
 				Mounter:         constants.S3FS,
 				IsFailedUnmount: true,
 			},
+			mountInfo:    mounted,
 			expectedResp: nil,
 			expectedErr:  errors.New("failed to unmount s3fs"),
 		},
+		{
+			testCaseName: "Positive: Target path is not mounted",
+			req: &csi.NodeUnpublishVolumeRequest{
+				VolumeId:   testVolumeID,
+				TargetPath: testTargetPath,
+			},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{}),
+			Mounter:          &mounter.FakeMounterFactory{IsFailedUnmount: true},
+			mountInfo:        nil,
+			expectedResp:     &csi.NodeUnpublishVolumeResponse{},
+			expectedErr:      nil,
+			expectedCleanup:  true,
+		},
+		{
+			testCaseName: "Positive: Target path does not exist",
+			req: &csi.NodeUnpublishVolumeRequest{
+				VolumeId:   testVolumeID,
+				TargetPath: testTargetPath,
+			},
+			driverStatsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{}),
+			Mounter:          &mounter.FakeMounterFactory{IsFailedUnmount: true},
+			mountInfo:        nil,
+			removeDirErr:     os.ErrNotExist,
+			expectedResp:     &csi.NodeUnpublishVolumeResponse{},
+			expectedErr:      nil,
+			expectedCleanup:  true,
+		},
+		{
+			testCaseName: "Negative: Failed to read mount table",
+			req: &csi.NodeUnpublishVolumeRequest{
+				VolumeId:   testVolumeID,
+				TargetPath: testTargetPath,
+			},
+			mountInfoErr: errors.New("failed to read mount table"),
+			expectedResp: nil,
+			expectedErr:  errors.New("failed to read mount table"),
+		},
+		{
+			testCaseName: "Negative: Failed to remove target path",
+			req: &csi.NodeUnpublishVolumeRequest{
+				VolumeId:   testVolumeID,
+				TargetPath: testTargetPath,
+			},
+			mountInfo:       nil,
+			removeDirErr:    errors.New("directory not empty"),
+			expectedResp:    nil,
+			expectedErr:     errors.New("failed to remove target path"),
+			expectedCleanup: true,
+		},
 	}
 
+	defer func() {
+		cleanupMountConfig = mounter.CleanupMountConfig
+		removeDir = os.Remove
+	}()
+
 	for _, tc := range testCases {
 		t.Log("Testcase being executed", zap.String("testcase", tc.testCaseName))
 
+		cleanedUp := false
+		cleanupMountConfig = func(target string) {
+			cleanedUp = true
+		}
+		removeDirErr := tc.removeDirErr
+		removeDir = func(name string) error {
+			return removeDirErr
+		}
+
+		mountInfo, mountInfoErr := tc.mountInfo, tc.mountInfoErr
 		nodeServer := nodeServer{
 			Stats:   tc.driverStatsUtils,
 			Mounter: tc.Mounter,
+			MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{
+				GetMountInfoFn: func(path string) (*mounterUtils.MountInfo, error) {
+					return mountInfo, mountInfoErr
+				},
+			}),
 		}
 		actualResp, actualErr := nodeServer.NodeUnpublishVolume(ctx, tc.req)
+		assert.Equal(t, tc.expectedCleanup, cleanedUp)
 
 		if tc.expectedErr != nil {
 			assert.Error(t, actualErr)
@@ -647,6 +1542,13 @@ func TestNodeGetCapabilities(t *testing.T) {
 							},
 						},
 					},
+					{
+						Type: &csi.NodeServiceCapability_Rpc{
+							Rpc: &csi.NodeServiceCapability_RPC{
+								Type: nodeServerCapabilities[3],
+							},
+						},
+					},
 				},
 			},
 			expectedErr: nil,
@@ -11,6 +11,7 @@
 package driver
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strconv"
@@ -20,17 +21,25 @@ import (
 	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
 	"github.com/IBM/ibm-object-csi-driver/pkg/mounter"
 	mounterUtils "github.com/IBM/ibm-object-csi-driver/pkg/mounter/utils"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mountpolicy"
 	"github.com/IBM/ibm-object-csi-driver/pkg/s3client"
 	pkgUtils "github.com/IBM/ibm-object-csi-driver/pkg/utils"
 	csi "github.com/container-storage-interface/spec/lib/go/csi"
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/klog/v2"
 )
 
 var (
-	// volumeCapabilities represents how the volume could be accessed.
+	// volumeCapabilities represents how the volume could be accessed. A bucket can be mounted on any number
+	// of nodes, MULTI_NODE_SINGLE_WRITER is left out as a single writer across nodes cannot be enforced.
 	volumeCapabilities = []csi.VolumeCapability_AccessMode_Mode{
 		csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY,
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_SINGLE_WRITER,
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_MULTI_WRITER,
+		csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY,
+		csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
 	}
 
 	// controllerCapabilities represents the capability of controller service
@@ -43,6 +52,7 @@ var (
 		csi.NodeServiceCapability_RPC_GET_VOLUME_STATS,
 		csi.NodeServiceCapability_RPC_VOLUME_CONDITION,
 		csi.NodeServiceCapability_RPC_VOLUME_MOUNT_GROUP,
+		csi.NodeServiceCapability_RPC_SINGLE_NODE_MULTI_WRITER,
 	}
 )
 
@@ -54,6 +64,7 @@ type S3Driver struct {
 	iamEndpoint string
 
 	s3client s3client.ObjectStorageSession
+	health   *healthChecker
 
 	ids *identityServer
 	ns  *nodeServer
@@ -158,6 +169,47 @@ func newNodeServer(d *S3Driver, statsUtil pkgUtils.StatsUtils, nodeID string, mo
 		maxVolumesPerNode = int64(constants.DefaultVolumesPerNode)
 	}
 
+	cache, err := newCacheManager()
+	if err != nil {
+		return nil, err
+	}
+
+	var ephemeralNamespaces *pkgUtils.Set
+	if val := os.Getenv(constants.EphemeralNamespacesEnv); val != "" {
+		ephemeralNamespaces = pkgUtils.NewSet()
+		for _, namespace := range strings.Split(val, ",") {
+			if namespace = strings.TrimSpace(namespace); namespace != "" {
+				ephemeralNamespaces.Add(namespace)
+			}
+		}
+	}
+
+	maxConcurrentMounts := constants.DefaultMaxConcurrentMounts
+	if val := os.Getenv(constants.MaxConcurrentMountsEnv); val != "" {
+		maxConcurrentMounts, err = strconv.Atoi(val)
+		if err != nil || maxConcurrentMounts < 0 {
+			return nil, fmt.Errorf("invalid %s env variable %q", constants.MaxConcurrentMountsEnv, val)
+		}
+	}
+	var mountSlots chan struct{}
+	if maxConcurrentMounts > 0 {
+		mountSlots = make(chan struct{}, maxConcurrentMounts)
+	}
+
+	var optionPolicy *mountpolicy.Policy
+	if file := os.Getenv(constants.MountOptionPolicyEnv); file != "" {
+		if optionPolicy, err = mountpolicy.Load(file); err != nil {
+			return nil, err
+		}
+	}
+
+	verifyBucketAccess := true
+	if val := os.Getenv(constants.VerifyBucketAccessEnv); val != "" {
+		if verifyBucketAccess, err = strconv.ParseBool(val); err != nil {
+			return nil, fmt.Errorf("invalid %s env variable %q", constants.VerifyBucketAccessEnv, val)
+		}
+	}
+
 	ciphersuite := ""
 	if strings.Contains(strings.ToLower(data.OS), "ubuntu") {
 		ciphersuite = "AESGCM"
@@ -167,25 +219,65 @@ func newNodeServer(d *S3Driver, statsUtil pkgUtils.StatsUtils, nodeID string, mo
 		S3Driver: d,
 		Stats:    statsUtil,
 		NodeServerConfig: NodeServerConfig{MaxVolumesPerNode: maxVolumesPerNode, Region: data.Region, Zone: data.Zone,
-			NodeID: nodeID, TLSCipherSuite: ciphersuite, KnownS3FSOptions: mounter.GetKnownS3FSOptions()},
+			NodeID: nodeID, TLSCipherSuite: ciphersuite, KnownS3FSOptions: mounter.GetKnownS3FSOptions(), Cache: cache,
+			EphemeralNamespaces: ephemeralNamespaces, MaxConcurrentMounts: maxConcurrentMounts,
+			OptionPolicy: optionPolicy, VerifyBucketAccess: verifyBucketAccess},
 		Mounter:      mountObj,
 		MounterUtils: mounterUtil,
+		mountSlots:   mountSlots,
 	}, nil
 }
 
+// newCacheManager creates the manager of volume caches from the CACHE_* env variables. The budgets are
+// quantities such as 50Gi, an unset budget is unlimited.
+func newCacheManager() (*mounter.CacheManager, error) {
+	root := os.Getenv(constants.CacheRootEnv)
+	if root == "" {
+		root = constants.DefaultCacheRoot
+	}
+
+	var budgets [2]int64
+	for i, env := range []string{constants.CacheNodeBudgetEnv, constants.CacheVolumeBudgetEnv} {
+		val := os.Getenv(env)
+		if val == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s env variable %q: %v", env, val, err)
+		}
+		budgets[i] = quantity.Value()
+	}
+	return mounter.NewCacheManager(root, budgets[0], budgets[1]), nil
+}
+
 func (driver *S3Driver) NewS3CosDriver(nodeID string, endpoint string, s3cosSession s3client.ObjectStorageSessionFactory, mountObj mounter.NewMounterFactory, statsUtil pkgUtils.StatsUtils, mounterUtil mounterUtils.MounterUtils) (*S3Driver, error) {
 	s3client, err := s3client.NewS3Client(driver.logger)
 	if err != nil {
 		return nil, err
 	}
 
-	iamEP, _, err := statsUtil.GetEndpoints()
+	iamEP, cosEP, err := statsUtil.GetEndpoints()
 	if err != nil {
 		return nil, err
 	}
 	klog.Infof("iam endpoint: %v", iamEP)
 	driver.iamEndpoint = iamEP
 
+	driver.health = &healthChecker{}
+	if strings.Contains(driver.mode, "node") {
+		driver.health.mounterHealth = mounter.MounterHealth
+	}
+	probeEndpoints := true
+	if val := os.Getenv(constants.ProbeEndpointsEnv); val != "" {
+		if probeEndpoints, err = strconv.ParseBool(val); err != nil {
+			return nil, fmt.Errorf("invalid %s env variable %q", constants.ProbeEndpointsEnv, val)
+		}
+	}
+	if probeEndpoints {
+		driver.health.endpoints = []string{iamEP, cosEP}
+	}
+
 	driver.endpoint = endpoint
 	driver.s3client = s3client
 
@@ -208,6 +300,22 @@ func (driver *S3Driver) NewS3CosDriver(nodeID string, endpoint string, s3cosSess
 	return driver, err
 }
 
+// Readiness returns why the driver cannot serve volumes, nothing if it is ready
+func (driver *S3Driver) Readiness(ctx context.Context) []string {
+	if driver.health == nil {
+		return nil
+	}
+	return driver.health.readiness(ctx)
+}
+
+// Liveness returns why the driver is broken on this node, nothing if it is not
+func (driver *S3Driver) Liveness(ctx context.Context) []string {
+	if driver.health == nil {
+		return nil
+	}
+	return driver.health.liveness(ctx)
+}
+
 func (driver *S3Driver) Run() {
 	driver.logger.Info("--S3CSIDriver Run--")
 	driver.logger.Info("Driver:", zap.Reflect("Driver Name", driver.name))
@@ -115,8 +115,11 @@ func TestNewNodeServer(t *testing.T) {
 		{
 			testCaseName: "Positive: success",
 			envVars: map[string]string{
-				constants.KubeNodeName:         nodeID,
-				constants.MaxVolumesPerNodeEnv: "10",
+				constants.KubeNodeName:           nodeID,
+				constants.MaxVolumesPerNodeEnv:   "10",
+				constants.CacheNodeBudgetEnv:     "10Gi",
+				constants.EphemeralNamespacesEnv: "team-a, team-b",
+				constants.MaxConcurrentMountsEnv: "4",
 			},
 			statsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
 				GetClusterNodeDataFn: func(nodeName string) (*utils.ClusterNodeData, error) {
@@ -136,6 +139,14 @@ func TestNewNodeServer(t *testing.T) {
 				assert.Equal(t, ns.NodeID, nodeID)
 				assert.NotNil(t, ns.KnownS3FSOptions, "KnownS3FSOptions should be initialized")
 				assert.True(t, ns.KnownS3FSOptions.Contains("allow_other"), "Set should contain known s3fs options")
+				assert.Equal(t, constants.DefaultCacheRoot, ns.Cache.Root)
+				assert.Equal(t, int64(10*1024*1024*1024), ns.Cache.NodeBudget)
+				assert.Zero(t, ns.Cache.VolumeBudget)
+				assert.True(t, ns.EphemeralNamespaces.Contains("team-b"))
+				assert.False(t, ns.EphemeralNamespaces.Contains("default"))
+				assert.Equal(t, 4, ns.MaxConcurrentMounts)
+				assert.Equal(t, 4, cap(ns.mountSlots))
+				assert.True(t, ns.VerifyBucketAccess)
 			},
 			expectedErr: nil,
 		},
@@ -209,6 +220,85 @@ func TestNewNodeServer(t *testing.T) {
 			},
 			expectedErr: nil,
 		},
+		{
+			testCaseName: "Negative: invalid value of cache volume budget",
+			envVars: map[string]string{
+				constants.KubeNodeName:         nodeID,
+				constants.CacheVolumeBudgetEnv: "invalid",
+			},
+			statsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				GetClusterNodeDataFn: func(nodeName string) (*utils.ClusterNodeData, error) {
+					return &utils.ClusterNodeData{
+						Region: testRegion,
+						Zone:   testZone,
+					}, nil
+				},
+			}),
+			verifyResult: func(t *testing.T, ns *nodeServer, err error) {
+				assert.Nil(t, ns)
+			},
+			expectedErr: errors.New("invalid CACHE_VOLUME_BUDGET env variable"),
+		},
+		{
+			testCaseName: "Negative: invalid value of max concurrent mounts",
+			envVars: map[string]string{
+				constants.KubeNodeName:           nodeID,
+				constants.CacheVolumeBudgetEnv:   "",
+				constants.MaxConcurrentMountsEnv: "-1",
+			},
+			statsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				GetClusterNodeDataFn: func(nodeName string) (*utils.ClusterNodeData, error) {
+					return &utils.ClusterNodeData{
+						Region: testRegion,
+						Zone:   testZone,
+					}, nil
+				},
+			}),
+			verifyResult: func(t *testing.T, ns *nodeServer, err error) {
+				assert.Nil(t, ns)
+			},
+			expectedErr: errors.New("invalid MAX_CONCURRENT_MOUNTS env variable"),
+		},
+		{
+			testCaseName: "Negative: missing mount option policy",
+			envVars: map[string]string{
+				constants.KubeNodeName:           nodeID,
+				constants.MaxConcurrentMountsEnv: "",
+				constants.MountOptionPolicyEnv:   "/nonexistent/policy.yaml",
+			},
+			statsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				GetClusterNodeDataFn: func(nodeName string) (*utils.ClusterNodeData, error) {
+					return &utils.ClusterNodeData{
+						Region: testRegion,
+						Zone:   testZone,
+					}, nil
+				},
+			}),
+			verifyResult: func(t *testing.T, ns *nodeServer, err error) {
+				assert.Nil(t, ns)
+			},
+			expectedErr: errors.New("no such file or directory"),
+		},
+		{
+			testCaseName: "Negative: invalid value of verify bucket access",
+			envVars: map[string]string{
+				constants.KubeNodeName:          nodeID,
+				constants.MountOptionPolicyEnv:  "",
+				constants.VerifyBucketAccessEnv: "sometimes",
+			},
+			statsUtils: utils.NewFakeStatsUtilsImpl(utils.FakeStatsUtilsFuncStruct{
+				GetClusterNodeDataFn: func(nodeName string) (*utils.ClusterNodeData, error) {
+					return &utils.ClusterNodeData{
+						Region: testRegion,
+						Zone:   testZone,
+					}, nil
+				},
+			}),
+			verifyResult: func(t *testing.T, ns *nodeServer, err error) {
+				assert.Nil(t, ns)
+			},
+			expectedErr: errors.New("invalid VERIFY_BUCKET_ACCESS env variable"),
+		},
 	}
 
 	logger, teardown := GetTestLogger(t)
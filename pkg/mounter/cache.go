@@ -0,0 +1,190 @@
+package mounter
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+)
+
+const cacheReservationSuffix = ".size"
+
+var (
+	readDir   = os.ReadDir
+	readFile  = os.ReadFile
+	writeFile = os.WriteFile
+	statfs    = syscall.Statfs
+)
+
+// VolumeCache is the local cache directory allocated to one published volume
+type VolumeCache struct {
+	Dir string
+	// Size is the number of bytes the mounter may use in Dir
+	Size int64
+	// DiskFree is the number of bytes available on the disk of the cache root when Dir was allocated
+	DiskFree int64
+}
+
+// CacheManager hands out per-volume cache directories under Root and keeps the sum of their sizes within
+// the node budget. Each allocation is recorded in a "<dir>.size" file next to the cache directory, so the
+// node usage survives driver restarts without any in-memory state.
+type CacheManager struct {
+	Root string
+	// NodeBudget is the total number of bytes reserved for all volumes of the node, 0 means unlimited
+	NodeBudget int64
+	// VolumeBudget is the largest cache a single volume may request, 0 means unlimited
+	VolumeBudget int64
+
+	mu sync.Mutex
+}
+
+func NewCacheManager(root string, nodeBudget, volumeBudget int64) *CacheManager {
+	return &CacheManager{
+		Root:         root,
+		NodeBudget:   nodeBudget,
+		VolumeBudget: volumeBudget,
+	}
+}
+
+func (c *CacheManager) cacheDir(target string) string {
+	return path.Join(c.Root, fmt.Sprintf("%x", sha256.Sum256([]byte(target))))
+}
+
+// Allocate creates the cache directory of target and reserves size bytes for it. Allocating again for the
+// same target replaces the previous reservation.
+func (c *CacheManager) Allocate(target string, size int64) (*VolumeCache, error) {
+	if size <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid cache size %d", size)
+	}
+	if c.VolumeBudget > 0 && size > c.VolumeBudget {
+		return nil, status.Errorf(codes.InvalidArgument, "requested cache size %d exceeds the per-volume limit of %d bytes", size, c.VolumeBudget)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dir := c.cacheDir(target)
+	if c.NodeBudget > 0 {
+		used, err := c.reserved(path.Base(dir))
+		if err != nil {
+			klog.Errorf("CacheManager Allocate: Cannot compute cache usage under %s: %v", c.Root, err)
+			return nil, status.Errorf(codes.Internal, "cannot compute cache usage: %v", err)
+		}
+		if used+size > c.NodeBudget {
+			return nil, status.Errorf(codes.ResourceExhausted, "requested cache size %d exceeds the node cache budget, %d of %d bytes are already reserved",
+				size, used, c.NodeBudget)
+		}
+	}
+
+	if err := MakeDir(dir, 0750); err != nil {
+		klog.Errorf("CacheManager Allocate: Cannot create directory %s: %v", dir, err)
+		return nil, status.Errorf(codes.Internal, "cannot create cache directory %s: %v", dir, err)
+	}
+	if err := writeFile(dir+cacheReservationSuffix, []byte(strconv.FormatInt(size, 10)), 0600); err != nil {
+		klog.Errorf("CacheManager Allocate: Cannot record reservation of %s: %v", dir, err)
+		_ = RemoveAll(dir)
+		return nil, status.Errorf(codes.Internal, "cannot record cache reservation of %s: %v", dir, err)
+	}
+
+	free, err := diskFree(c.Root)
+	if err != nil {
+		klog.Errorf("CacheManager Allocate: Cannot get free space of %s: %v", c.Root, err)
+		_ = RemoveAll(dir)
+		_ = RemoveAll(dir + cacheReservationSuffix)
+		return nil, status.Errorf(codes.Internal, "cannot get free space of cache root %s: %v", c.Root, err)
+	}
+
+	klog.Infof("CacheManager Allocate: reserved %d bytes in %s for %s, %d bytes are free", size, dir, target, free)
+	return &VolumeCache{Dir: dir, Size: size, DiskFree: free}, nil
+}
+
+// Release deletes the cache directory of target and its reservation. It is a no-op on a nil manager,
+// so mounters can call it whether or not caching is configured.
+func (c *CacheManager) Release(target string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dir := c.cacheDir(target)
+	if err := RemoveAll(dir); err != nil {
+		klog.Errorf("CacheManager Release: Failed to remove cache directory %s: %v", dir, err)
+		// keep the reservation, the space is still in use
+		return
+	}
+	if err := RemoveAll(dir + cacheReservationSuffix); err != nil {
+		klog.Errorf("CacheManager Release: Failed to remove reservation of %s: %v", dir, err)
+		return
+	}
+	klog.Infof("CacheManager Release: removed cache directory %s", dir)
+}
+
+// reserved returns the bytes reserved by all volumes except the one with the exclude directory name
+func (c *CacheManager) reserved(exclude string) (int64, error) {
+	entries, err := readDir(c.Root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	var total int64
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, cacheReservationSuffix) || name == exclude+cacheReservationSuffix {
+			continue
+		}
+		data, err := readFile(path.Join(c.Root, name))
+		if err != nil {
+			return 0, err
+		}
+		size, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil {
+			klog.Warningf("CacheManager: ignoring malformed reservation %s: %v", name, err)
+			continue
+		}
+		total += size
+	}
+	return total, nil
+}
+
+// diskFree returns the number of bytes available to unprivileged users on the filesystem of dir
+func diskFree(dir string) (int64, error) {
+	var st syscall.Statfs_t
+	if err := statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return int64(st.Bavail) * st.Bsize, nil // #nosec G115: the free space of a filesystem fits in int64
+}
+
+// cacheSizeMiB converts a cache size to the MiB unit of the mounters' size options, rounding down
+func cacheSizeMiB(size int64) int64 {
+	if mib := size / (1024 * 1024); mib > 0 {
+		return mib
+	}
+	return 1
+}
+
+// setMountOption replaces every occurrence of the key or key=value option in options with the given one
+func setMountOption(options []string, key, value string) []string {
+	updated := make([]string, 0, len(options)+1)
+	for _, opt := range options {
+		if strings.TrimPrefix(strings.SplitN(opt, "=", 2)[0], "--") == key {
+			continue
+		}
+		updated = append(updated, opt)
+	}
+	if value == "" {
+		return append(updated, key)
+	}
+	return append(updated, key+"="+value)
+}
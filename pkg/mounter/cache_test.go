@@ -0,0 +1,96 @@
+package mounter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const mib = 1024 * 1024
+
+func TestCacheManager_Allocate(t *testing.T) {
+	cache := NewCacheManager(t.TempDir(), 3*mib, 2*mib)
+
+	vc, err := cache.Allocate("/target/a", 2*mib)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2*mib), vc.Size)
+	assert.Equal(t, cache.Root, filepath.Dir(vc.Dir))
+	assert.DirExists(t, vc.Dir)
+	assert.Positive(t, vc.DiskFree)
+
+	data, err := os.ReadFile(vc.Dir + cacheReservationSuffix)
+	assert.NoError(t, err)
+	assert.Equal(t, "2097152", string(data))
+
+	// allocating again for the same target replaces its reservation
+	again, err := cache.Allocate("/target/a", 2*mib)
+	assert.NoError(t, err)
+	assert.Equal(t, vc.Dir, again.Dir)
+
+	_, err = cache.Allocate("/target/b", 2*mib)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	vc, err = cache.Allocate("/target/b", mib)
+	assert.NoError(t, err)
+	assert.DirExists(t, vc.Dir)
+}
+
+func TestCacheManager_Allocate_InvalidSize(t *testing.T) {
+	cache := NewCacheManager(t.TempDir(), 0, mib)
+
+	_, err := cache.Allocate("/target/a", 0)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = cache.Allocate("/target/a", 2*mib)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, err.Error(), "per-volume limit")
+}
+
+func TestCacheManager_Allocate_Negative(t *testing.T) {
+	defer func() {
+		MakeDir = os.MkdirAll
+		writeFile = os.WriteFile
+	}()
+	cache := NewCacheManager(t.TempDir(), 0, 0)
+
+	MakeDir = func(string, os.FileMode) error { return errors.New("read-only file system") }
+	_, err := cache.Allocate("/target/a", mib)
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	MakeDir = os.MkdirAll
+	writeFile = func(string, []byte, os.FileMode) error { return errors.New("no space left on device") }
+	_, err = cache.Allocate("/target/a", mib)
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.NoDirExists(t, cache.cacheDir("/target/a"))
+}
+
+func TestCacheManager_Release(t *testing.T) {
+	cache := NewCacheManager(t.TempDir(), mib, 0)
+
+	vc, err := cache.Allocate("/target/a", mib)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(vc.Dir, "object"), []byte("data"), 0600))
+
+	cache.Release("/target/a")
+	assert.NoDirExists(t, vc.Dir)
+	assert.NoFileExists(t, vc.Dir+cacheReservationSuffix)
+
+	// the released space is available again
+	_, err = cache.Allocate("/target/b", mib)
+	assert.NoError(t, err)
+
+	var nilCache *CacheManager
+	nilCache.Release("/target/a")
+}
+
+func TestSetMountOption(t *testing.T) {
+	options := setMountOption([]string{"use_cache=/tmp", "--vfs-cache-mode=writes", "del_cache", "ro"}, "use_cache", "/cache")
+	options = setMountOption(options, "vfs-cache-mode", "full")
+	options = setMountOption(options, "del_cache", "")
+	assert.Equal(t, []string{"ro", "use_cache=/cache", "vfs-cache-mode=full", "del_cache"}, options)
+}
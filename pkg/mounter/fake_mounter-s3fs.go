@@ -1,6 +1,9 @@
 package mounter
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
 
 type fakes3fsMounter struct {
 	bucketName    string
@@ -13,6 +16,7 @@ type fakes3fsMounter struct {
 
 	isFailedMount   bool
 	isFailedUnmount bool
+	mountErr        error
 }
 
 func fakenewS3fsMounter(isFailedMount, isFailedUnmount bool) Mounter {
@@ -29,14 +33,17 @@ func fakenewS3fsMounter(isFailedMount, isFailedUnmount bool) Mounter {
 	}
 }
 
-func (s3fs *fakes3fsMounter) Mount(source string, target string) error {
+func (s3fs *fakes3fsMounter) Mount(_ context.Context, source string, target string) error {
+	if s3fs.mountErr != nil {
+		return s3fs.mountErr
+	}
 	if s3fs.isFailedMount {
 		return errors.New("failed to mount s3fs")
 	}
 	return nil
 }
 
-func (s3fs *fakes3fsMounter) Unmount(target string) error {
+func (s3fs *fakes3fsMounter) Unmount(_ context.Context, target string) error {
 	if s3fs.isFailedUnmount {
 		return errors.New("failed to unmount s3fs")
 	}
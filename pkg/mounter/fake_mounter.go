@@ -1,6 +1,8 @@
 package mounter
 
 import (
+	"fmt"
+
 	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
 )
 
@@ -14,18 +16,26 @@ const (
 )
 
 type FakeMounterFactory struct {
-	Mounter         string
-	IsFailedMount   bool
-	IsFailedUnmount bool
+	Mounter            string
+	IsFailedMount      bool
+	IsFailedUnmount    bool
+	IsFailedNewMounter bool
+	// MountErr is returned by Mount of the s3fs mounter instead of its default error
+	MountErr error
 }
 
-func (f *FakeMounterFactory) NewMounter(params MounterParams) Mounter {
+func (f *FakeMounterFactory) NewMounter(params MounterParams) (Mounter, error) {
+	if f.IsFailedNewMounter {
+		return nil, fmt.Errorf("unsupported mounter %q", f.Mounter)
+	}
 	switch f.Mounter {
 	case constants.S3FS:
-		return fakenewS3fsMounter(f.IsFailedMount, f.IsFailedUnmount)
+		m := fakenewS3fsMounter(f.IsFailedMount, f.IsFailedUnmount)
+		m.(*fakes3fsMounter).mountErr = f.MountErr
+		return m, nil
 	case constants.RClone:
-		return fakenewRcloneMounter(f.IsFailedMount, f.IsFailedUnmount)
+		return fakenewRcloneMounter(f.IsFailedMount, f.IsFailedUnmount), nil
 	default:
-		return fakenewS3fsMounter(f.IsFailedMount, f.IsFailedUnmount)
+		return fakenewS3fsMounter(f.IsFailedMount, f.IsFailedUnmount), nil
 	}
 }
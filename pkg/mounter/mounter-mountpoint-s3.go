@@ -0,0 +1,353 @@
+/*******************************************************************************
+ * IBM Confidential
+ * OCO Source Materials
+ * IBM Cloud Kubernetes Service, 5737-D43
+ * (C) Copyright IBM Corp. 2023 All Rights Reserved.
+ * The source code for this program is not published or otherwise divested of
+ * its trade secrets, irrespective of what has been deposited with
+ * the U.S. Copyright Office.
+ ******************************************************************************/
+
+// Package mounter
+package mounter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounter/utils"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+)
+
+// Mounter interface defined in mounter.go
+// MountpointS3Mounter Implements Mounter
+type MountpointS3Mounter struct {
+	BucketName    string //From Secret in SC
+	ObjectPath    string //From Secret in SC
+	EndPoint      string //From Secret in SC
+	LocConstraint string //From Secret in SC
+	AuthType      string
+	AccessKeys    string
+	UID           string
+	GID           string
+	ReadOnly      bool
+	MountOptions  []string
+	MounterUtils  utils.MounterUtils
+	VolumeCache   *VolumeCache
+	Cache         *CacheManager
+	// Readiness tells when the mount is ready
+	Readiness mounterapi.Readiness
+}
+
+const mntS3CredFile = "credentials" // #nosec G101: not password
+
+var (
+	writeMntS3CredWrap = writePass
+	// credentials are kept in the same per-target directory layout as the s3fs password file
+	removeMntS3CredFile = removeS3FSCredFile
+)
+
+type MountpointS3MounterParams struct {
+	SecretMap    map[string]string
+	MountOptions []string
+	MounterUtils utils.MounterUtils
+	Gid          string
+	ReadOnly     bool
+	VolumeCache  *VolumeCache
+	Cache        *CacheManager
+	// Readiness tells when the mount is ready
+	Readiness mounterapi.Readiness
+}
+
+func NewMountpointS3Mounter(params MountpointS3MounterParams) Mounter {
+	secretMap := params.SecretMap
+	klog.Info("-newMountpointS3Mounter-")
+
+	var (
+		val       string
+		check     bool
+		accessKey string
+		secretKey string
+		apiKey    string
+	)
+
+	mounter := &MountpointS3Mounter{}
+	mounter.MounterUtils = params.MounterUtils
+	mounter.Readiness = params.Readiness
+	mounter.VolumeCache = params.VolumeCache
+	mounter.Cache = params.Cache
+
+	if val, check = secretMap["cosEndpoint"]; check {
+		mounter.EndPoint = val
+	}
+	if val, check = secretMap["locationConstraint"]; check {
+		mounter.LocConstraint = val
+	}
+	if val, check = secretMap["bucketName"]; check {
+		mounter.BucketName = val
+	}
+	if val, check = secretMap["objectPath"]; check {
+		mounter.ObjectPath = val
+	}
+	if val, check = secretMap["accessKey"]; check {
+		accessKey = val
+	}
+	if val, check = secretMap["secretKey"]; check {
+		secretKey = val
+	}
+	if val, check = secretMap["apiKey"]; check {
+		apiKey = val
+	}
+
+	// mountpoint-s3 only understands AWS style credentials, so IAM API keys cannot be used
+	if accessKey == "" && apiKey != "" {
+		mounter.AuthType = "iam"
+	} else {
+		mounter.AccessKeys = fmt.Sprintf("%s:%s", accessKey, secretKey)
+		mounter.AuthType = "hmac"
+	}
+
+	// set uid and gid, if present in csi secret "data" section
+	if secretMap["uid"] != "" {
+		mounter.UID = secretMap["uid"]
+	}
+	if secretMap["gid"] != "" {
+		mounter.GID = secretMap["gid"]
+	}
+
+	// override gid, based on fsGroup defined in securityContext of the workload pod, if defined
+	if params.Gid != "" {
+		mounter.GID = params.Gid
+	}
+
+	// if gid is set but uid is not, default uid to gid
+	if mounter.GID != "" && mounter.UID == "" {
+		mounter.UID = mounter.GID
+	}
+
+	mounter.ReadOnly = params.ReadOnly
+
+	klog.Infof("newMountpointS3Mounter args:\n\tbucketName: [%s]\n\tobjectPath: [%s]\n\tendPoint: [%s]\n\tlocationConstraint: [%s]\n\tauthType: [%s]",
+		mounter.BucketName, mounter.ObjectPath, mounter.EndPoint, mounter.LocConstraint, mounter.AuthType)
+
+	mounter.MountOptions = updateMountpointS3Options(params.MountOptions, secretMap)
+	return mounter
+}
+
+// updateMountpointS3Options merges the storage class mount options with the ones from the secret.
+// Options are either mount-s3 flags with a value (key=value) or boolean flags (key).
+func updateMountpointS3Options(defaultMountOptions []string, secretMap map[string]string) []string {
+	mountOptsMap := make(map[string]string)
+
+	addOption := func(opt string) {
+		opt = strings.TrimSpace(opt)
+		if opt == "" {
+			return
+		}
+		opts := strings.SplitN(opt, "=", 2)
+		key := strings.TrimPrefix(strings.TrimSpace(opts[0]), "--")
+		if key == "" {
+			klog.Infof("Invalid mount option: %s", opt)
+			return
+		}
+		if len(opts) == 2 {
+			mountOptsMap[key] = strings.TrimSpace(opts[1])
+		} else {
+			mountOptsMap[key] = ""
+		}
+	}
+
+	for _, opt := range defaultMountOptions {
+		addOption(opt)
+	}
+	if stringData, ok := secretMap["mountOptions"]; ok {
+		for _, line := range strings.Split(stringData, "\n") {
+			addOption(line)
+		}
+	}
+
+	updatedOptions := make([]string, 0, len(mountOptsMap))
+	for k, v := range mountOptsMap {
+		if v == "" {
+			updatedOptions = append(updatedOptions, k)
+		} else {
+			updatedOptions = append(updatedOptions, fmt.Sprintf("%s=%s", k, v))
+		}
+	}
+
+	klog.Infof("Updated mountpoint-s3 Options: %v", updatedOptions)
+	return updatedOptions
+}
+
+func (mnts3 *MountpointS3Mounter) Mount(ctx context.Context, source string, target string) error {
+	klog.Info("-MountpointS3Mounter Mount-")
+	klog.Infof("Mount args:\n\tsource: <%s>\n\ttarget: <%s>", source, target)
+
+	if mnts3.AuthType != "hmac" {
+		klog.Error("MountpointS3Mounter Mount: HMAC credentials are required")
+		return status.Error(codes.InvalidArgument, "mountpoint-s3 mounter requires HMAC credentials (accessKey and secretKey)")
+	}
+
+	var credDir string
+	if mountWorker {
+		credDir = constants.MounterConfigPathOnHost
+	} else {
+		credDir = constants.MounterConfigPathOnPodMntS3
+	}
+
+	metaPath := path.Join(credDir, fmt.Sprintf("%x", sha256.Sum256([]byte(target))))
+	pathExist, err := checkPath(metaPath)
+	if err != nil {
+		klog.Errorf("MountpointS3Mounter Mount: Cannot stat directory %s: %v", metaPath, err)
+		return fmt.Errorf("MountpointS3Mounter Mount: Cannot stat directory %s: %v", metaPath, err)
+	}
+
+	if !pathExist {
+		if err = MakeDir(metaPath, 0700); err != nil {
+			klog.Errorf("MountpointS3Mounter Mount: Cannot create directory %s: %v", metaPath, err)
+			return fmt.Errorf("MountpointS3Mounter Mount: Cannot create directory %s: %v", metaPath, err)
+		}
+	}
+
+	credFile := path.Join(metaPath, mntS3CredFile)
+	keys := strings.SplitN(mnts3.AccessKeys, ":", 2)
+	credContent := fmt.Sprintf("[%s]\naws_access_key_id = %s\naws_secret_access_key = %s\n", utils.MountpointS3Profile, keys[0], keys[1])
+	if err = writeMntS3CredWrap(credFile, credContent); err != nil {
+		klog.Errorf("MountpointS3Mounter Mount: Cannot create file %s: %v", credFile, err)
+		return fmt.Errorf("MountpointS3Mounter Mount: Cannot create file %s: %v", credFile, err)
+	}
+
+	args, wnOp := mnts3.formulateMountOptions(mnts3.BucketName, target, credFile)
+
+	if mountWorker {
+		klog.Info("Mount on Worker started...")
+
+		jsonData, err := json.Marshal(wnOp)
+		if err != nil {
+			klog.Errorf("Error marshalling data: %v", err)
+			return err
+		}
+
+		err = mounterClient.Mount(ctx, &mounterapi.MountRequest{
+			Path:      target,
+			Bucket:    mnts3.BucketName,
+			Mounter:   constants.MountpointS3,
+			Args:      jsonData,
+			Readiness: &mnts3.Readiness,
+		})
+		if err != nil {
+			klog.Error("failed to mount on  worker...", err)
+			return err
+		}
+		return nil
+	}
+	klog.Info("NodeServer Mounting...")
+	return mnts3.MounterUtils.FuseMountWithOptions(target, constants.MountpointS3Binary, args, utils.MountOptions{
+		Env:       utils.MountpointS3Env(credFile),
+		Readiness: mnts3.Readiness,
+	})
+}
+
+func (mnts3 *MountpointS3Mounter) Unmount(ctx context.Context, target string) error {
+	klog.Info("-MountpointS3Mounter Unmount-")
+
+	if mountWorker {
+		klog.Info("Unmount on Worker started...")
+
+		err := mounterClient.Unmount(ctx, &mounterapi.UnmountRequest{Path: target})
+		if err != nil {
+			klog.Error("failed to unmount on  worker...", err)
+			return err
+		}
+
+		removeMntS3CredFile(constants.MounterConfigPathOnHost, target)
+		mnts3.Cache.Release(target)
+		return nil
+	}
+	klog.Info("NodeServer Unmounting...")
+
+	err := mnts3.MounterUtils.FuseUnmountWithContext(ctx, target, 0)
+	if err != nil {
+		return err
+	}
+
+	removeMntS3CredFile(constants.MounterConfigPathOnPodMntS3, target)
+	mnts3.Cache.Release(target)
+	return nil
+}
+
+func (mnts3 *MountpointS3Mounter) formulateMountOptions(bucket, target, credFile string) (nodeServerOp []string, workerNodeOp map[string]string) {
+	workerNodeOp = map[string]string{
+		"allow-other":      "true",
+		"force-path-style": "true",
+		// COS does not support the additional checksums mount-s3 sends by default
+		"upload-checksums": "off",
+	}
+
+	for _, val := range mnts3.MountOptions {
+		splitVal := strings.SplitN(val, "=", 2)
+		if len(splitVal) == 1 {
+			workerNodeOp[splitVal[0]] = "true"
+		} else {
+			workerNodeOp[splitVal[0]] = splitVal[1]
+		}
+	}
+
+	// options below are derived from the secret and pod, they always take precedence over mount options
+	workerNodeOp["endpoint-url"] = mnts3.EndPoint
+	workerNodeOp["credentials-file"] = credFile
+
+	if mnts3.LocConstraint != "" {
+		workerNodeOp["region"] = mnts3.LocConstraint
+	}
+
+	if mnts3.ObjectPath != "" {
+		// mount-s3 requires the prefix to end with a delimiter
+		workerNodeOp["prefix"] = strings.Trim(mnts3.ObjectPath, "/") + "/"
+	}
+
+	if mnts3.GID != "" {
+		workerNodeOp["gid"] = mnts3.GID
+	}
+	if mnts3.UID != "" {
+		workerNodeOp["uid"] = mnts3.UID
+	}
+
+	if mnts3.VolumeCache != nil {
+		workerNodeOp["cache"] = mnts3.VolumeCache.Dir
+		workerNodeOp["max-cache-size"] = strconv.FormatInt(cacheSizeMiB(mnts3.VolumeCache.Size), 10)
+	}
+
+	if mnts3.ReadOnly {
+		workerNodeOp["read-only"] = "true"
+		delete(workerNodeOp, "allow-delete")
+		delete(workerNodeOp, "allow-overwrite")
+	} else {
+		workerNodeOp["allow-delete"] = "true"
+		workerNodeOp["allow-overwrite"] = "true"
+	}
+
+	nodeServerOp = []string{bucket, target}
+	for key, val := range workerNodeOp {
+		switch {
+		case key == "credentials-file": // passed through the environment, not a mount-s3 flag
+			continue
+		case val == "true":
+			nodeServerOp = append(nodeServerOp, "--"+key)
+		case val == "false":
+			continue
+		default:
+			nodeServerOp = append(nodeServerOp, fmt.Sprintf("--%s=%s", key, val))
+		}
+	}
+	return
+}
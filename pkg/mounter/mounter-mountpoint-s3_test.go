@@ -0,0 +1,275 @@
+package mounter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
+	mounterUtils "github.com/IBM/ibm-object-csi-driver/pkg/mounter/utils"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var secretMapMntS3 = map[string]string{
+	"cosEndpoint":        "https://test-endpoint",
+	"locationConstraint": "test-loc-constraint",
+	"bucketName":         "test-bucket-name",
+	"objectPath":         "/test-obj-path/",
+	"accessKey":          "test-access-key",
+	"secretKey":          "test-secret-key",
+	"gid":                "1001",
+	"mountOptions":       "\nmax-threads=32\n--allow-root",
+}
+
+func stubMntS3Files(t *testing.T) *string {
+	var credContent string
+	Stat = func(string) (os.FileInfo, error) {
+		return nil, os.ErrNotExist
+	}
+	MakeDir = func(string, os.FileMode) error { return nil }
+	writeMntS3CredWrap = func(_, content string) error {
+		credContent = content
+		return nil
+	}
+	t.Cleanup(func() {
+		Stat = os.Stat
+		MakeDir = os.MkdirAll
+		writeMntS3CredWrap = writePass
+		mountWorker = true
+		mounterClient = newMounterClient()
+	})
+	return &credContent
+}
+
+func TestNewMountpointS3Mounter_HMAC(t *testing.T) {
+	mounter := NewMountpointS3Mounter(MountpointS3MounterParams{
+		SecretMap:    secretMapMntS3,
+		MountOptions: []string{"metadata-ttl=60"},
+		MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{}),
+		ReadOnly:     true,
+	})
+
+	mntS3Mounter, ok := mounter.(*MountpointS3Mounter)
+	assert.True(t, ok)
+
+	assert.Equal(t, secretMapMntS3["bucketName"], mntS3Mounter.BucketName)
+	assert.Equal(t, secretMapMntS3["objectPath"], mntS3Mounter.ObjectPath)
+	assert.Equal(t, secretMapMntS3["cosEndpoint"], mntS3Mounter.EndPoint)
+	assert.Equal(t, "hmac", mntS3Mounter.AuthType)
+	assert.Equal(t, "test-access-key:test-secret-key", mntS3Mounter.AccessKeys)
+	assert.Equal(t, "1001", mntS3Mounter.GID)
+	assert.Equal(t, "1001", mntS3Mounter.UID) // uid auto-set from gid when uid absent
+	assert.True(t, mntS3Mounter.ReadOnly)
+	assert.ElementsMatch(t, []string{"metadata-ttl=60", "max-threads=32", "allow-root"}, mntS3Mounter.MountOptions)
+}
+
+func TestNewMountpointS3Mounter_IAM(t *testing.T) {
+	mounter := NewMountpointS3Mounter(MountpointS3MounterParams{
+		SecretMap: map[string]string{
+			"cosEndpoint": "https://test-endpoint",
+			"bucketName":  "test-bucket-name",
+			"apiKey":      "test-api-key",
+		},
+		MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{}),
+	})
+
+	mntS3Mounter, ok := mounter.(*MountpointS3Mounter)
+	assert.True(t, ok)
+	assert.Equal(t, "iam", mntS3Mounter.AuthType)
+	assert.Empty(t, mntS3Mounter.AccessKeys)
+}
+
+func TestMountpointS3Mount_IAM_Negative(t *testing.T) {
+	mntS3 := &MountpointS3Mounter{AuthType: "iam"}
+
+	err := mntS3.Mount(context.Background(), source, target)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestMountpointS3Mount_NodeServer_Positive(t *testing.T) {
+	credContent := stubMntS3Files(t)
+	mountWorker = false
+
+	var (
+		gotComm string
+		gotArgs []string
+		gotEnv  []string
+	)
+	mntS3 := &MountpointS3Mounter{
+		BucketName:    "testBucket",
+		ObjectPath:    "/testObjectPath",
+		EndPoint:      "https://testEndpoint",
+		LocConstraint: "us-south",
+		AuthType:      "hmac",
+		AccessKeys:    "testAccessKey:testSecretKey",
+		UID:           "1001",
+		GID:           "1001",
+		MountOptions:  []string{"max-threads=32", "allow-delete=false"},
+		MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{
+			FuseMountWithOptionsFn: func(path, comm string, args []string, opts mounterUtils.MountOptions) error {
+				gotComm, gotArgs, gotEnv = comm, args, opts.Env
+				return nil
+			},
+		}),
+	}
+
+	err := mntS3.Mount(context.Background(), source, target)
+	assert.NoError(t, err)
+
+	assert.Equal(t, constants.MountpointS3Binary, gotComm)
+	assert.Equal(t, []string{"testBucket", target}, gotArgs[:2])
+	assert.ElementsMatch(t, []string{
+		"testBucket", target,
+		"--allow-other", "--force-path-style", "--upload-checksums=off",
+		"--max-threads=32", "--endpoint-url=https://testEndpoint", "--region=us-south",
+		"--prefix=testObjectPath/", "--gid=1001", "--uid=1001",
+		"--allow-delete", "--allow-overwrite",
+	}, gotArgs)
+	assert.Len(t, gotEnv, 2)
+	assert.Contains(t, gotEnv[0], "AWS_SHARED_CREDENTIALS_FILE="+constants.MounterConfigPathOnPodMntS3)
+	assert.Equal(t, "AWS_PROFILE=default", gotEnv[1])
+	assert.Equal(t, "[default]\naws_access_key_id = testAccessKey\naws_secret_access_key = testSecretKey\n", *credContent)
+}
+
+func TestMountpointS3Mount_ReadOnly(t *testing.T) {
+	mntS3 := &MountpointS3Mounter{
+		BucketName:   "testBucket",
+		EndPoint:     "https://testEndpoint",
+		ReadOnly:     true,
+		MountOptions: []string{"allow-delete"},
+	}
+
+	args, wnOp := mntS3.formulateMountOptions("testBucket", target, "/tmp/credentials")
+	assert.Contains(t, args, "--read-only")
+	assert.NotContains(t, args, "--allow-delete")
+	assert.NotContains(t, args, "--allow-overwrite")
+	assert.Equal(t, "/tmp/credentials", wnOp["credentials-file"])
+	for _, arg := range args {
+		assert.NotContains(t, arg, "credentials-file")
+	}
+}
+
+func TestMountpointS3Mount_VolumeCache(t *testing.T) {
+	mntS3 := &MountpointS3Mounter{
+		BucketName:  "testBucket",
+		EndPoint:    "https://testEndpoint",
+		VolumeCache: &VolumeCache{Dir: "/var/lib/coscsi-cache/abc", Size: 100 * 1024 * 1024},
+	}
+
+	args, wnOp := mntS3.formulateMountOptions("testBucket", target, "/tmp/credentials")
+	assert.Equal(t, "/var/lib/coscsi-cache/abc", wnOp["cache"])
+	assert.Equal(t, "100", wnOp["max-cache-size"])
+	assert.Contains(t, args, "--cache=/var/lib/coscsi-cache/abc")
+	assert.Contains(t, args, "--max-cache-size=100")
+}
+
+func TestMountpointS3Mount_WriteCredFails_Negative(t *testing.T) {
+	stubMntS3Files(t)
+	writeMntS3CredWrap = func(_, _ string) error {
+		return errors.New("write failed")
+	}
+
+	mntS3 := &MountpointS3Mounter{AuthType: "hmac", AccessKeys: "ak:sk"}
+
+	err := mntS3.Mount(context.Background(), source, target)
+	assert.ErrorContains(t, err, "write failed")
+}
+
+func TestMountpointS3Mount_WorkerNode_Positive(t *testing.T) {
+	stubMntS3Files(t)
+	mountWorker = true
+
+	client := &mounterapi.FakeClient{}
+	mounterClient = client
+
+	mntS3 := &MountpointS3Mounter{
+		BucketName: "testBucket",
+		EndPoint:   "https://testEndpoint",
+		AuthType:   "hmac",
+		AccessKeys: "ak:sk",
+	}
+
+	err := mntS3.Mount(context.Background(), source, target)
+	assert.NoError(t, err)
+	assert.Len(t, client.MountRequests, 1)
+
+	req := client.MountRequests[0]
+	var args map[string]string
+	assert.NoError(t, json.Unmarshal(req.Args, &args))
+	assert.Equal(t, target, req.Path)
+	assert.Equal(t, "testBucket", req.Bucket)
+	assert.Equal(t, constants.MountpointS3, req.Mounter)
+	assert.Equal(t, "https://testEndpoint", args["endpoint-url"])
+	assert.Contains(t, args["credentials-file"], constants.MounterConfigPathOnHost)
+}
+
+func TestMountpointS3Mount_WorkerNode_Negative(t *testing.T) {
+	stubMntS3Files(t)
+	mountWorker = true
+	mounterClient = &mounterapi.FakeClient{MountErr: errors.New("failed to create http request")}
+
+	mntS3 := &MountpointS3Mounter{AuthType: "hmac", AccessKeys: "ak:sk"}
+
+	err := mntS3.Mount(context.Background(), source, target)
+	assert.ErrorContains(t, err, "failed to create http request")
+}
+
+func TestMountpointS3Unmount_NodeServer(t *testing.T) {
+	mountWorker = false
+	defer func() {
+		mountWorker = true
+		removeMntS3CredFile = removeS3FSCredFile
+	}()
+
+	var removedFrom string
+	removeMntS3CredFile = func(credDir, _ string) {
+		removedFrom = credDir
+	}
+
+	mntS3 := &MountpointS3Mounter{MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{
+		FuseUnmountWithContextFn: func(_ context.Context, path string, _ int) error {
+			return nil
+		},
+	})}
+
+	err := mntS3.Unmount(context.Background(), target)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.MounterConfigPathOnPodMntS3, removedFrom)
+}
+
+func TestMountpointS3Unmount_NodeServer_Negative(t *testing.T) {
+	mountWorker = false
+	defer func() { mountWorker = true }()
+
+	mntS3 := &MountpointS3Mounter{MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{
+		FuseUnmountWithContextFn: func(_ context.Context, path string, _ int) error {
+			return errors.New("failed to unmount")
+		},
+	})}
+
+	err := mntS3.Unmount(context.Background(), target)
+	assert.ErrorContains(t, err, "failed to unmount")
+}
+
+func TestMountpointS3Unmount_WorkerNode(t *testing.T) {
+	mountWorker = true
+	defer func() {
+		mounterClient = newMounterClient()
+		removeMntS3CredFile = removeS3FSCredFile
+	}()
+
+	var removedFrom string
+	removeMntS3CredFile = func(credDir, _ string) {
+		removedFrom = credDir
+	}
+	mounterClient = &mounterapi.FakeClient{}
+
+	err := (&MountpointS3Mounter{}).Unmount(context.Background(), target)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.MounterConfigPathOnHost, removedFrom)
+}
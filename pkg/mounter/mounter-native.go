@@ -0,0 +1,270 @@
+/*******************************************************************************
+ * IBM Confidential
+ * OCO Source Materials
+ * IBM Cloud Kubernetes Service, 5737-D43
+ * (C) Copyright IBM Corp. 2023 All Rights Reserved.
+ * The source code for this program is not published or otherwise divested of
+ * its trade secrets, irrespective of what has been deposited with
+ * the U.S. Copyright Office.
+ ******************************************************************************/
+
+// Package mounter
+package mounter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounter/utils"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
+	"k8s.io/klog/v2"
+)
+
+// Mounter interface defined in mounter.go
+// NativeMounter Implements Mounter, the bucket is served by the Go FUSE filesystem of cos-csi-mounter
+type NativeMounter struct {
+	BucketName    string //From Secret in SC
+	ObjectPath    string //From Secret in SC
+	EndPoint      string //From Secret in SC
+	LocConstraint string //From Secret in SC
+	AuthType      string
+	AccessKeys    string
+	IAMEndpoint   string
+	UID           string
+	GID           string
+	ReadOnly      bool
+	MountOptions  []string
+	MounterUtils  utils.MounterUtils
+	VolumeCache   *VolumeCache
+	Cache         *CacheManager
+	// Readiness tells when the mount is ready
+	Readiness mounterapi.Readiness
+}
+
+const nativePasswdFile = ".passwd-native" // #nosec G101: not password
+
+var (
+	writeNativePassWrap = writePass
+	// credentials are kept in the same per-target directory layout as the s3fs password file
+	removeNativePassFile = removeS3FSCredFile
+)
+
+type NativeMounterParams struct {
+	SecretMap    map[string]string
+	MountOptions []string
+	MounterUtils utils.MounterUtils
+	Gid          string
+	ReadOnly     bool
+	VolumeCache  *VolumeCache
+	Cache        *CacheManager
+	// Readiness tells when the mount is ready
+	Readiness mounterapi.Readiness
+}
+
+func NewNativeMounter(params NativeMounterParams) Mounter {
+	secretMap := params.SecretMap
+	klog.Info("-newNativeMounter-")
+
+	var (
+		val       string
+		check     bool
+		accessKey string
+		secretKey string
+		apiKey    string
+	)
+
+	mounter := &NativeMounter{}
+	mounter.MounterUtils = params.MounterUtils
+	mounter.Readiness = params.Readiness
+	mounter.VolumeCache = params.VolumeCache
+	mounter.Cache = params.Cache
+
+	if val, check = secretMap["cosEndpoint"]; check {
+		mounter.EndPoint = val
+	}
+	if val, check = secretMap["locationConstraint"]; check {
+		mounter.LocConstraint = val
+	}
+	if val, check = secretMap["bucketName"]; check {
+		mounter.BucketName = val
+	}
+	if val, check = secretMap["objectPath"]; check {
+		mounter.ObjectPath = val
+	}
+	if val, check = secretMap["accessKey"]; check {
+		accessKey = val
+	}
+	if val, check = secretMap["secretKey"]; check {
+		secretKey = val
+	}
+	if val, check = secretMap["apiKey"]; check {
+		apiKey = val
+	}
+	if val, check = secretMap["iamEndpoint"]; check {
+		mounter.IAMEndpoint = val
+	}
+	if apiKey != "" {
+		mounter.AccessKeys = fmt.Sprintf(":%s", apiKey)
+		mounter.AuthType = "iam"
+	} else {
+		mounter.AccessKeys = fmt.Sprintf("%s:%s", accessKey, secretKey)
+		mounter.AuthType = "hmac"
+	}
+	if mounter.AuthType == "iam" && mounter.IAMEndpoint == "" {
+		mounter.IAMEndpoint = constants.PublicIAMEndpoint
+	}
+
+	// set uid and gid, if present in csi secret "data" section
+	if secretMap["uid"] != "" {
+		mounter.UID = secretMap["uid"]
+	}
+	if secretMap["gid"] != "" {
+		mounter.GID = secretMap["gid"]
+	}
+
+	// override gid, based on fsGroup defined in securityContext of the workload pod, if defined
+	if params.Gid != "" {
+		mounter.GID = params.Gid
+	}
+
+	// if gid is set but uid is not, default uid to gid
+	if mounter.GID != "" && mounter.UID == "" {
+		mounter.UID = mounter.GID
+	}
+
+	mounter.ReadOnly = params.ReadOnly
+
+	klog.Infof("newNativeMounter args:\n\tbucketName: [%s]\n\tobjectPath: [%s]\n\tendPoint: [%s]\n\tlocationConstraint: [%s]\n\tauthType: [%s]",
+		mounter.BucketName, mounter.ObjectPath, mounter.EndPoint, mounter.LocConstraint, mounter.AuthType)
+
+	// the native mounter takes the same key=value options as mountpoint-s3
+	mounter.MountOptions = updateMountpointS3Options(params.MountOptions, secretMap)
+	return mounter
+}
+
+func (native *NativeMounter) Mount(ctx context.Context, source string, target string) error {
+	klog.Info("-NativeMounter Mount-")
+	klog.Infof("Mount args:\n\tsource: <%s>\n\ttarget: <%s>", source, target)
+
+	// the filesystem is served by the cos-csi-mounter binary, there is nothing to run inside the driver pod
+	if !mountWorker {
+		klog.Error("NativeMounter Mount: the native mounter requires the cos-csi-mounter service")
+		return errors.New("native mounter is only supported with the cos-csi-mounter service")
+	}
+
+	metaPath := path.Join(constants.MounterConfigPathOnHost, fmt.Sprintf("%x", sha256.Sum256([]byte(target))))
+	pathExist, err := checkPath(metaPath)
+	if err != nil {
+		klog.Errorf("NativeMounter Mount: Cannot stat directory %s: %v", metaPath, err)
+		return fmt.Errorf("NativeMounter Mount: Cannot stat directory %s: %v", metaPath, err)
+	}
+
+	if !pathExist {
+		if err = MakeDir(metaPath, 0700); err != nil {
+			klog.Errorf("NativeMounter Mount: Cannot create directory %s: %v", metaPath, err)
+			return fmt.Errorf("NativeMounter Mount: Cannot create directory %s: %v", metaPath, err)
+		}
+	}
+
+	passwdFile := path.Join(metaPath, nativePasswdFile)
+	if err = writeNativePassWrap(passwdFile, native.AccessKeys); err != nil {
+		klog.Errorf("NativeMounter Mount: Cannot create file %s: %v", passwdFile, err)
+		return fmt.Errorf("NativeMounter Mount: Cannot create file %s: %v", passwdFile, err)
+	}
+
+	wnOp := native.formulateMountOptions(passwdFile)
+
+	klog.Info("Mount on Worker started...")
+
+	jsonData, err := json.Marshal(wnOp)
+	if err != nil {
+		klog.Errorf("Error marshalling data: %v", err)
+		return err
+	}
+
+	err = mounterClient.Mount(ctx, &mounterapi.MountRequest{
+		Path:      target,
+		Bucket:    native.BucketName,
+		Mounter:   constants.Native,
+		Args:      jsonData,
+		Readiness: &native.Readiness,
+	})
+	if err != nil {
+		klog.Error("failed to mount on  worker...", err)
+		return err
+	}
+	return nil
+}
+
+func (native *NativeMounter) Unmount(ctx context.Context, target string) error {
+	klog.Info("-NativeMounter Unmount-")
+
+	if !mountWorker {
+		// nothing can have been mounted without the cos-csi-mounter service
+		return native.MounterUtils.FuseUnmountWithContext(ctx, target, 0)
+	}
+
+	klog.Info("Unmount on Worker started...")
+
+	err := mounterClient.Unmount(ctx, &mounterapi.UnmountRequest{Path: target})
+	if err != nil {
+		klog.Error("failed to unmount on  worker...", err)
+		return err
+	}
+
+	removeNativePassFile(constants.MounterConfigPathOnHost, target)
+	native.Cache.Release(target)
+	return nil
+}
+
+func (native *NativeMounter) formulateMountOptions(passwdFile string) map[string]string {
+	workerNodeOp := map[string]string{
+		"allow-other": "true",
+	}
+
+	for _, val := range native.MountOptions {
+		splitVal := strings.SplitN(val, "=", 2)
+		if len(splitVal) == 1 {
+			workerNodeOp[splitVal[0]] = "true"
+		} else {
+			workerNodeOp[splitVal[0]] = splitVal[1]
+		}
+	}
+
+	// options below are derived from the secret and pod, they always take precedence over mount options
+	workerNodeOp["endpoint-url"] = native.EndPoint
+	workerNodeOp["auth-type"] = native.AuthType
+	workerNodeOp["passwd-file"] = passwdFile
+
+	if native.AuthType == "iam" {
+		workerNodeOp["iam-endpoint"] = native.IAMEndpoint
+	}
+	if native.LocConstraint != "" {
+		workerNodeOp["region"] = native.LocConstraint
+	}
+	if native.ObjectPath != "" {
+		workerNodeOp["prefix"] = strings.Trim(native.ObjectPath, "/")
+	}
+	if native.GID != "" {
+		workerNodeOp["gid"] = native.GID
+	}
+	if native.UID != "" {
+		workerNodeOp["uid"] = native.UID
+	}
+	if native.VolumeCache != nil {
+		// the native filesystem buffers files being written in its cache directory
+		workerNodeOp["cache-dir"] = native.VolumeCache.Dir
+	}
+	if native.ReadOnly {
+		workerNodeOp["read-only"] = "true"
+	} else {
+		delete(workerNodeOp, "read-only")
+	}
+	return workerNodeOp
+}
@@ -0,0 +1,164 @@
+package mounter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
+	mounterUtils "github.com/IBM/ibm-object-csi-driver/pkg/mounter/utils"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
+	"github.com/stretchr/testify/assert"
+)
+
+var secretMapNative = map[string]string{
+	"cosEndpoint":        "https://test-endpoint",
+	"locationConstraint": "test-loc-constraint",
+	"bucketName":         "test-bucket-name",
+	"objectPath":         "/test-obj-path/",
+	"accessKey":          "test-access-key",
+	"secretKey":          "test-secret-key",
+	"gid":                "1001",
+	"mountOptions":       "\nread-ahead=1048576\n--debug",
+}
+
+func stubNativeFiles(t *testing.T) *string {
+	var passContent string
+	Stat = func(string) (os.FileInfo, error) {
+		return nil, os.ErrNotExist
+	}
+	MakeDir = func(string, os.FileMode) error { return nil }
+	writeNativePassWrap = func(_, content string) error {
+		passContent = content
+		return nil
+	}
+	t.Cleanup(func() {
+		Stat = os.Stat
+		MakeDir = os.MkdirAll
+		writeNativePassWrap = writePass
+		mountWorker = true
+		mounterClient = newMounterClient()
+		removeNativePassFile = removeS3FSCredFile
+	})
+	return &passContent
+}
+
+func TestNewNativeMounter(t *testing.T) {
+	mounter := NewNativeMounter(NativeMounterParams{
+		SecretMap:    secretMapNative,
+		MountOptions: []string{"metadata-ttl=60s"},
+		MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{}),
+		ReadOnly:     true,
+	})
+
+	nativeMounter, ok := mounter.(*NativeMounter)
+	assert.True(t, ok)
+
+	assert.Equal(t, secretMapNative["bucketName"], nativeMounter.BucketName)
+	assert.Equal(t, secretMapNative["objectPath"], nativeMounter.ObjectPath)
+	assert.Equal(t, "hmac", nativeMounter.AuthType)
+	assert.Equal(t, "test-access-key:test-secret-key", nativeMounter.AccessKeys)
+	assert.Empty(t, nativeMounter.IAMEndpoint)
+	assert.Equal(t, "1001", nativeMounter.GID)
+	assert.Equal(t, "1001", nativeMounter.UID)
+	assert.True(t, nativeMounter.ReadOnly)
+	assert.ElementsMatch(t, []string{"metadata-ttl=60s", "read-ahead=1048576", "debug"}, nativeMounter.MountOptions)
+}
+
+func TestNativeMount_Worker_Positive(t *testing.T) {
+	passContent := stubNativeFiles(t)
+
+	client := &mounterapi.FakeClient{}
+	mounterClient = client
+
+	native := &NativeMounter{
+		BucketName:    "testBucket",
+		ObjectPath:    "/testObjectPath/",
+		EndPoint:      "https://testEndpoint",
+		LocConstraint: "us-south",
+		AuthType:      "iam",
+		AccessKeys:    ":testApiKey",
+		IAMEndpoint:   "https://iam.test",
+		UID:           "1001",
+		GID:           "1002",
+		ReadOnly:      true,
+		MountOptions:  []string{"metadata-ttl=60s", "debug"},
+	}
+
+	err := native.Mount(context.Background(), source, target)
+	assert.NoError(t, err)
+	assert.Equal(t, ":testApiKey", *passContent)
+
+	assert.Len(t, client.MountRequests, 1)
+	req := client.MountRequests[0]
+	var args map[string]string
+	assert.NoError(t, json.Unmarshal(req.Args, &args))
+	assert.Equal(t, target, req.Path)
+	assert.Equal(t, "testBucket", req.Bucket)
+	assert.Equal(t, constants.Native, req.Mounter)
+	assert.Equal(t, "https://testEndpoint", args["endpoint-url"])
+	assert.Equal(t, "us-south", args["region"])
+	assert.Equal(t, "iam", args["auth-type"])
+	assert.Equal(t, "https://iam.test", args["iam-endpoint"])
+	assert.Equal(t, "testObjectPath", args["prefix"])
+	assert.Equal(t, "1001", args["uid"])
+	assert.Equal(t, "1002", args["gid"])
+	assert.Equal(t, "true", args["read-only"])
+	assert.Equal(t, "true", args["allow-other"])
+	assert.Equal(t, "60s", args["metadata-ttl"])
+	assert.Equal(t, "true", args["debug"])
+	assert.Contains(t, args["passwd-file"], constants.MounterConfigPathOnHost)
+}
+
+func TestNativeMount_ReadOnlyOptionIgnored(t *testing.T) {
+	native := &NativeMounter{AuthType: "hmac", MountOptions: []string{"read-only"}}
+
+	wnOp := native.formulateMountOptions("/tmp/passwd")
+	assert.NotContains(t, wnOp, "read-only")
+	assert.NotContains(t, wnOp, "iam-endpoint")
+	assert.Equal(t, "/tmp/passwd", wnOp["passwd-file"])
+}
+
+func TestNativeMount_VolumeCache(t *testing.T) {
+	native := &NativeMounter{AuthType: "hmac", VolumeCache: &VolumeCache{Dir: "/var/lib/coscsi-cache/abc", Size: 1024}}
+
+	wnOp := native.formulateMountOptions("/tmp/passwd")
+	assert.Equal(t, "/var/lib/coscsi-cache/abc", wnOp["cache-dir"])
+}
+
+func TestNativeMount_Negative(t *testing.T) {
+	stubNativeFiles(t)
+
+	mountWorker = false
+	err := (&NativeMounter{}).Mount(context.Background(), source, target)
+	assert.ErrorContains(t, err, "only supported with the cos-csi-mounter service")
+
+	mountWorker = true
+	writeNativePassWrap = func(string, string) error { return errors.New("write failed") }
+	err = (&NativeMounter{}).Mount(context.Background(), source, target)
+	assert.ErrorContains(t, err, "Cannot create file")
+
+	writeNativePassWrap = func(string, string) error { return nil }
+	mounterClient = &mounterapi.FakeClient{MountErr: errors.New("mount failed")}
+	err = (&NativeMounter{}).Mount(context.Background(), source, target)
+	assert.EqualError(t, err, "mount failed")
+}
+
+func TestNativeUnmount(t *testing.T) {
+	stubNativeFiles(t)
+
+	var removed bool
+	removeNativePassFile = func(string, string) { removed = true }
+	mounterClient = &mounterapi.FakeClient{}
+
+	native := &NativeMounter{MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{})}
+	assert.NoError(t, native.Unmount(context.Background(), target))
+	assert.True(t, removed)
+
+	removed = false
+	mounterClient = &mounterapi.FakeClient{UnmountErr: errors.New("unmount failed")}
+	assert.EqualError(t, native.Unmount(context.Background(), target), "unmount failed")
+	assert.False(t, removed)
+}
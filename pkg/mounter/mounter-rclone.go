@@ -13,6 +13,7 @@ package mounter
 
 import (
 	"bufio"
+	"context"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
@@ -23,6 +24,8 @@ import (
 
 	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
 	"github.com/IBM/ibm-object-csi-driver/pkg/mounter/utils"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
+	"github.com/IBM/ibm-object-csi-driver/pkg/s3client"
 	"k8s.io/klog/v2"
 )
 
@@ -43,6 +46,16 @@ type RcloneMounter struct {
 	ReadOnly          bool
 	MountOptions      []string
 	MounterUtils      utils.MounterUtils
+	VolumeCache       *VolumeCache
+	Cache             *CacheManager
+	// CreateObjectPath creates ObjectPath in the bucket before mounting if it does not exist
+	CreateObjectPath bool
+	// VerifyBucketAccess checks that the bucket can be listed with the credentials before mounting
+	VerifyBucketAccess bool
+	// SELinuxContext is the context the mount is labeled with, empty to keep the default fusefs label
+	SELinuxContext string
+	// Readiness tells when the mount is ready
+	Readiness mounterapi.Readiness
 }
 
 const (
@@ -63,6 +76,15 @@ type RcloneMounterParams struct {
 	MounterUtils utils.MounterUtils
 	Gid          string
 	ReadOnly     bool
+	VolumeCache  *VolumeCache
+	Cache        *CacheManager
+	// CreateObjectPath creates the objectPath of the secret in the bucket if it does not exist yet
+	CreateObjectPath bool
+	// VerifyBucketAccess checks that the bucket can be listed with the credentials before mounting
+	VerifyBucketAccess bool
+	SELinuxContext     string
+	// Readiness tells when the mount is ready
+	Readiness mounterapi.Readiness
 }
 
 func NewRcloneMounter(params RcloneMounterParams) Mounter {
@@ -141,7 +163,7 @@ func NewRcloneMounter(params RcloneMounterParams) Mounter {
 		mounter.UID = mounter.GID
 	}
 
-	// To mount the bucket in read-only mode based on PVC accessMode "ReadOnlyMany"
+	// To mount the bucket in read-only mode based on PVC accessMode "ReadOnlyMany" or a readOnly volumeMount
 	mounter.ReadOnly = params.ReadOnly
 
 	klog.Infof("newRcloneMounter args:\n\tbucketName: [%s]\n\tobjectPath: [%s]\n\tendPoint: [%s]\n\tlocationConstraint: [%s]\n\tauthType: [%s]",
@@ -151,6 +173,12 @@ func NewRcloneMounter(params RcloneMounterParams) Mounter {
 	mounter.MountOptions = updatedOptions
 
 	mounter.MounterUtils = mounterUtils
+	mounter.Readiness = params.Readiness
+	mounter.VolumeCache = params.VolumeCache
+	mounter.Cache = params.Cache
+	mounter.CreateObjectPath = params.CreateObjectPath
+	mounter.VerifyBucketAccess = params.VerifyBucketAccess
+	mounter.SELinuxContext = params.SELinuxContext
 
 	return mounter
 }
@@ -200,10 +228,27 @@ func updateMountOptions(dafaultMountOptions []string, secretMap map[string]strin
 	return updatedOptions
 }
 
-func (rclone *RcloneMounter) Mount(source string, target string) error {
+func (rclone *RcloneMounter) Mount(ctx context.Context, source string, target string) error {
 	klog.Info("-RcloneMounter Mount-")
 	klog.Infof("Mount args:\n\tsource: <%s>\n\ttarget: <%s>", source, target)
 
+	createObjectPath := rclone.CreateObjectPath && rclone.ObjectPath != ""
+	if rclone.VerifyBucketAccess || createObjectPath {
+		client := newObjectClient(rclone.EndPoint, rclone.LocConstraint, objectStorageCredentials(rclone.AuthType, rclone.AccessKeys, rclone.IAMEndpoint))
+		if rclone.VerifyBucketAccess {
+			if err := s3client.VerifyBucketAccess(client, rclone.EndPoint, rclone.BucketName, rclone.ObjectPath, false); err != nil {
+				klog.Errorf("RcloneMounter Mount: %v", err)
+				return err
+			}
+		}
+		if createObjectPath {
+			if err := ensureObjectPath(client, rclone.BucketName, rclone.ObjectPath); err != nil {
+				klog.Errorf("RcloneMounter Mount: Cannot create object path %s: %v", rclone.ObjectPath, err)
+				return fmt.Errorf("RcloneMounter Mount: Cannot create object path %s: %v", rclone.ObjectPath, err)
+			}
+		}
+	}
+
 	var bucketName string
 	var err error
 
@@ -238,9 +283,13 @@ func (rclone *RcloneMounter) Mount(source string, target string) error {
 			return err
 		}
 
-		payload := fmt.Sprintf(`{"path":"%s","bucket":"%s","mounter":"%s","args":%s}`, target, bucketName, constants.RClone, jsonData)
-
-		err = mounterRequest(payload, "http://unix/api/cos/mount")
+		err = mounterClient.Mount(ctx, &mounterapi.MountRequest{
+			Path:      target,
+			Bucket:    bucketName,
+			Mounter:   constants.RClone,
+			Args:      jsonData,
+			Readiness: &rclone.Readiness,
+		})
 		if err != nil {
 			klog.Error("failed to mount on  worker...", err)
 			return err
@@ -248,34 +297,34 @@ func (rclone *RcloneMounter) Mount(source string, target string) error {
 		return nil
 	}
 	klog.Info("NodeServer Mounting...")
-	return rclone.MounterUtils.FuseMount(target, constants.RClone, args)
+	return rclone.MounterUtils.FuseMountWithOptions(target, constants.RClone, args, utils.MountOptions{Readiness: rclone.Readiness})
 }
 
-func (rclone *RcloneMounter) Unmount(target string) error {
+func (rclone *RcloneMounter) Unmount(ctx context.Context, target string) error {
 	klog.Info("-RcloneMounter Unmount-")
 
 	if mountWorker {
 		klog.Info("Unmount on Worker started...")
 
-		payload := fmt.Sprintf(`{"path":"%s"}`, target)
-
-		err := mounterRequest(payload, "http://unix/api/cos/unmount")
+		err := mounterClient.Unmount(ctx, &mounterapi.UnmountRequest{Path: target})
 		if err != nil {
 			klog.Error("failed to unmount on  worker...", err)
 			return err
 		}
 
 		removeConfigFile(constants.MounterConfigPathOnHost, target)
+		rclone.Cache.Release(target)
 		return nil
 	}
 	klog.Info("NodeServer Unmounting...")
 
-	err := rclone.MounterUtils.FuseUnmount(target)
+	err := rclone.MounterUtils.FuseUnmountWithContext(ctx, target, 0)
 	if err != nil {
 		return err
 	}
 
 	removeConfigFile(constants.MounterConfigPathOnPodRclone, target)
+	rclone.Cache.Release(target)
 	return nil
 }
 
@@ -321,8 +370,7 @@ func createConfig(configPathWithVolID string, rclone *RcloneMounter) error {
 
 	configParams = append(configParams, rclone.MountOptions...)
 
-	if err := MakeDir(configPathWithVolID, 0755); // #nosec G301: used for rclone
-	err != nil {
+	if err := MakeDir(configPathWithVolID, 0700); err != nil {
 		klog.Errorf("RcloneMounter Mount: Cannot create directory %s: %v", configPathWithVolID, err)
 		return err
 	}
@@ -339,7 +387,7 @@ func createConfig(configPathWithVolID string, rclone *RcloneMounter) error {
 		}
 	}()
 
-	err = Chmod(configFile, 0644) // #nosec G302: used for rclone
+	err = Chmod(configFile, 0600)
 	if err != nil {
 		klog.Errorf("RcloneMounter Mount: Cannot change permissions on file  %s: %v", configFileName, err)
 		return err
@@ -363,6 +411,12 @@ func createConfig(configPathWithVolID string, rclone *RcloneMounter) error {
 }
 
 func (rclone *RcloneMounter) formulateMountOptions(bucket, target, configPathWithVolID string) (nodeServerOp []string, workerNodeOp map[string]string) {
+	vfsCacheMode := "writes"
+	if rclone.VolumeCache != nil {
+		// with a managed cache, reads are cached as well and rclone evicts files beyond the budget
+		vfsCacheMode = "full"
+	}
+
 	nodeServerOp = []string{
 		"mount",
 		bucket,
@@ -371,7 +425,7 @@ func (rclone *RcloneMounter) formulateMountOptions(bucket, target, configPathWit
 		"--daemon",
 		"--config=" + configPathWithVolID + "/" + configFileName,
 		"--log-file=/var/log/rclone.log",
-		"--vfs-cache-mode=writes",
+		"--vfs-cache-mode=" + vfsCacheMode,
 	}
 
 	workerNodeOp = map[string]string{
@@ -379,7 +433,7 @@ func (rclone *RcloneMounter) formulateMountOptions(bucket, target, configPathWit
 		"daemon":         "true",
 		"config":         configPathWithVolID + "/" + configFileName,
 		"log-file":       "/var/log/rclone.log",
-		"vfs-cache-mode": "writes",
+		"vfs-cache-mode": vfsCacheMode,
 	}
 
 	if rclone.GID != "" {
@@ -394,6 +448,17 @@ func (rclone *RcloneMounter) formulateMountOptions(bucket, target, configPathWit
 		nodeServerOp = append(nodeServerOp, "--read-only")
 		workerNodeOp["read-only"] = "true"
 	}
+	if rclone.VolumeCache != nil {
+		maxSize := fmt.Sprintf("%dM", cacheSizeMiB(rclone.VolumeCache.Size))
+		nodeServerOp = append(nodeServerOp, "--cache-dir="+rclone.VolumeCache.Dir, "--vfs-cache-max-size="+maxSize)
+		workerNodeOp["cache-dir"] = rclone.VolumeCache.Dir
+		workerNodeOp["vfs-cache-max-size"] = maxSize
+	}
+	if rclone.SELinuxContext != "" {
+		// rclone hands --option values to libfuse
+		nodeServerOp = append(nodeServerOp, "--option="+fuseContextOption(rclone.SELinuxContext))
+		workerNodeOp["context"] = rclone.SELinuxContext
+	}
 	return
 }
 
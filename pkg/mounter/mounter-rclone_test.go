@@ -1,11 +1,14 @@
 package mounter
 
 import (
+	"context"
 	"errors"
 	"os"
 	"testing"
 
 	mounterUtils "github.com/IBM/ibm-object-csi-driver/pkg/mounter/utils"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
+	"github.com/IBM/ibm-object-csi-driver/pkg/s3client"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -149,7 +152,7 @@ func TestRcloneMount_NodeServer_Positive(t *testing.T) {
 		GID:        "testGID",
 		UID:        "testUID",
 		MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{
-			FuseMountFn: func(path, comm string, args []string) error {
+			FuseMountWithOptionsFn: func(path, comm string, args []string, opts mounterUtils.MountOptions) error {
 				return nil
 			},
 		}),
@@ -159,7 +162,7 @@ func TestRcloneMount_NodeServer_Positive(t *testing.T) {
 		return nil
 	}
 
-	err := rclone.Mount(source, target)
+	err := rclone.Mount(context.Background(), source, target)
 	assert.NoError(t, err)
 }
 
@@ -170,7 +173,7 @@ func TestRcloneMount_CreateConfigFails_Negative(t *testing.T) {
 		return errors.New("failed to create config file")
 	}
 
-	err := rclone.Mount(source, target)
+	err := rclone.Mount(context.Background(), source, target)
 	assert.Error(t, err)
 	assert.EqualError(t, err, "failed to create config file")
 }
@@ -186,7 +189,7 @@ func TestRcloneMount_WorkerNode_Positive(t *testing.T) {
 		UID:        "testUID",
 		ObjectPath: "testObjectPath",
 		MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{
-			FuseMountFn: func(path, comm string, args []string) error {
+			FuseMountWithOptionsFn: func(path, comm string, args []string, opts mounterUtils.MountOptions) error {
 				return nil
 			},
 		}),
@@ -195,11 +198,9 @@ func TestRcloneMount_WorkerNode_Positive(t *testing.T) {
 	createConfigWrap = func(_ string, _ *RcloneMounter) error {
 		return nil
 	}
-	mounterRequest = func(_, _ string) error {
-		return nil
-	}
+	mounterClient = &mounterapi.FakeClient{}
 
-	err := rclone.Mount(source, target)
+	err := rclone.Mount(context.Background(), source, target)
 	assert.NoError(t, err)
 }
 
@@ -214,7 +215,7 @@ func TestRcloneMount_WorkerNode_Negative(t *testing.T) {
 		UID:        "testUID",
 		ObjectPath: "testObjectPath",
 		MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{
-			FuseMountFn: func(path, comm string, args []string) error {
+			FuseMountWithOptionsFn: func(path, comm string, args []string, opts mounterUtils.MountOptions) error {
 				return nil
 			},
 		}),
@@ -223,11 +224,9 @@ func TestRcloneMount_WorkerNode_Negative(t *testing.T) {
 	createConfigWrap = func(_ string, _ *RcloneMounter) error {
 		return nil
 	}
-	mounterRequest = func(_, _ string) error {
-		return errors.New("failed to create http request")
-	}
+	mounterClient = &mounterapi.FakeClient{MountErr: errors.New("failed to create http request")}
 
-	err := rclone.Mount(source, target)
+	err := rclone.Mount(context.Background(), source, target)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to create http request")
 }
@@ -238,12 +237,12 @@ func TestRcloneUnmount_NodeServer(t *testing.T) {
 	removeConfigFile = func(_, _ string) {}
 
 	rclone := &RcloneMounter{MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{
-		FuseUnmountFn: func(path string) error {
+		FuseUnmountWithContextFn: func(_ context.Context, path string, _ int) error {
 			return nil
 		},
 	})}
 
-	err := rclone.Unmount(target)
+	err := rclone.Unmount(context.Background(), target)
 	assert.NoError(t, err)
 }
 
@@ -253,16 +252,14 @@ func TestRcloneUnmount_WorkerNode(t *testing.T) {
 	removeConfigFile = func(_, _ string) {}
 
 	rclone := &RcloneMounter{MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{
-		FuseUnmountFn: func(path string) error {
+		FuseUnmountWithContextFn: func(_ context.Context, path string, _ int) error {
 			return nil
 		},
 	})}
 
-	mounterRequest = func(_, _ string) error {
-		return nil
-	}
+	mounterClient = &mounterapi.FakeClient{}
 
-	err := rclone.Unmount(target)
+	err := rclone.Unmount(context.Background(), target)
 	assert.NoError(t, err)
 }
 
@@ -270,16 +267,14 @@ func TestRcloneUnmount_WorkerNode_Negative(t *testing.T) {
 	mountWorker = true
 
 	rclone := &RcloneMounter{MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{
-		FuseUnmountFn: func(path string) error {
+		FuseUnmountWithContextFn: func(_ context.Context, path string, _ int) error {
 			return nil
 		},
 	})}
 
-	mounterRequest = func(_, _ string) error {
-		return errors.New("failed to create http request")
-	}
+	mounterClient = &mounterapi.FakeClient{UnmountErr: errors.New("failed to create http request")}
 
-	err := rclone.Unmount(target)
+	err := rclone.Unmount(context.Background(), target)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to create http request")
 }
@@ -290,12 +285,12 @@ func TestRcloneUnmount_NodeServer_Negative(t *testing.T) {
 	removeConfigFile = func(_, _ string) {}
 
 	rclone := &RcloneMounter{MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{
-		FuseUnmountFn: func(path string) error {
+		FuseUnmountWithContextFn: func(_ context.Context, path string, _ int) error {
 			return errors.New("failed to unmount")
 		},
 	})}
 
-	err := rclone.Unmount(target)
+	err := rclone.Unmount(context.Background(), target)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to unmount")
 }
@@ -585,3 +580,78 @@ func TestFormulateRcloneMountOptions_GidUid(t *testing.T) {
 	assert.Equal(t, "1000", workerOp["gid"])
 	assert.Equal(t, "1000", workerOp["uid"])
 }
+
+func TestFormulateRcloneMountOptions_VolumeCache(t *testing.T) {
+	rclone := &RcloneMounter{
+		EndPoint:    "test-endpoint",
+		VolumeCache: &VolumeCache{Dir: "/var/lib/coscsi-cache/abc", Size: 512 * 1024 * 1024},
+	}
+
+	nodeOp, workerOp := rclone.formulateMountOptions("bucket", "/target", "/config")
+
+	assert.Contains(t, nodeOp, "--vfs-cache-mode=full")
+	assert.NotContains(t, nodeOp, "--vfs-cache-mode=writes")
+	assert.Contains(t, nodeOp, "--cache-dir=/var/lib/coscsi-cache/abc")
+	assert.Contains(t, nodeOp, "--vfs-cache-max-size=512M")
+	assert.Equal(t, "full", workerOp["vfs-cache-mode"])
+	assert.Equal(t, "/var/lib/coscsi-cache/abc", workerOp["cache-dir"])
+	assert.Equal(t, "512M", workerOp["vfs-cache-max-size"])
+}
+
+func TestRcloneMount_CreateObjectPath(t *testing.T) {
+	mountWorker = true
+
+	createConfigWrap = func(_ string, _ *RcloneMounter) error {
+		return nil
+	}
+	mounterClient = &mounterapi.FakeClient{}
+
+	client := s3client.NewFakeObjectClient(nil)
+	stubObjectClient(t, client)
+
+	rclone := &RcloneMounter{
+		BucketName:       "testBucket",
+		ObjectPath:       "team-a/app-0",
+		AuthType:         "hmac",
+		AccessKeys:       "ak:sk",
+		CreateObjectPath: true,
+	}
+
+	err := rclone.Mount(context.Background(), source, target)
+	assert.NoError(t, err)
+	assert.Contains(t, client.Objects, "team-a/app-0/")
+
+	client.FailList = true
+	rclone.ObjectPath = "team-a/app-1"
+	err = rclone.Mount(context.Background(), source, target)
+	assert.ErrorContains(t, err, "Cannot create object path")
+}
+
+func TestRcloneMount_VerifyBucketAccess(t *testing.T) {
+	mountWorker = true
+
+	createConfigWrap = func(_ string, _ *RcloneMounter) error {
+		return nil
+	}
+	mounterClient = &mounterapi.FakeClient{}
+
+	client := s3client.NewFakeObjectClient(nil)
+	stubObjectClient(t, client)
+
+	rclone := &RcloneMounter{
+		BucketName:         "testBucket",
+		ObjectPath:         "team-a",
+		AuthType:           "hmac",
+		AccessKeys:         "ak:sk",
+		VerifyBucketAccess: true,
+	}
+
+	// rclone mounts object paths that do not exist yet
+	err := rclone.Mount(context.Background(), source, target)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, client.Calls["ListObjects"])
+
+	client.FailList = true
+	err = rclone.Mount(context.Background(), source, target)
+	assert.ErrorContains(t, err, "cannot access bucket testBucket")
+}
@@ -12,16 +12,20 @@
 package mounter
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
 	"github.com/IBM/ibm-object-csi-driver/pkg/mounter/utils"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
+	"github.com/IBM/ibm-object-csi-driver/pkg/s3client"
 	pkgutils "github.com/IBM/ibm-object-csi-driver/pkg/utils"
 	"k8s.io/klog/v2"
 )
@@ -40,6 +44,16 @@ type S3fsMounter struct {
 	MountOptions  []string
 	AddMountParam string
 	MounterUtils  utils.MounterUtils
+	VolumeCache   *VolumeCache
+	Cache         *CacheManager
+	// CreateObjectPath creates ObjectPath in the bucket before mounting if it does not exist
+	CreateObjectPath bool
+	// VerifyBucketAccess checks that the bucket can be listed with the credentials before mounting
+	VerifyBucketAccess bool
+	// SELinuxContext is the context the mount is labeled with, empty to keep the default fusefs label
+	SELinuxContext string
+	// Readiness tells when the mount is ready
+	Readiness mounterapi.Readiness
 }
 
 const (
@@ -60,6 +74,14 @@ type S3fsMounterParams struct {
 	DefaultParams    map[string]string
 	Gid              string
 	ReadOnly         bool
+	VolumeCache      *VolumeCache
+	Cache            *CacheManager
+	CreateObjectPath bool
+	// VerifyBucketAccess checks that the bucket can be listed with the credentials before mounting
+	VerifyBucketAccess bool
+	SELinuxContext     string
+	// Readiness tells when the mount is ready
+	Readiness mounterapi.Readiness
 }
 
 func NewS3fsMounter(params S3fsMounterParams) Mounter {
@@ -71,6 +93,11 @@ func NewS3fsMounter(params S3fsMounterParams) Mounter {
 	klog.Info("-newS3fsMounter-")
 	mounter := &S3fsMounter{}
 	mounter.MounterUtils = mounterUtils
+	mounter.Readiness = params.Readiness
+	mounter.Cache = params.Cache
+	mounter.CreateObjectPath = params.CreateObjectPath
+	mounter.VerifyBucketAccess = params.VerifyBucketAccess
+	mounter.SELinuxContext = params.SELinuxContext
 	if secretMap == nil && mountOptions == nil && knownS3FSOptions == nil && defaultParams == nil { // For unmount request
 		return mounter
 	}
@@ -118,15 +145,38 @@ func NewS3fsMounter(params S3fsMounterParams) Mounter {
 	klog.Infof("newS3fsMounter args:\n\tbucketName: [%s]\n\tobjectPath: [%s]\n\tendPoint: [%s]\n\tlocationConstraint: [%s]\n\tauthType: [%s]\n\tkpRootKeyCrn: [%s]",
 		mounter.BucketName, mounter.ObjectPath, mounter.EndPoint, mounter.LocConstraint, mounter.AuthType, mounter.KpRootKeyCrn)
 	updatedOptions, addMountParam := updateS3FSMountOptions(mountOptions, secretMap, knownS3FSOptions, defaultParams, params.Gid, params.ReadOnly)
+	if params.VolumeCache != nil {
+		updatedOptions = setMountOption(updatedOptions, "use_cache", params.VolumeCache.Dir)
+		updatedOptions = setMountOption(updatedOptions, "del_cache", "")
+		updatedOptions = setMountOption(updatedOptions, "ensure_diskfree", strconv.FormatInt(ensureDiskFreeMiB(params.VolumeCache), 10))
+		mounter.VolumeCache = params.VolumeCache
+	}
 	mounter.MountOptions = updatedOptions
 	mounter.AddMountParam = addMountParam
 	return mounter
 }
 
-func (s3fs *S3fsMounter) Mount(source string, target string) error {
+func (s3fs *S3fsMounter) Mount(ctx context.Context, source string, target string) error {
 	klog.Info("-S3FSMounter Mount-")
 	klog.Infof("Mount args:\n\tsource: <%s>\n\ttarget: <%s>", source, target)
 
+	createObjectPath := s3fs.CreateObjectPath && s3fs.ObjectPath != ""
+	if s3fs.VerifyBucketAccess || createObjectPath {
+		client := newObjectClient(s3fs.EndPoint, s3fs.LocConstraint, objectStorageCredentials(s3fs.AuthType, s3fs.AccessKeys, s3fs.IAMEndpoint))
+		if s3fs.VerifyBucketAccess {
+			if err := s3client.VerifyBucketAccess(client, s3fs.EndPoint, s3fs.BucketName, s3fs.ObjectPath, !s3fs.CreateObjectPath); err != nil {
+				klog.Errorf("S3FSMounter Mount: %v", err)
+				return err
+			}
+		}
+		if createObjectPath {
+			if err := ensureObjectPath(client, s3fs.BucketName, s3fs.ObjectPath); err != nil {
+				klog.Errorf("S3FSMounter Mount: Cannot create object path %s: %v", s3fs.ObjectPath, err)
+				return fmt.Errorf("S3FSMounter Mount: Cannot create object path %s: %v", s3fs.ObjectPath, err)
+			}
+		}
+	}
+
 	var s3fsCredDir string
 	if mountWorker {
 		s3fsCredDir = constants.MounterConfigPathOnHost
@@ -146,8 +196,7 @@ func (s3fs *S3fsMounter) Mount(source string, target string) error {
 	}
 
 	if !pathExist {
-		if err = MakeDir(metaPath, 0755); // #nosec G301: used for s3fs
-		err != nil {
+		if err = MakeDir(metaPath, 0700); err != nil {
 			klog.Errorf("S3FSMounter Mount: Cannot create directory %s: %v", metaPath, err)
 			return fmt.Errorf("S3FSMounter Mount: Cannot create directory %s: %v", metaPath, err)
 		}
@@ -180,11 +229,15 @@ func (s3fs *S3fsMounter) Mount(source string, target string) error {
 			return err
 		}
 
-		payload := fmt.Sprintf(`{"path":"%s","bucket":"%s","mounter":"%s","args":%s}`, target, bucketName, constants.S3FS, jsonData)
+		klog.Infof("Worker Mounting Payload... path: %s, bucket: %s, args: %s", target, bucketName, jsonData)
 
-		klog.Info("Worker Mounting Payload...", payload)
-
-		err = mounterRequest(payload, "http://unix/api/cos/mount")
+		err = mounterClient.Mount(ctx, &mounterapi.MountRequest{
+			Path:      target,
+			Bucket:    bucketName,
+			Mounter:   constants.S3FS,
+			Args:      jsonData,
+			Readiness: &s3fs.Readiness,
+		})
 		if err != nil {
 			klog.Error("failed to mount on  worker...", err)
 			return err
@@ -192,38 +245,52 @@ func (s3fs *S3fsMounter) Mount(source string, target string) error {
 		return nil
 	}
 	klog.Info("NodeServer Mounting...")
-	return s3fs.MounterUtils.FuseMount(target, constants.S3FS, args)
+	return s3fs.MounterUtils.FuseMountWithOptions(target, constants.S3FS, args, utils.MountOptions{Readiness: s3fs.Readiness})
 }
 
-func (s3fs *S3fsMounter) Unmount(target string) error {
+func (s3fs *S3fsMounter) Unmount(ctx context.Context, target string) error {
 	klog.Info("-S3FSMounter Unmount-")
 	klog.Infof("Unmount args:\n\ttarget: <%s>", target)
 
 	if mountWorker {
 		klog.Info("Unmount on Worker started...")
 
-		payload := fmt.Sprintf(`{"path":"%s"}`, target)
-
-		err := mounterRequest(payload, "http://unix/api/cos/unmount")
+		err := mounterClient.Unmount(ctx, &mounterapi.UnmountRequest{Path: target})
 		if err != nil {
 			klog.Error("failed to unmount on  worker...", err)
 			return err
 		}
 
 		removeFile(constants.MounterConfigPathOnHost, target)
+		s3fs.Cache.Release(target)
 		return nil
 	}
 	klog.Info("NodeServer Unmounting...")
 
-	err := s3fs.MounterUtils.FuseUnmount(target)
+	err := s3fs.MounterUtils.FuseUnmountWithContext(ctx, target, 0)
 	if err != nil {
 		return err
 	}
 
 	removeFile(constants.MounterConfigPathOnPodS3fs, target)
+	s3fs.Cache.Release(target)
 	return nil
 }
 
+// ensureDiskFreeMiB returns the ensure_diskfree option limiting the cache of s3fs to the size of vc. s3fs has no
+// cache size option, it stops caching files once the free space of the cache disk falls below ensure_diskfree, so
+// the option is set to the free space left once the volume has used its size.
+func ensureDiskFreeMiB(vc *VolumeCache) int64 {
+	const mib = 1024 * 1024
+	threshold := vc.DiskFree - vc.Size
+	if threshold <= 0 {
+		// the disk has no room for the cache, s3fs does not cache at all
+		threshold = vc.DiskFree
+	}
+	// rounded up to keep the cache within its size, s3fs takes 0 as its default
+	return max((threshold+mib-1)/mib, 1)
+}
+
 // GetKnownS3FSOptions returns a Set of known s3fs mount option names used to
 // classify options as known (standard s3fs) or unknown (custom for addMountParam)
 func GetKnownS3FSOptions() *pkgutils.Set {
@@ -239,7 +306,8 @@ func GetKnownS3FSOptions() *pkgutils.Set {
 		"retries", "sigv2", "sigv4",
 		"stat_cache_expire", "uid", "umask",
 		"url", "use_path_request_style", "use_xattr",
-		"tmpdir", "use_cache",
+		"tmpdir", "use_cache", "del_cache",
+		"ensure_diskfree",
 	)
 }
 
@@ -339,7 +407,7 @@ func updateS3FSMountOptions(defaultMountOp []string, secretMap map[string]string
 		delete(mountOptsMap, constants.CipherSuitesKey)
 	}
 
-	// To mount the bucket in read-only mode using s3fs based on PVC accessMode "ReadOnlyMany"
+	// To mount the bucket in read-only mode using s3fs based on PVC accessMode "ReadOnlyMany" or a readOnly volumeMount
 	if readOnly {
 		mountOptsMap["ro"] = "true"
 	}
@@ -428,6 +496,11 @@ func (s3fs *S3fsMounter) formulateMountOptions(bucket, target, passwdFile string
 		workerNodeOp["default_acl"] = "private"
 	}
 
+	if s3fs.SELinuxContext != "" {
+		nodeServerOp = append(nodeServerOp, "-o", fuseContextOption(s3fs.SELinuxContext))
+		workerNodeOp["context"] = s3fs.SELinuxContext
+	}
+
 	// Add unknown mount options to workerNodeOp for mounter service
 	if s3fs.AddMountParam != "" {
 		workerNodeOp["add-mount-param"] = s3fs.AddMountParam
@@ -1,6 +1,7 @@
 package mounter
 
 import (
+	"context"
 	"errors"
 	"os"
 	"strings"
@@ -8,7 +9,11 @@ import (
 
 	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
 	mounterUtils "github.com/IBM/ibm-object-csi-driver/pkg/mounter/utils"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
+	"github.com/IBM/ibm-object-csi-driver/pkg/s3client"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var (
@@ -91,7 +96,7 @@ func TestS3FSMount_NodeServer_Positive(t *testing.T) {
 
 	s3fs := &S3fsMounter{
 		MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{
-			FuseMountFn: func(path, comm string, args []string) error {
+			FuseMountWithOptionsFn: func(path, comm string, args []string, opts mounterUtils.MountOptions) error {
 				return nil
 			},
 		}),
@@ -100,7 +105,7 @@ func TestS3FSMount_NodeServer_Positive(t *testing.T) {
 		ObjectPath:    "test-objectPath",
 	}
 
-	err := s3fs.Mount(source, target)
+	err := s3fs.Mount(context.Background(), source, target)
 	assert.NoError(t, err)
 }
 
@@ -113,20 +118,18 @@ func TestS3FSMount_WorkerNode_Positive(t *testing.T) {
 	writePassWrap = func(_, _ string) error {
 		return nil
 	}
-	mounterRequest = func(_, _ string) error {
-		return nil
-	}
+	mounterClient = &mounterapi.FakeClient{}
 
 	s3fs := &S3fsMounter{
 		MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{
-			FuseMountFn: func(path, comm string, args []string) error {
+			FuseMountWithOptionsFn: func(path, comm string, args []string, opts mounterUtils.MountOptions) error {
 				return nil
 			},
 		}),
 		AuthType: "hmac",
 	}
 
-	err := s3fs.Mount(source, target)
+	err := s3fs.Mount(context.Background(), source, target)
 	assert.NoError(t, err)
 }
 
@@ -137,7 +140,7 @@ func TestMount_CreateDirFails_Negative(t *testing.T) {
 		return errors.New("failed to create directory")
 	}
 
-	err := s3fs.Mount(source, target)
+	err := s3fs.Mount(context.Background(), source, target)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "Cannot create directory")
 }
@@ -152,7 +155,7 @@ func TestMount_FailedToCreatePassFile_Negative(t *testing.T) {
 
 	s3fs := &S3fsMounter{}
 
-	err := s3fs.Mount(source, target)
+	err := s3fs.Mount(context.Background(), source, target)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to create file")
 }
@@ -166,13 +169,11 @@ func TestS3FSMount_WorkerNode_Negative(t *testing.T) {
 	writePassWrap = func(_, _ string) error {
 		return nil
 	}
-	mounterRequest = func(_, _ string) error {
-		return errors.New("failed to perform http request")
-	}
+	mounterClient = &mounterapi.FakeClient{MountErr: errors.New("failed to perform http request")}
 
 	s3fs := &S3fsMounter{}
 
-	err := s3fs.Mount(source, target)
+	err := s3fs.Mount(context.Background(), source, target)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to perform http request")
 }
@@ -183,12 +184,12 @@ func TestUnmount_NodeServer(t *testing.T) {
 	removeFile = func(_, _ string) {}
 
 	s3fs := &S3fsMounter{MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{
-		FuseUnmountFn: func(path string) error {
+		FuseUnmountWithContextFn: func(_ context.Context, path string, _ int) error {
 			return nil
 		},
 	})}
 
-	err := s3fs.Unmount(target)
+	err := s3fs.Unmount(context.Background(), target)
 	assert.NoError(t, err)
 }
 
@@ -198,16 +199,14 @@ func TestUnmount_WorkerNode(t *testing.T) {
 	removeFile = func(_, _ string) {}
 
 	s3fs := &S3fsMounter{MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{
-		FuseUnmountFn: func(path string) error {
+		FuseUnmountWithContextFn: func(_ context.Context, path string, _ int) error {
 			return nil
 		},
 	})}
 
-	mounterRequest = func(_, _ string) error {
-		return nil
-	}
+	mounterClient = &mounterapi.FakeClient{}
 
-	err := s3fs.Unmount(target)
+	err := s3fs.Unmount(context.Background(), target)
 	assert.NoError(t, err)
 }
 
@@ -215,16 +214,14 @@ func TestUnmount_WorkerNode_Negative(t *testing.T) {
 	mountWorker = true
 
 	s3fs := &S3fsMounter{MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{
-		FuseUnmountFn: func(path string) error {
+		FuseUnmountWithContextFn: func(_ context.Context, path string, _ int) error {
 			return nil
 		},
 	})}
 
-	mounterRequest = func(_, _ string) error {
-		return errors.New("failed to create http request")
-	}
+	mounterClient = &mounterapi.FakeClient{UnmountErr: errors.New("failed to create http request")}
 
-	err := s3fs.Unmount(target)
+	err := s3fs.Unmount(context.Background(), target)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to create http request")
 }
@@ -235,12 +232,12 @@ func TestUnmount_NodeServer_Negative(t *testing.T) {
 	removeFile = func(_, _ string) {}
 
 	s3fs := &S3fsMounter{MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{
-		FuseUnmountFn: func(path string) error {
+		FuseUnmountWithContextFn: func(_ context.Context, path string, _ int) error {
 			return errors.New("failed to unmount")
 		},
 	})}
 
-	err := s3fs.Unmount(target)
+	err := s3fs.Unmount(context.Background(), target)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to unmount")
 }
@@ -616,3 +613,153 @@ func TestUpdateS3FSMountOptions_SkipCipherSuitesDefault(t *testing.T) {
 		})
 	}
 }
+
+func TestNewS3fsMounter_VolumeCache(t *testing.T) {
+	mounter := NewS3fsMounter(S3fsMounterParams{
+		SecretMap:        secretMap,
+		MountOptions:     []string{"use_cache=/tmp", "opt1=val1"},
+		MounterUtils:     mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{}),
+		KnownS3FSOptions: GetKnownS3FSOptions(),
+		VolumeCache:      &VolumeCache{Dir: "/var/lib/coscsi-cache/abc", Size: 1024 * 1024, DiskFree: 10 * 1024 * 1024},
+	})
+
+	s3fsMounter, ok := mounter.(*S3fsMounter)
+	assert.True(t, ok)
+	assert.Contains(t, s3fsMounter.MountOptions, "use_cache=/var/lib/coscsi-cache/abc")
+	assert.Contains(t, s3fsMounter.MountOptions, "del_cache")
+	assert.Contains(t, s3fsMounter.MountOptions, "ensure_diskfree=9")
+	assert.NotContains(t, s3fsMounter.MountOptions, "use_cache=/tmp")
+}
+
+func TestEnsureDiskFreeMiB(t *testing.T) {
+	const mib = 1024 * 1024
+	// s3fs stops caching once the volume used its size
+	assert.Equal(t, int64(90), ensureDiskFreeMiB(&VolumeCache{Size: 10 * mib, DiskFree: 100 * mib}))
+	// partial MiB are rounded up to stay within the size
+	assert.Equal(t, int64(91), ensureDiskFreeMiB(&VolumeCache{Size: 10*mib - 1, DiskFree: 100 * mib}))
+	// without room for the cache nothing is cached
+	assert.Equal(t, int64(5), ensureDiskFreeMiB(&VolumeCache{Size: 10 * mib, DiskFree: 5 * mib}))
+	assert.Equal(t, int64(1), ensureDiskFreeMiB(&VolumeCache{Size: 10 * mib}))
+}
+
+func TestUnmount_WorkerNode_ReleasesCache(t *testing.T) {
+	mountWorker = true
+	removeFile = func(_, _ string) {}
+	mounterClient = &mounterapi.FakeClient{}
+
+	cache := NewCacheManager(t.TempDir(), 0, 0)
+	vc, err := cache.Allocate(target, 1024)
+	assert.NoError(t, err)
+
+	s3fs := &S3fsMounter{Cache: cache}
+	err = s3fs.Unmount(context.Background(), target)
+	assert.NoError(t, err)
+	assert.NoDirExists(t, vc.Dir)
+}
+
+func TestS3FSMount_CreateObjectPath(t *testing.T) {
+	mountWorker = true
+
+	MakeDir = func(path string, perm os.FileMode) error {
+		return nil
+	}
+	writePassWrap = func(_, _ string) error {
+		return nil
+	}
+	mounterClient = &mounterapi.FakeClient{}
+
+	client := s3client.NewFakeObjectClient(nil)
+	stubObjectClient(t, client)
+
+	s3fs := &S3fsMounter{
+		BucketName:       "testBucket",
+		ObjectPath:       "team-a/app-0",
+		AuthType:         "hmac",
+		AccessKeys:       "ak:sk",
+		CreateObjectPath: true,
+	}
+
+	err := s3fs.Mount(context.Background(), source, target)
+	assert.NoError(t, err)
+	assert.Contains(t, client.Objects, "team-a/app-0/")
+
+	client.FailUpload = true
+	s3fs.ObjectPath = "team-a/app-1"
+	err = s3fs.Mount(context.Background(), source, target)
+	assert.ErrorContains(t, err, "Cannot create object path")
+}
+
+func TestS3FSMount_VerifyBucketAccess(t *testing.T) {
+	mountWorker = true
+
+	MakeDir = func(path string, perm os.FileMode) error {
+		return nil
+	}
+	writePassWrap = func(_, _ string) error {
+		return nil
+	}
+	mounterClient = &mounterapi.FakeClient{}
+
+	client := s3client.NewFakeObjectClient(map[string]string{"team-a/file": "data"})
+	stubObjectClient(t, client)
+
+	s3fs := &S3fsMounter{
+		BucketName:         "testBucket",
+		ObjectPath:         "team-a",
+		AuthType:           "hmac",
+		AccessKeys:         "ak:sk",
+		VerifyBucketAccess: true,
+	}
+
+	err := s3fs.Mount(context.Background(), source, target)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, client.Calls["ListObjects"])
+
+	s3fs.ObjectPath = "team-b"
+	err = s3fs.Mount(context.Background(), source, target)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.ErrorContains(t, err, "objectPath team-b does not exist")
+
+	// the object path is created once the access is verified
+	s3fs.CreateObjectPath = true
+	err = s3fs.Mount(context.Background(), source, target)
+	assert.NoError(t, err)
+	assert.Contains(t, client.Objects, "team-b/")
+
+	client.FailList = true
+	err = s3fs.Mount(context.Background(), source, target)
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestS3FSMount_Readiness(t *testing.T) {
+	MakeDir = func(path string, perm os.FileMode) error {
+		return nil
+	}
+	writePassWrap = func(_, _ string) error {
+		return nil
+	}
+	readiness := mounterapi.Readiness{Probe: mounterapi.ProbeList}
+
+	var got mounterUtils.MountOptions
+	s3fs := &S3fsMounter{
+		MounterUtils: mounterUtils.NewFakeMounterUtilsImpl(mounterUtils.FakeMounterUtilsFuncStruct{
+			FuseMountWithOptionsFn: func(path, comm string, args []string, opts mounterUtils.MountOptions) error {
+				got = opts
+				return nil
+			},
+		}),
+		AuthType:  "hmac",
+		Readiness: readiness,
+	}
+
+	mountWorker = false
+	assert.NoError(t, s3fs.Mount(context.Background(), source, target))
+	assert.Equal(t, readiness, got.Readiness)
+
+	mountWorker = true
+	client := &mounterapi.FakeClient{}
+	mounterClient = client
+	assert.NoError(t, s3fs.Mount(context.Background(), source, target))
+	assert.Len(t, client.MountRequests, 1)
+	assert.Equal(t, &readiness, client.MountRequests[0].Readiness)
+}
@@ -2,27 +2,22 @@ package mounter
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
-	"io"
-	"net"
-	"net/http"
+	"fmt"
 	"os"
-	"strings"
 	"syscall"
-	"time"
 
 	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
 	mounterUtils "github.com/IBM/ibm-object-csi-driver/pkg/mounter/utils"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mountpolicy"
 	pkgutils "github.com/IBM/ibm-object-csi-driver/pkg/utils"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 	"k8s.io/klog/v2"
 )
 
 var (
-	mountWorker    = true
-	mounterRequest = createCOSCSIMounterRequest
+	mountWorker                        = true
+	mounterClient mounterapi.Interface = newMounterClient()
 
 	MakeDir    = os.MkdirAll
 	CreateFile = os.Create
@@ -32,39 +27,52 @@ var (
 )
 
 type Mounter interface {
-	Mount(source string, target string) error
-	Unmount(target string) error
+	Mount(ctx context.Context, source string, target string) error
+	Unmount(ctx context.Context, target string) error
 }
 
 type CSIMounterFactory struct{}
 
 type MounterParams struct {
-	Attrib          map[string]string
-	SecretMap       map[string]string
-	MountFlags      []string
+	Attrib           map[string]string
+	SecretMap        map[string]string
+	MountFlags       []string
 	KnownS3FSOptions *pkgutils.Set
-	DefaultMOMap    map[string]string
-	Gid             string
-	ReadOnly        bool
+	DefaultMOMap     map[string]string
+	Gid              string
+	ReadOnly         bool
+	// VolumeCache is the cache directory allocated to the volume, nil if the volume is not cached
+	VolumeCache *VolumeCache
+	// Cache releases the cache directory on unmount, nil if caching is not configured
+	Cache *CacheManager
+	// CreateObjectPath creates the objectPath of the secret in the bucket if it does not exist yet
+	CreateObjectPath bool
+	// VerifyBucketAccess checks that the bucket can be accessed with the credentials of the secret before the
+	// s3fs and rclone mounters start, they only report access failures as a mount timeout
+	VerifyBucketAccess bool
+	// OptionPolicy restricts the mount options of the secret, nil allows all options
+	OptionPolicy *mountpolicy.Policy
+	// Namespace is the namespace of the pod the volume is published to, if known
+	Namespace string
+	// Readiness tells when the mount is ready, it is set per storage class
+	Readiness mounterapi.Readiness
 }
 
 type NewMounterFactory interface {
-	NewMounter(params MounterParams) Mounter
+	NewMounter(params MounterParams) (Mounter, error)
 }
 
 func NewCSIMounterFactory() *CSIMounterFactory {
 	return &CSIMounterFactory{}
 }
 
-func (s *CSIMounterFactory) NewMounter(params MounterParams) Mounter {
+func (s *CSIMounterFactory) NewMounter(params MounterParams) (Mounter, error) {
 	attrib := params.Attrib
 	secretMap := params.SecretMap
 	mountFlags := params.MountFlags
 	knownS3FSOptions := params.KnownS3FSOptions
 	defaultMOMap := params.DefaultMOMap
 	klog.Info("-NewMounter-")
-	var mounter, val string
-	var check bool
 
 	if secretMap == nil {
 		secretMap = map[string]string{}
@@ -73,51 +81,123 @@ func (s *CSIMounterFactory) NewMounter(params MounterParams) Mounter {
 		mountFlags = []string{}
 	}
 
-	// Select mounter as per storage class
-	if val, check = attrib["mounter"]; check {
-		mounter = val
-	} else {
-		// if mounter not set in storage class
-		if val, check = secretMap["mounter"]; check {
-			mounter = val
-		}
+	mounter := GetMounterName(attrib, secretMap)
+	mounterUtils := &(mounterUtils.MounterOptsUtils{})
+
+	if err := checkSecretMountOptions(params.OptionPolicy, mounter, params.Namespace, secretMap); err != nil {
+		klog.Errorf("NewMounter: %v", err)
+		return nil, err
 	}
 
-	mounterUtils := &(mounterUtils.MounterOptsUtils{})
+	mountFlags, seLinuxContext := splitSELinuxContext(mountFlags)
+	if seLinuxContext != "" && mounter != constants.S3FS && mounter != constants.RClone {
+		klog.Warningf("NewMounter: SELinux context %s is not supported by the %s mounter, the mount keeps its default label", seLinuxContext, mounter)
+	}
 
 	switch mounter {
 	case constants.S3FS:
 		return NewS3fsMounter(S3fsMounterParams{
-			SecretMap:        secretMap,
-			MountOptions:     mountFlags,
-			MounterUtils:     mounterUtils,
-			KnownS3FSOptions: knownS3FSOptions,
-			DefaultParams:    defaultMOMap,
-			Gid:              params.Gid,
-			ReadOnly:         params.ReadOnly,
-		})
+			SecretMap:          secretMap,
+			MountOptions:       mountFlags,
+			MounterUtils:       mounterUtils,
+			KnownS3FSOptions:   knownS3FSOptions,
+			DefaultParams:      defaultMOMap,
+			Gid:                params.Gid,
+			ReadOnly:           params.ReadOnly,
+			VolumeCache:        params.VolumeCache,
+			Cache:              params.Cache,
+			CreateObjectPath:   params.CreateObjectPath,
+			VerifyBucketAccess: params.VerifyBucketAccess,
+			SELinuxContext:     seLinuxContext,
+			Readiness:          params.Readiness,
+		}), nil
 	case constants.RClone:
 		return NewRcloneMounter(RcloneMounterParams{
+			SecretMap:          secretMap,
+			MountOptions:       mountFlags,
+			MounterUtils:       mounterUtils,
+			Gid:                params.Gid,
+			ReadOnly:           params.ReadOnly,
+			VolumeCache:        params.VolumeCache,
+			Cache:              params.Cache,
+			CreateObjectPath:   params.CreateObjectPath,
+			VerifyBucketAccess: params.VerifyBucketAccess,
+			SELinuxContext:     seLinuxContext,
+			Readiness:          params.Readiness,
+		}), nil
+	case constants.MountpointS3:
+		return NewMountpointS3Mounter(MountpointS3MounterParams{
+			SecretMap:    secretMap,
+			MountOptions: mountFlags,
+			MounterUtils: mounterUtils,
+			Gid:          params.Gid,
+			ReadOnly:     params.ReadOnly,
+			VolumeCache:  params.VolumeCache,
+			Cache:        params.Cache,
+			Readiness:    params.Readiness,
+		}), nil
+	case constants.Native:
+		return NewNativeMounter(NativeMounterParams{
 			SecretMap:    secretMap,
 			MountOptions: mountFlags,
 			MounterUtils: mounterUtils,
 			Gid:          params.Gid,
 			ReadOnly:     params.ReadOnly,
-		})
+			VolumeCache:  params.VolumeCache,
+			Cache:        params.Cache,
+			Readiness:    params.Readiness,
+		}), nil
 	default:
-		// default to s3fs
-		return NewS3fsMounter(S3fsMounterParams{
-			SecretMap:        secretMap,
-			MountOptions:     mountFlags,
-			MounterUtils:     mounterUtils,
-			KnownS3FSOptions: knownS3FSOptions,
-			DefaultParams:    defaultMOMap,
-			Gid:              params.Gid,
-			ReadOnly:         params.ReadOnly,
-		})
+		klog.Errorf("NewMounter: unsupported mounter %q", mounter)
+		return nil, fmt.Errorf("unsupported mounter %q, supported mounters are %s, %s, %s and %s",
+			mounter, constants.S3FS, constants.RClone, constants.MountpointS3, constants.Native)
 	}
 }
 
+// GetMounterName returns the mounter selected in the storage class, falling back to the secret and then to s3fs
+func GetMounterName(attrib, secretMap map[string]string) string {
+	// Select mounter as per storage class
+	if val := attrib["mounter"]; val != "" {
+		return val
+	}
+	// if mounter not set in storage class
+	if val := secretMap["mounter"]; val != "" {
+		return val
+	}
+	return constants.S3FS
+}
+
+// checkSecretMountOptions applies policy to the mount options users set in the secret, before the mounters
+// merge them with the options of the storage class in updateS3FSMountOptions, updateMountOptions and
+// updateMountpointS3Options
+func checkSecretMountOptions(policy *mountpolicy.Policy, mounter, namespace string, secretMap map[string]string) error {
+	if policy == nil {
+		return nil
+	}
+	options := mountpolicy.ParseOptions(secretMap["mountOptions"])
+	if mounter == constants.S3FS {
+		// s3fs also takes these options from their own secret keys
+		for _, key := range []string{"tmpdir", "use_cache"} {
+			if val, ok := secretMap[key]; ok {
+				options[key] = val
+			}
+		}
+	}
+	return policy.Check(mounter, namespace, options)
+}
+
+// CleanupMountConfig removes the credential/config directory created for target by any of the mounters.
+// It is used when there is nothing left to unmount but a previous publish may have left files behind.
+func CleanupMountConfig(target string) {
+	if mountWorker {
+		removeFile(constants.MounterConfigPathOnHost, target)
+		return
+	}
+	removeFile(constants.MounterConfigPathOnPodS3fs, target)
+	removeConfigFile(constants.MounterConfigPathOnPodRclone, target)
+	removeMntS3CredFile(constants.MounterConfigPathOnPodMntS3, target)
+}
+
 func checkPath(path string) (bool, error) {
 	if path == "" {
 		return false, errors.New("undefined path")
@@ -165,116 +245,17 @@ func writePass(pwFileName string, pwFileContent string) error {
 	return nil
 }
 
-func createCOSCSIMounterRequest(payload string, url string) error {
-	// Get socket path
+// MounterHealth returns the health of the cos-csi-mounter service
+func MounterHealth(ctx context.Context) (*mounterapi.Health, error) {
+	return mounterClient.Health(ctx)
+}
+
+// newMounterClient returns the client of the cos-csi-mounter service listening on the socket configured for the node server
+func newMounterClient() *mounterapi.Client {
 	socketPath := os.Getenv(constants.COSCSIMounterSocketPathEnv)
 	if socketPath == "" {
 		socketPath = constants.COSCSIMounterSocketPath
 	}
 	klog.Infof("COS CSI Mounter Socket Path: %s", socketPath)
-
-	err := isGRPCServerAvailable(socketPath)
-	if err != nil {
-		return err
-	}
-
-	// Create a custom dialer function for Unix socket connection
-	dialer := func(_ context.Context, _, _ string) (net.Conn, error) {
-		return net.Dial("unix", socketPath)
-	}
-
-	// Create an HTTP client with the Unix socket transport
-	client := &http.Client{
-		Transport: &http.Transport{
-			DialContext: dialer,
-		},
-		Timeout: constants.Timeout,
-	}
-
-	// Create POST request
-	req, err := http.NewRequest("POST", url, strings.NewReader(payload))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	response, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-
-	defer func() {
-		if err := response.Body.Close(); err != nil {
-			klog.Errorf("failed to close response body: %v", err)
-		}
-	}()
-
-	body, err := io.ReadAll(response.Body)
-	if err != nil {
-		return err
-	}
-
-	responseBody := string(body)
-	klog.Infof("response from cos-csi-mounter -> Response body: %s, Response code: %v", responseBody, response.StatusCode)
-
-	if response.StatusCode != http.StatusOK {
-		return parseGRPCResponse(response.StatusCode, responseBody)
-	}
-	return nil
-}
-
-// parseGRPCResponse takes both response body and error code and frames error message
-func parseGRPCResponse(code int, response string) error {
-	errMsg := parseErrFromResponse(response)
-	switch code {
-	case http.StatusBadRequest:
-		return status.Error(codes.InvalidArgument, errMsg)
-	case http.StatusNotFound:
-		return status.Error(codes.NotFound, errMsg)
-	case http.StatusConflict:
-		return status.Error(codes.AlreadyExists, errMsg)
-	case http.StatusForbidden:
-		return status.Error(codes.PermissionDenied, errMsg)
-	case http.StatusTooManyRequests:
-		return status.Error(codes.ResourceExhausted, errMsg)
-	case http.StatusNotImplemented:
-		return status.Error(codes.Unimplemented, errMsg)
-	case http.StatusInternalServerError:
-		return status.Error(codes.Internal, errMsg)
-	case http.StatusServiceUnavailable:
-		return status.Error(codes.Unavailable, errMsg)
-	case http.StatusUnauthorized:
-		return status.Error(codes.Unauthenticated, errMsg)
-	default:
-		return status.Error(codes.Unknown, errMsg)
-	}
-}
-
-// isGRPCServerAvailable tries to connect to the UNIX socket to see if it's up
-func isGRPCServerAvailable(socketPath string) error {
-	conn, err := net.DialTimeout("unix", socketPath, 500*time.Millisecond)
-	if err != nil {
-		return err
-	}
-	err = conn.Close()
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
-// parseErrFromResponse fetches error from responseBody
-// e.g. ResponseBody: {"error":"invalid args for mounter: invalid s3fs args decode error: json: unknown field \"unknownkey\""}
-// parseErrFromResponse returns "invalid args for mounter: invalid s3fs args decode error: json: unknown field \"unknownkey\"
-func parseErrFromResponse(response string) string {
-	var errFromResp map[string]string
-	err := json.Unmarshal([]byte(response), &errFromResp)
-	if err != nil {
-		klog.Warning("failed to unmarshal response from server", err)
-		return response
-	}
-	val, exists := errFromResp["error"]
-	if !exists {
-		return response
-	}
-	return val
+	return mounterapi.NewClient(socketPath, constants.Timeout)
 }
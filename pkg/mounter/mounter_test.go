@@ -1,12 +1,14 @@
 package mounter
 
 import (
+	"errors"
 	"reflect"
 	"sort"
 	"testing"
 
 	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
 	mounterUtils "github.com/IBM/ibm-object-csi-driver/pkg/mounter/utils"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mountpolicy"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -116,18 +118,80 @@ func TestNewMounter(t *testing.T) {
 			},
 			expectedErr: nil,
 		},
+		{
+			name:   "Mountpoint-S3 Mounter",
+			attrib: map[string]string{"mounter": constants.MountpointS3},
+			secretMap: map[string]string{
+				"cosEndpoint":        "test-endpoint",
+				"locationConstraint": "test-loc-constraint",
+				"bucketName":         "test-bucket-name",
+				"objectPath":         "test-obj-path",
+				"accessKey":          "test-access-key",
+				"secretKey":          "test-secret-key",
+				"gid":                "fake-gid",
+			},
+			mountOptions: []string{"--max-threads=16", "allow-root"},
+			expected: &MountpointS3Mounter{
+				BucketName:    "test-bucket-name",
+				ObjectPath:    "test-obj-path",
+				EndPoint:      "test-endpoint",
+				LocConstraint: "test-loc-constraint",
+				AccessKeys:    "test-access-key:test-secret-key",
+				AuthType:      "hmac",
+				UID:           "fake-gid",
+				GID:           "fake-gid",
+				MountOptions:  []string{"max-threads=16", "allow-root"},
+				MounterUtils:  &mounterUtils.MounterOptsUtils{},
+			},
+			expectedErr: nil,
+		},
+		{
+			name:   "Native Mounter",
+			attrib: map[string]string{"mounter": constants.Native},
+			secretMap: map[string]string{
+				"cosEndpoint": "test-endpoint",
+				"bucketName":  "test-bucket-name",
+				"objectPath":  "test-obj-path",
+				"apiKey":      "test-api-key",
+			},
+			mountOptions: []string{"metadata-ttl=30s"},
+			expected: &NativeMounter{
+				BucketName:   "test-bucket-name",
+				ObjectPath:   "test-obj-path",
+				EndPoint:     "test-endpoint",
+				AccessKeys:   ":test-api-key",
+				AuthType:     "iam",
+				IAMEndpoint:  constants.PublicIAMEndpoint,
+				MountOptions: []string{"metadata-ttl=30s"},
+				MounterUtils: &mounterUtils.MounterOptsUtils{},
+			},
+			expectedErr: nil,
+		},
+		{
+			name:        "Unknown Mounter",
+			attrib:      map[string]string{"mounter": "goofys"},
+			secretMap:   map[string]string{},
+			expected:    nil,
+			expectedErr: errors.New(`unsupported mounter "goofys", supported mounters are s3fs, rclone, mountpoint-s3 and native`),
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			factory := &CSIMounterFactory{}
 
-			result := factory.NewMounter(MounterParams{
+			result, err := factory.NewMounter(MounterParams{
 				Attrib:           test.attrib,
 				SecretMap:        test.secretMap,
 				MountFlags:       test.mountOptions,
 				KnownS3FSOptions: GetKnownS3FSOptions(),
 			})
+			if test.expectedErr != nil {
+				assert.EqualError(t, err, test.expectedErr.Error())
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
 
 			if s3fs, ok := result.(*S3fsMounter); ok {
 				expected := test.expected.(*S3fsMounter)
@@ -145,6 +209,14 @@ func TestNewMounter(t *testing.T) {
 				rclone.MountOptions = nil
 				expected.MountOptions = nil
 			}
+			if mnts3, ok := result.(*MountpointS3Mounter); ok {
+				expected := test.expected.(*MountpointS3Mounter)
+				if !stringSlicesEqualIgnoreOrder(mnts3.MountOptions, expected.MountOptions) {
+					t.Errorf("MountOptions mismatch.\nGot:  %v\nWant: %v", mnts3.MountOptions, expected.MountOptions)
+				}
+				mnts3.MountOptions = nil
+				expected.MountOptions = nil
+			}
 
 			assert.Equal(t, result, test.expected)
 
@@ -154,3 +226,59 @@ func TestNewMounter(t *testing.T) {
 		})
 	}
 }
+
+func TestNewMounter_OptionPolicy(t *testing.T) {
+	policy := &mountpolicy.Policy{Rules: []mountpolicy.Rule{
+		{Deny: []string{"passwd_file", "url"}},
+		{Mounters: []string{constants.S3FS}, Namespaces: []string{"restricted"}, Deny: []string{"use_cache"}},
+	}}
+	assert.NoError(t, policy.Compile())
+	newMounter := func(mounter, namespace string, secretMap map[string]string) error {
+		secretMap["bucketName"] = "test-bucket-name"
+		_, err := (&CSIMounterFactory{}).NewMounter(MounterParams{
+			Attrib:           map[string]string{"mounter": mounter},
+			SecretMap:        secretMap,
+			KnownS3FSOptions: GetKnownS3FSOptions(),
+			OptionPolicy:     policy,
+			Namespace:        namespace,
+		})
+		return err
+	}
+
+	err := newMounter(constants.S3FS, "", map[string]string{"mountOptions": "retries=5\nmultipart_size=52,passwd_file=/etc/shadow"})
+	assert.ErrorContains(t, err, `mount option "passwd_file"`)
+	err = newMounter(constants.RClone, "", map[string]string{"mountOptions": "--url=https://evil"})
+	assert.ErrorContains(t, err, `mount option "url"`)
+	err = newMounter(constants.S3FS, "restricted", map[string]string{"use_cache": "/tmp"})
+	assert.ErrorContains(t, err, `mount option "use_cache"`)
+	assert.NoError(t, newMounter(constants.S3FS, "other", map[string]string{"use_cache": "/tmp", "mountOptions": "retries=5"}))
+}
+
+func TestCleanupMountConfig(t *testing.T) {
+	defer func() {
+		mountWorker = true
+		removeFile = removeS3FSCredFile
+		removeConfigFile = removeRcloneConfigFile
+		removeMntS3CredFile = removeS3FSCredFile
+	}()
+
+	var removed []string
+	removeFile = func(credDir, _ string) {
+		removed = append(removed, credDir)
+	}
+	removeConfigFile = func(configPath, _ string) {
+		removed = append(removed, configPath)
+	}
+	removeMntS3CredFile = func(credDir, _ string) {
+		removed = append(removed, credDir)
+	}
+
+	mountWorker = true
+	CleanupMountConfig(target)
+	assert.Equal(t, []string{constants.MounterConfigPathOnHost}, removed)
+
+	removed = nil
+	mountWorker = false
+	CleanupMountConfig(target)
+	assert.Equal(t, []string{constants.MounterConfigPathOnPodS3fs, constants.MounterConfigPathOnPodRclone, constants.MounterConfigPathOnPodMntS3}, removed)
+}
@@ -0,0 +1,54 @@
+package mounter
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/IBM/ibm-object-csi-driver/pkg/s3client"
+	"go.uber.org/zap"
+	"k8s.io/klog/v2"
+)
+
+// newObjectClient creates the client used to create missing object paths
+var newObjectClient = func(endpoint, locationConstraint string, creds *s3client.ObjectStorageCredentials) s3client.ObjectClient {
+	return s3client.NewObjectClient(endpoint, locationConstraint, creds, zap.L())
+}
+
+// ensureObjectPath creates the "<objectPath>/" directory marker in bucket unless an object already exists under
+// the prefix. s3fs fails to mount a prefix that does not exist and rclone would show an empty directory only
+// once something is written, so templated object paths are created before the first mount.
+func ensureObjectPath(client s3client.ObjectClient, bucket, objectPath string) error {
+	prefix := strings.Trim(objectPath, "/") + "/"
+	if prefix == "/" {
+		return nil
+	}
+
+	listing, err := client.ListObjects(bucket, prefix, "", "", 1)
+	if err != nil {
+		return err
+	}
+	if len(listing.Objects) > 0 {
+		return nil
+	}
+
+	klog.Infof("Creating object path %s in bucket %s", prefix, bucket)
+	return client.UploadObject(bucket, prefix, bytes.NewReader(nil), 0, 0)
+}
+
+// objectStorageCredentials converts the credentials of a mounter, "accessKey:secretKey" for hmac or the api
+// key for iam, to the credentials of the COS client
+func objectStorageCredentials(authType, accessKeys, iamEndpoint string) *s3client.ObjectStorageCredentials {
+	if authType != "hmac" {
+		return &s3client.ObjectStorageCredentials{
+			AuthType:    "iam",
+			APIKey:      strings.TrimPrefix(accessKeys, ":"),
+			IAMEndpoint: iamEndpoint,
+		}
+	}
+	keys := strings.SplitN(accessKeys, ":", 2)
+	creds := &s3client.ObjectStorageCredentials{AuthType: "hmac", AccessKey: keys[0]}
+	if len(keys) == 2 {
+		creds.SecretKey = keys[1]
+	}
+	return creds
+}
@@ -0,0 +1,59 @@
+package mounter
+
+import (
+	"testing"
+
+	"github.com/IBM/ibm-object-csi-driver/pkg/s3client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnsureObjectPath(t *testing.T) {
+	client := s3client.NewFakeObjectClient(nil)
+
+	err := ensureObjectPath(client, "bucket", "/team-a/app-0/")
+	assert.NoError(t, err)
+	assert.Contains(t, client.Objects, "team-a/app-0/")
+	assert.Equal(t, 1, client.Calls["UploadObject"])
+
+	// the prefix exists now, nothing is uploaded again
+	err = ensureObjectPath(client, "bucket", "team-a/app-0")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, client.Calls["UploadObject"])
+
+	client = s3client.NewFakeObjectClient(map[string]string{"team-a/app-1/data.txt": "data"})
+	err = ensureObjectPath(client, "bucket", "team-a/app-1")
+	assert.NoError(t, err)
+	assert.Zero(t, client.Calls["UploadObject"])
+
+	err = ensureObjectPath(client, "bucket", "/")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, client.Calls["ListObjects"])
+
+	client.FailList = true
+	err = ensureObjectPath(client, "bucket", "team-a/app-2")
+	assert.Error(t, err)
+
+	client.FailList = false
+	client.FailUpload = true
+	err = ensureObjectPath(client, "bucket", "team-a/app-2")
+	assert.Error(t, err)
+}
+
+func TestObjectStorageCredentials(t *testing.T) {
+	assert.Equal(t, &s3client.ObjectStorageCredentials{AuthType: "hmac", AccessKey: "ak", SecretKey: "sk"},
+		objectStorageCredentials("hmac", "ak:sk", ""))
+	assert.Equal(t, &s3client.ObjectStorageCredentials{AuthType: "iam", APIKey: "apikey", IAMEndpoint: "https://iam.test"},
+		objectStorageCredentials("iam", ":apikey", "https://iam.test"))
+	assert.Equal(t, &s3client.ObjectStorageCredentials{AuthType: "iam", APIKey: "apikey", IAMEndpoint: "https://iam.test"},
+		objectStorageCredentials("iam", "apikey", "https://iam.test"))
+}
+
+func stubObjectClient(t *testing.T, client s3client.ObjectClient) {
+	orig := newObjectClient
+	newObjectClient = func(string, string, *s3client.ObjectStorageCredentials) s3client.ObjectClient {
+		return client
+	}
+	t.Cleanup(func() {
+		newObjectClient = orig
+	})
+}
@@ -0,0 +1,30 @@
+package mounter
+
+import (
+	"strings"
+)
+
+// seLinuxContextOption is the mount flag kubelet adds to the volume capability when the CSIDriver sets
+// seLinuxMount, e.g. context="system_u:object_r:container_file_t:s0:c15,c26"
+const seLinuxContextOption = "context"
+
+// splitSELinuxContext removes the context= flag from mountFlags and returns the remaining flags and the
+// context, quotes included. The context is not a mounter option, it is passed to the kernel by libfuse.
+func splitSELinuxContext(mountFlags []string) ([]string, string) {
+	var context string
+	flags := make([]string, 0, len(mountFlags))
+	for _, flag := range mountFlags {
+		if value, found := strings.CutPrefix(strings.TrimSpace(flag), seLinuxContextOption+"="); found {
+			context = value
+			continue
+		}
+		flags = append(flags, flag)
+	}
+	return flags, context
+}
+
+// fuseContextOption returns the context option for libfuse, which splits its -o options on commas unless
+// they are escaped. The category list of an MCS level, such as s0:c15,c26, contains commas.
+func fuseContextOption(context string) string {
+	return seLinuxContextOption + "=" + strings.ReplaceAll(context, ",", `\,`)
+}
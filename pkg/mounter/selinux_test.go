@@ -0,0 +1,53 @@
+package mounter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSELinuxContext = `"system_u:object_r:container_file_t:s0:c15,c26"`
+
+func TestSplitSELinuxContext(t *testing.T) {
+	flags, context := splitSELinuxContext([]string{"ro", "context=" + testSELinuxContext, "uid=1000"})
+	assert.Equal(t, []string{"ro", "uid=1000"}, flags)
+	assert.Equal(t, testSELinuxContext, context)
+
+	flags, context = splitSELinuxContext([]string{"ro"})
+	assert.Equal(t, []string{"ro"}, flags)
+	assert.Empty(t, context)
+}
+
+func TestFormulateMountOptions_SELinuxContext(t *testing.T) {
+	s3fs := &S3fsMounter{EndPoint: "https://cos", AuthType: "hmac", SELinuxContext: testSELinuxContext}
+	args, wnOp := s3fs.formulateMountOptions("bucket", "/target", "/passwd")
+	assert.Contains(t, args, `context="system_u:object_r:container_file_t:s0:c15\,c26"`)
+	assert.Equal(t, testSELinuxContext, wnOp["context"])
+
+	rclone := &RcloneMounter{SELinuxContext: testSELinuxContext}
+	args, wnOp = rclone.formulateMountOptions("ibmcos:bucket", "/target", "/config")
+	assert.Contains(t, args, `--option=context="system_u:object_r:container_file_t:s0:c15\,c26"`)
+	assert.Equal(t, testSELinuxContext, wnOp["context"])
+}
+
+func TestNewMounter_SELinuxContext(t *testing.T) {
+	factory := NewCSIMounterFactory()
+	mountFlags := []string{"context=" + testSELinuxContext}
+
+	m, err := factory.NewMounter(MounterParams{
+		Attrib:     map[string]string{"mounter": "s3fs"},
+		SecretMap:  map[string]string{"cosEndpoint": "https://cos"},
+		MountFlags: mountFlags,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, testSELinuxContext, m.(*S3fsMounter).SELinuxContext)
+	assert.NotContains(t, m.(*S3fsMounter).MountOptions, "context="+testSELinuxContext)
+
+	m, err = factory.NewMounter(MounterParams{
+		Attrib:     map[string]string{"mounter": "rclone"},
+		SecretMap:  map[string]string{"cosEndpoint": "https://cos"},
+		MountFlags: mountFlags,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, testSELinuxContext, m.(*RcloneMounter).SELinuxContext)
+}
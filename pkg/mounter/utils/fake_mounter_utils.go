@@ -1,8 +1,13 @@
 package utils
 
+import "context"
+
 type FakeMounterUtilsFuncStruct struct {
-	FuseMountFn   func(path string, comm string, args []string) error
-	FuseUnmountFn func(path string) error
+	FuseMountFn              func(path string, comm string, args []string) error
+	FuseMountWithOptionsFn   func(path string, comm string, args []string, opts MountOptions) error
+	FuseUnmountFn            func(path string) error
+	FuseUnmountWithContextFn func(ctx context.Context, path string, pid int) error
+	GetMountInfoFn           func(path string) (*MountInfo, error)
 }
 
 type FakeMounterUtilsFuncStructImpl struct {
@@ -22,9 +27,30 @@ func (m *FakeMounterUtilsFuncStructImpl) FuseMount(path string, comm string, arg
 	panic("requested method should not be nil")
 }
 
+func (m *FakeMounterUtilsFuncStructImpl) FuseMountWithOptions(path string, comm string, args []string, opts MountOptions) error {
+	if m.FuncStruct.FuseMountWithOptionsFn != nil {
+		return m.FuncStruct.FuseMountWithOptionsFn(path, comm, args, opts)
+	}
+	panic("requested method should not be nil")
+}
+
 func (m *FakeMounterUtilsFuncStructImpl) FuseUnmount(path string) error {
 	if m.FuncStruct.FuseUnmountFn != nil {
 		return m.FuncStruct.FuseUnmountFn(path)
 	}
 	panic("requested method should not be nil")
 }
+
+func (m *FakeMounterUtilsFuncStructImpl) FuseUnmountWithContext(ctx context.Context, path string, pid int) error {
+	if m.FuncStruct.FuseUnmountWithContextFn != nil {
+		return m.FuncStruct.FuseUnmountWithContextFn(ctx, path, pid)
+	}
+	panic("requested method should not be nil")
+}
+
+func (m *FakeMounterUtilsFuncStructImpl) GetMountInfo(path string) (*MountInfo, error) {
+	if m.FuncStruct.GetMountInfoFn != nil {
+		return m.FuncStruct.GetMountInfoFn(path)
+	}
+	panic("requested method should not be nil")
+}
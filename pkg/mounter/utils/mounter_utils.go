@@ -4,16 +4,24 @@
 package utils
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
+	"github.com/IBM/ibm-object-csi-driver/pkg/mounterapi"
 	"github.com/mitchellh/go-ps"
 	"k8s.io/klog/v2"
 	k8sMountUtils "k8s.io/mount-utils"
@@ -21,18 +29,84 @@ import (
 
 var unmount = syscall.Unmount
 var commandWithCtx = exec.CommandContext
+var mountInfoFile = "/proc/self/mountinfo"
 
-var ErrTimeoutWaitProcess = errors.New("timeout waiting for process to end")
+var fuseConnectionsDir = "/sys/fs/fuse/connections"
+
+// ownedProcesses are the mounter processes started by FuseMountWithOptions whose exec.Cmd has not waited for them
+// yet, only cmd.Wait reaps them so that their exit status is not lost
+var ownedProcesses sync.Map
+
+// fuseMounters are the commands serving FUSE mounts, the native mounter is served by cos-csi-mounter itself
+var fuseMounters = []string{constants.S3FS, constants.RClone, constants.MountpointS3Binary}
+
+const (
+	// DefaultUnmountTimeout bounds FuseUnmount, including the wait for the mounter process to exit
+	DefaultUnmountTimeout = time.Minute
+
+	// the mounter process gets processExitTimeout to exit after the unmount and processTermTimeout after SIGTERM
+	processExitTimeout = 10 * time.Second
+	processTermTimeout = 5 * time.Second
+)
+
+// OnUnmountAttempt, if set, is called after each unmount attempt of FuseUnmount with the method used,
+// "standard", "lazy", "force" or "abort" of the fuse connection, or the signal sent to the mounter process,
+// "sigterm" or "sigkill", and its result. cos-csi-mounter uses it to count escalations.
+var OnUnmountAttempt func(method string, err error)
+
+func observeUnmountAttempt(method string, err error) {
+	if OnUnmountAttempt != nil {
+		OnUnmountAttempt(method, err)
+	}
+}
+
+// MountpointS3Profile is the profile mount-s3 reads from its shared credentials file
+const MountpointS3Profile = "default"
 
 type MounterUtils interface {
 	FuseUnmount(path string) error
+	FuseUnmountWithContext(ctx context.Context, path string, pid int) error
 	FuseMount(path string, comm string, args []string) error
+	FuseMountWithOptions(path string, comm string, args []string, opts MountOptions) error
+	GetMountInfo(path string) (*MountInfo, error)
+}
+
+// MountInfo describes the mount currently present at a target path
+type MountInfo struct {
+	Source   string
+	FsType   string
+	ReadOnly bool
+}
+
+// MountOptions are the settings of a mount besides the arguments of the mounter
+type MountOptions struct {
+	// Env is appended to the environment of the current process, for mounters which only accept credentials
+	// through environment variables
+	Env []string
+	// Readiness tells when the mount is ready
+	Readiness mounterapi.Readiness
 }
 
 type MounterOptsUtils struct {
+	// Output, if set, opens the file the output of the mounter process of a path is written to
+	Output func(path string) (*os.File, error)
 }
 
 func (su *MounterOptsUtils) FuseMount(path string, comm string, args []string) error {
+	return su.FuseMountWithOptions(path, comm, args, MountOptions{})
+}
+
+// MountpointS3Env returns the environment pointing mount-s3 at the credentials file written for a target
+func MountpointS3Env(credFile string) []string {
+	return []string{
+		"AWS_SHARED_CREDENTIALS_FILE=" + credFile,
+		"AWS_PROFILE=" + MountpointS3Profile,
+	}
+}
+
+// FuseMountWithOptions runs the fuse mounter and waits for the mount to be ready as told by opts. A mount that
+// fails the readiness probe is unmounted, so that a retry starts afresh.
+func (su *MounterOptsUtils) FuseMountWithOptions(path string, comm string, args []string, opts MountOptions) error {
 	klog.Info("-FuseMount-")
 	klog.Infof("FuseMount: params:\n\tpath: <%s>\n\tcommand: <%s>\n\targs: <%v>", path, comm, args)
 
@@ -45,25 +119,49 @@ func (su *MounterOptsUtils) FuseMount(path string, comm string, args []string) e
 	}()
 
 	cmd := commandWithCtx(ctx, comm, args...)
+	// the mounter must keep serving the mount when the caller stops, so it does not get the signals of its group
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if len(opts.Env) > 0 {
+		cmd.Env = append(os.Environ(), opts.Env...)
+	}
+	if su.Output != nil {
+		output, err := su.Output(path)
+		if err != nil {
+			klog.Warningf("FuseMount: cannot open output file of mounter=%s, path=%s, error=%v", comm, path, err)
+		} else {
+			// the process gets its own descriptor, it keeps writing after this one is closed
+			defer func() {
+				if err := output.Close(); err != nil {
+					klog.Warningf("FuseMount: cannot close output file of path=%s, error=%v", path, err)
+				}
+			}()
+			cmd.Stdout = output
+			cmd.Stderr = output
+		}
+	}
 	err := cmd.Start()
 	if err != nil {
 		klog.Errorf("FuseMount: command start failed: mounter=%s, args=%v, error=%v", comm, args, err)
 		return fmt.Errorf("FuseMount: '%s' command start failed: %v", comm, err)
 	}
 	klog.Infof("FuseMount: command 'start' succeeded for '%s' mounter", comm)
+	pid := cmd.Process.Pid
+	ownedProcesses.Store(pid, struct{}{})
 
 	waitCh := make(chan error, 1)
 	mountCh := make(chan error, 1)
 
 	go func() {
 		klog.Infof("FuseMount: cmd.Wait() goroutine start for mounter=%s, path=%v", comm, path)
-		waitCh <- cmd.Wait()
+		err := cmd.Wait()
+		ownedProcesses.Delete(pid)
+		waitCh <- err
 		klog.Infof("FuseMount: cmd.Wait() goroutine end for mounter=%s, path=%v", comm, path)
 	}()
 
 	go func() {
 		klog.Infof("FuseMount: waitForMount() goroutine start for mounter=%s, path=%v", comm, path)
-		mountCh <- waitForMount(ctx, path, 2*time.Second, 30*time.Second) // kubelet retries NodePublishVolume after 120 seconds
+		mountCh <- waitForMount(ctx, path, opts.Readiness)
 		klog.Infof("FuseMount: waitForMount() goroutine end  for mounter=%s, path=%v", comm, path)
 	}()
 
@@ -81,12 +179,14 @@ func (su *MounterOptsUtils) FuseMount(path string, comm string, args []string) e
 		}
 		klog.Infof("FuseMount: command 'wait' succeeded for '%s' mounter", comm)
 		if err := <-mountCh; err != nil {
+			unmountUnready(path)
 			return err
 		}
 
 	case err := <-mountCh:
 		if err != nil {
-			klog.Errorf("FuseMount: path is not mountpoint. Mount failed: mounter=%s, path=%s", comm, path)
+			klog.Errorf("FuseMount: mount is not ready. Mount failed: mounter=%s, path=%s", comm, path)
+			unmountUnready(path)
 			return fmt.Errorf("'%s' mount failed: %v", comm, err)
 		}
 	}
@@ -97,52 +197,280 @@ func (su *MounterOptsUtils) FuseMount(path string, comm string, args []string) e
 }
 
 func (su *MounterOptsUtils) FuseUnmount(path string) error {
+	return su.FuseUnmountWithContext(context.Background(), path, 0)
+}
+
+// FuseUnmountWithContext unmounts path and waits for the mounter process serving it to exit, signaling it if it
+// does not. pid is the mounter process recorded by the caller, if any. The wait ends when ctx is done, or after
+// DefaultUnmountTimeout if ctx has no deadline, the recorded process is then killed. A process that is only
+// found by its command line is sent SIGTERM but never killed.
+func (su *MounterOptsUtils) FuseUnmountWithContext(ctx context.Context, path string, pid int) error {
 	klog.Info("-FuseUnmount-")
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultUnmountTimeout)
+		defer cancel()
+	}
+
+	// the process is looked up before the unmount, its command line is gone once it exits
+	pid, recorded := mounterPID(path, pid)
+
 	// check if mountpoint exists
 	isMount, checkMountErr := isMountpoint(path)
 	if isMount || checkMountErr != nil {
 		klog.Infof("isMountpoint  %v", isMount)
-		err := unmount(path, 0)
-		if err != nil {
-			klog.Warningf("Standard unmount failed for %s: %v. Trying lazy unmount...", path, err)
-			// Try lazy (MNT_DETACH) unmount
-			err = unmount(path, syscall.MNT_DETACH)
-			if err != nil {
-				klog.Warningf("Lazy unmount failed for %s: %v. Trying force unmount...", path, err)
-				// Try force unmount as last resort
-				err = unmount(path, syscall.MNT_FORCE)
-				if err != nil {
-					klog.Errorf("Force unmount failed for %s: %v", path, err)
-					return fmt.Errorf("all unmount attempts failed for %s: %v", path, err)
-				}
-				klog.Infof("Force unmounted %s successfully", path)
-			} else {
-				klog.Infof("Lazy unmounted %s successfully", path)
-			}
-		} else {
-			klog.Infof("Unmounted %s with standard unmount successfully", path)
+		if err := unmountPath(path); err != nil {
+			return err
 		}
 	}
 
-	// as fuse quits immediately, we will try to wait until the process is done
-	process, err := findFuseMountProcess(path)
+	if pid == 0 {
+		klog.Infof("Unable to find PID of fuse mount %s, it must have finished already", path)
+		return nil
+	}
+	klog.Infof("Found fuse pid %v of mount %s, checking if it still runs", pid, path)
+	return stopProcess(ctx, path, pid, recorded)
+}
+
+// unmountPath escalates from a standard to a lazy and a force unmount. If they all fail the FUSE connection of the
+// mount is aborted, which fails the pending requests of the mounter, and the lazy unmount is retried.
+func unmountPath(path string) error {
+	err := unmount(path, 0)
+	observeUnmountAttempt("standard", err)
+	if err == nil {
+		klog.Infof("Unmounted %s with standard unmount successfully", path)
+		return nil
+	}
+	klog.Warningf("Standard unmount failed for %s: %v. Trying lazy unmount...", path, err)
+	// Try lazy (MNT_DETACH) unmount
+	err = unmount(path, syscall.MNT_DETACH)
+	observeUnmountAttempt("lazy", err)
+	if err == nil {
+		klog.Infof("Lazy unmounted %s successfully", path)
+		return nil
+	}
+	klog.Warningf("Lazy unmount failed for %s: %v. Trying force unmount...", path, err)
+	// Try force unmount as last resort
+	err = unmount(path, syscall.MNT_FORCE)
+	observeUnmountAttempt("force", err)
+	if err == nil {
+		klog.Infof("Force unmounted %s successfully", path)
+		return nil
+	}
+	klog.Warningf("Force unmount failed for %s: %v. Aborting its fuse connection...", path, err)
+	abortErr := abortFuseConnection(path)
+	observeUnmountAttempt("abort", abortErr)
+	if abortErr != nil {
+		klog.Errorf("Cannot abort fuse connection of %s: %v", path, abortErr)
+		return fmt.Errorf("all unmount attempts failed for %s: %v", path, err)
+	}
+	if err = unmount(path, syscall.MNT_DETACH); err != nil {
+		klog.Errorf("Unmount after aborting the fuse connection failed for %s: %v", path, err)
+		return fmt.Errorf("all unmount attempts failed for %s: %v", path, err)
+	}
+	klog.Infof("Unmounted %s after aborting its fuse connection", path)
+	return nil
+}
+
+// abortFuseConnection aborts the connection of the FUSE mount at path through the fusectl filesystem, whose
+// directories are named after the device number of the mounts
+func abortFuseConnection(path string) error {
+	mounts, err := k8sMountUtils.ParseMountInfo(mountInfoFile)
 	if err != nil {
-		klog.Infof("Error getting PID of fuse mount: %s", err)
+		return fmt.Errorf("failed to read mount table: %v", err)
+	}
+	path = filepath.Clean(path)
+	for i := len(mounts) - 1; i >= 0; i-- {
+		if mounts[i].MountPoint != path || !strings.HasPrefix(mounts[i].FsType, "fuse") {
+			continue
+		}
+		// the device number of the kernel, not the one of the userspace ABI
+		dev := uint64(mounts[i].Major)<<20 | uint64(mounts[i].Minor) // #nosec G115: device numbers are not negative
+		abortFile := filepath.Join(fuseConnectionsDir, strconv.FormatUint(dev, 10), "abort")
+		klog.Infof("Aborting fuse connection of %s: %s", path, abortFile)
+		return os.WriteFile(abortFile, []byte("1"), 0600)
+	}
+	return fmt.Errorf("%s is not a fuse mount", path)
+}
+
+// mounterPID returns pid and true if it still runs the mounter of path, else the mounter process of path found
+// by its command line and false, 0 if there is none
+func mounterPID(path string, pid int) (int, bool) {
+	if pid > 0 {
+		if IsMounterProcess(pid, path) {
+			return pid, true
+		}
+		// a zombie has no command line, it is reaped here if it is a child of this process
+		if ReapProcess(pid) {
+			klog.Infof("Mounter process %d of %s already exited", pid, path)
+		} else {
+			klog.Infof("Process %d no longer runs the mounter of %s, looking it up", pid, path)
+		}
+	}
+	return FindMounterProcess(path), false
+}
+
+// stopProcess waits for the mounter process pid of path to exit after the unmount. It escalates to SIGTERM if the
+// process is still running after processExitTimeout, and then to SIGKILL if kill is set, or at once when ctx is
+// done. The process is checked to still be the mounter of path before each signal.
+func stopProcess(ctx context.Context, path string, pid int, kill bool) error {
+	if waitForExit(ctx, pid, processExitTimeout) {
 		return nil
 	}
-	if process == nil {
-		klog.Infof("Unable to find PID of fuse mount %s, it must have finished already", path)
+	if ctx.Err() == nil || !kill {
+		klog.Warningf("Fuse process %d of %s still runs %v after the unmount, sending SIGTERM", pid, path, processExitTimeout)
+		if !signalMounter(path, pid, syscall.SIGTERM, "sigterm") || waitForExit(ctx, pid, processTermTimeout) {
+			return nil
+		}
+	}
+	if !kill {
+		return fmt.Errorf("fuse process %d of %s still runs after SIGTERM", pid, path)
+	}
+
+	klog.Warningf("Fuse process %d of %s did not exit in time, sending SIGKILL", pid, path)
+	if !signalMounter(path, pid, syscall.SIGKILL, "sigkill") {
 		return nil
 	}
-	klog.Infof("Found fuse pid %v of mount %s, checking if it still runs", process.Pid, path)
+	// SIGKILL is not bounded by ctx, it only fails to end processes stuck in the kernel
+	if !waitForExit(context.Background(), pid, processTermTimeout) {
+		return fmt.Errorf("fuse process %d of %s still runs after SIGKILL", pid, path)
+	}
+	return nil
+}
 
-	err = waitForProcess(process, 1)
-	if errors.Is(err, ErrTimeoutWaitProcess) {
-		klog.Infof("timeout waiting for pid %d to end, killing process", process.Pid)
-		return process.Kill()
+// signalMounter sends sig to pid if it still runs the mounter of path. It returns false if the process exited
+// or runs something else.
+func signalMounter(path string, pid int, sig syscall.Signal, method string) bool {
+	if !IsMounterProcess(pid, path) {
+		klog.Infof("Process %d no longer runs the mounter of %s, it is not signaled", pid, path)
+		return false
+	}
+	err := syscall.Kill(pid, sig)
+	observeUnmountAttempt(method, err)
+	if errors.Is(err, syscall.ESRCH) {
+		return false
 	}
+	if err != nil {
+		klog.Warningf("Cannot send %v to fuse process %d: %v", sig, pid, err)
+	}
+	return true
+}
 
-	return err
+// waitForExit polls whether the process pid exited, for at most timeout or until ctx is done
+func waitForExit(ctx context.Context, pid int, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(constants.Interval)
+	defer ticker.Stop()
+	for {
+		if ReapProcess(pid) {
+			klog.Infof("Fuse process %d exited", pid)
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-timer.C:
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReapProcess tells whether the process pid exited. The mounters daemonize, so their processes are reparented
+// to the init process of the node server container, i.e. the node server, or to cos-csi-mounter which is their
+// subreaper. Nothing else waits for them, so a process that exited is reaped here if it is a child of the
+// current process. A zombie that is not a child counts as exited, its parent reaps it.
+// Processes started by FuseMountWithOptions are left to their exec.Cmd, they count as running until it waited
+// for them.
+func ReapProcess(pid int) bool {
+	if _, owned := ownedProcesses.Load(pid); owned {
+		return false
+	}
+	var status syscall.WaitStatus
+	wpid, err := syscall.Wait4(pid, &status, syscall.WNOHANG, nil)
+	if err == nil {
+		return wpid == pid
+	}
+	if !errors.Is(err, syscall.ECHILD) {
+		klog.Warningf("Cannot wait for process %d: %v", pid, err)
+	}
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid)) // #nosec G304: Dynamic pid .
+	if err != nil {
+		return os.IsNotExist(err)
+	}
+	// the state follows the command name, which is in parentheses and may contain spaces
+	fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))
+	return len(fields) > 0 && (fields[0] == "Z" || fields[0] == "X")
+}
+
+// GetMountInfo returns details of the mount at path, or nil if path is not a mountpoint.
+// It reads the mount table instead of stat-ing the path, so corrupted fuse mounts are reported as well.
+func (su *MounterOptsUtils) GetMountInfo(path string) (*MountInfo, error) {
+	mounts, err := k8sMountUtils.ParseMountInfo(mountInfoFile)
+	if err != nil {
+		klog.Errorf("GetMountInfo: failed to parse %s: %v", mountInfoFile, err)
+		return nil, fmt.Errorf("failed to read mount table: %v", err)
+	}
+
+	path = filepath.Clean(path)
+	// the last entry wins in case of stacked mounts on the same path
+	for i := len(mounts) - 1; i >= 0; i-- {
+		if mounts[i].MountPoint != path {
+			continue
+		}
+		info := &MountInfo{
+			Source: mounts[i].Source,
+			FsType: mounts[i].FsType,
+		}
+		for _, opt := range mounts[i].MountOptions {
+			if opt == "ro" {
+				info.ReadOnly = true
+				break
+			}
+		}
+		klog.Infof("GetMountInfo: found mount at %s: %+v", path, *info)
+		return info, nil
+	}
+	return nil, nil
+}
+
+// csiVolumeDir is the directory of the kubelet holding the target paths of the CSI volumes of a pod
+const csiVolumeDir = "kubernetes.io~csi"
+
+// VolumeMountPoints returns the fuse mounts of the kubelet target paths of volumeID. The kubelet names the
+// directory of a target path after the PV, so the volume is identified by the vol_data.json next to it.
+func VolumeMountPoints(volumeID string) ([]string, error) {
+	mounts, err := k8sMountUtils.ParseMountInfo(mountInfoFile)
+	if err != nil {
+		klog.Errorf("VolumeMountPoints: failed to parse %s: %v", mountInfoFile, err)
+		return nil, fmt.Errorf("failed to read mount table: %v", err)
+	}
+
+	var mountPoints []string
+	for _, mnt := range mounts {
+		volumeDir := filepath.Dir(mnt.MountPoint)
+		if !strings.HasPrefix(mnt.FsType, "fuse") || filepath.Base(mnt.MountPoint) != "mount" ||
+			filepath.Base(filepath.Dir(volumeDir)) != csiVolumeDir {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(volumeDir, "vol_data.json")) // #nosec G304: path from the mount table
+		if err != nil {
+			klog.Warningf("VolumeMountPoints: cannot read volume data of %s: %v", mnt.MountPoint, err)
+			continue
+		}
+		var volData struct {
+			VolumeHandle string `json:"volumeHandle"`
+		}
+		if err = json.Unmarshal(data, &volData); err != nil {
+			klog.Warningf("VolumeMountPoints: cannot parse volume data of %s: %v", mnt.MountPoint, err)
+			continue
+		}
+		if volData.VolumeHandle == volumeID && !slices.Contains(mountPoints, mnt.MountPoint) {
+			mountPoints = append(mountPoints, mnt.MountPoint)
+		}
+	}
+	return mountPoints, nil
 }
 
 func isMountpoint(pathname string) (bool, error) {
@@ -170,86 +498,123 @@ func isMountpoint(pathname string) (bool, error) {
 	return false, nil
 }
 
-func waitForMount(ctx context.Context, path string, initialDelay, timeout time.Duration) error {
-	if initialDelay > 0 {
-		time.Sleep(initialDelay)
-	}
-	var elapsed time.Duration
-	attempt := 1
-	for {
+// waitForMount polls the mount at path until it passes the probe of readiness or the timeout of readiness runs out
+func waitForMount(ctx context.Context, path string, readiness mounterapi.Readiness) error {
+	timeout, probe := readiness.MountTimeout(), readiness.MountProbe()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for attempt := 1; ; attempt++ {
+		err := checkMount(ctx, path, probe)
+		if err == nil {
+			klog.Infof("Mount is ready: pathname: %s, probe: %s", path, probe)
+			return nil
+		}
+		klog.Infof("Mount readiness check in progress: attempt=%d, path=%s, probe=%s, err=%v, timeout=%v", attempt, path, probe, err, timeout)
+
 		select {
 		case <-ctx.Done():
-			err := ctx.Err()
-			klog.Infof("waitForMount: context is done, error: %v", err)
-			return err
-		default:
-			isMount, err := k8sMountUtils.New("").IsMountPoint(path)
-			if err == nil && isMount {
-				klog.Infof("Path is a mountpoint: pathname: %s", path)
-				return nil
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("timeout waiting for mount. Last check response: %v, probe=%s, timeout=%v", err, probe, timeout)
 			}
-
-			klog.Infof("Mountpoint check in progress: attempt=%d, path=%s, isMount=%v, err=%v, timeout=%v", attempt, path, isMount, err, timeout)
-			time.Sleep(constants.Interval)
-			elapsed += constants.Interval
-			if elapsed >= timeout {
-				return fmt.Errorf("timeout waiting for mount. Last check response: isMount=%v, err=%v, timeout=%v", isMount, err, constants.Timeout)
-			}
-			attempt++
+			klog.Infof("waitForMount: context is done, error: %v", ctx.Err())
+			return ctx.Err()
+		case <-time.After(constants.Interval):
 		}
 	}
 }
 
-func findFuseMountProcess(path string) (*os.Process, error) {
-	processes, err := ps.Processes()
-	if err != nil {
-		return nil, err
+// checkMount checks that path is a mountpoint which passes probe. The checks go through the fuse mount, they
+// run apart so that a mounter which does not answer only blocks them until ctx is done.
+func checkMount(ctx context.Context, path, probe string) error {
+	checkCh := make(chan error, 1)
+	go func() {
+		isMount, err := k8sMountUtils.New("").IsMountPoint(path)
+		switch {
+		case err != nil:
+			checkCh <- err
+		case !isMount:
+			checkCh <- errors.New("path is not a mountpoint")
+		default:
+			checkCh <- probeMount(path, probe)
+		}
+	}()
+	select {
+	case err := <-checkCh:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("mount does not answer: %v", ctx.Err())
 	}
-	for _, p := range processes {
-		cmdLine, err := getCmdLine(p.Pid())
+}
+
+// probeMount checks that the mount at path serves I/O with probe
+func probeMount(path, probe string) error {
+	switch probe {
+	case mounterapi.ProbeMountpoint:
+		return nil
+	case mounterapi.ProbeList:
+		dir, err := os.Open(path) // #nosec G304: mount path of the request
 		if err != nil {
-			klog.Errorf("Unable to get cmdline of PID %v: %s", p.Pid(), err)
-			continue
+			return err
 		}
-		if strings.Contains(cmdLine, path) {
-			klog.Infof("Found matching pid %v on path %s", p.Pid(), path)
-			return os.FindProcess(p.Pid())
+		defer dir.Close() // #nosec G307: read only
+		if _, err = dir.Readdirnames(1); err != nil && !errors.Is(err, io.EOF) {
+			return err
 		}
+		return nil
+	default:
+		_, err := os.Stat(path)
+		return err
 	}
-	return nil, nil
 }
 
-func getCmdLine(pid int) (string, error) {
-	cmdLineFile := fmt.Sprintf("/proc/%v/cmdline", pid)
-	cmdLine, err := os.ReadFile(cmdLineFile) // #nosec G304: Dynamic pid .
-	if err != nil {
-		return "", err
+// unmountUnready unmounts path if the mounter mounted it but the mount is not ready, the mounter then exits
+func unmountUnready(path string) {
+	if isMount, err := isMountpoint(path); err != nil || !isMount {
+		return
+	}
+	klog.Warningf("Unmounting %s, it is mounted but not ready", path)
+	if err := unmountPath(path); err != nil {
+		klog.Errorf("Cannot unmount %s after a failed mount: %v", path, err)
 	}
-	return string(cmdLine), nil
 }
 
-func waitForProcess(p *os.Process, backoff int) error {
-	// totally it waits 60 seconds before force killing the process
-	if backoff == 120 {
-		return ErrTimeoutWaitProcess
-	}
-	cmdLine, err := getCmdLine(p.Pid)
+// FindMounterProcess returns the PID of the FUSE mounter process serving path, 0 if there is none
+func FindMounterProcess(path string) int {
+	processes, err := ps.Processes()
 	if err != nil {
-		klog.Warningf("Error checking cmdline of PID %v, assuming it is dead: %s", p.Pid, err)
-		return nil
+		klog.Errorf("Unable to list processes: %v", err)
+		return 0
 	}
-	if cmdLine == "" {
-		// ignore defunct processes
-		// TODO: debug why this happens in the first place
-		// seems to only happen on k8s, not on local docker
-		klog.Warning("Fuse process seems dead, returning")
-		return nil
+	for _, p := range processes {
+		if IsMounterProcess(p.Pid(), path) {
+			klog.Infof("Found mounter pid %v of path %s", p.Pid(), path)
+			return p.Pid()
+		}
 	}
-	if err := p.Signal(syscall.Signal(0)); err != nil {
-		klog.Warningf("Fuse process does not seem active or we are unprivileged: %s", err)
-		return nil
+	return 0
+}
+
+// IsMounterProcess tells whether pid runs a FUSE mounter one of whose arguments is path
+func IsMounterProcess(pid int, path string) bool {
+	argv, err := getArgv(pid)
+	if err != nil || len(argv) < 2 {
+		return false
+	}
+	isMounter := slices.Contains(fuseMounters, filepath.Base(argv[0])) || argv[1] == constants.Native
+	if !isMounter {
+		return false
+	}
+	path = filepath.Clean(path)
+	return slices.ContainsFunc(argv[1:], func(arg string) bool {
+		return arg != "" && filepath.Clean(arg) == path
+	})
+}
+
+func getArgv(pid int) ([]string, error) {
+	cmdLineFile := fmt.Sprintf("/proc/%v/cmdline", pid)
+	cmdLine, err := os.ReadFile(cmdLineFile) // #nosec G304: Dynamic pid .
+	if err != nil {
+		return nil, err
 	}
-	klog.Infof("Fuse process with PID %v still active, waiting... %v", p.Pid, backoff)
-	time.Sleep(time.Duration(500) * time.Millisecond)
-	return waitForProcess(p, backoff+1)
+	return strings.Split(strings.TrimSuffix(string(cmdLine), "\x00"), "\x00"), nil
 }
@@ -0,0 +1,192 @@
+// Package mounterapi holds the API the node server uses to request mounts from the cos-csi-mounter service
+// running on the host, shared by the service and its client.
+package mounterapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// API versions served by cos-csi-mounter. V1 is the unversioned /api/cos API of the first releases, it is kept
+// for node servers that predate the version negotiation.
+const (
+	V1 = "v1"
+	V2 = "v2"
+
+	VersionPath = "/api/version"
+	HealthPath  = "/api/health"
+	V1BasePath  = "/api/cos"
+	V2BasePath  = "/api/v2"
+)
+
+// SupportedVersions are the API versions of this release in order of preference
+var SupportedVersions = []string{V2, V1}
+
+// BasePath returns the path under which the routes of an API version are served
+func BasePath(version string) string {
+	if version == V1 {
+		return V1BasePath
+	}
+	return "/api/" + version
+}
+
+// MountRequest asks to mount a bucket on path with a mounter. Args are the mounter specific arguments, they are
+// decoded by the service into the arguments of the mounter.
+type MountRequest struct {
+	Path    string          `json:"path"`
+	Bucket  string          `json:"bucket"`
+	Mounter string          `json:"mounter"`
+	Args    json.RawMessage `json:"args"`
+	// Readiness tells when the mount is ready, the defaults apply if nil
+	Readiness *Readiness `json:"readiness,omitempty"`
+}
+
+// UnmountRequest asks to unmount path
+type UnmountRequest struct {
+	Path string `json:"path"`
+	// Timeout is the time left to the caller, the service bounds the unmount by it if it is set and shorter than
+	// its own unmount timeout
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
+// Response is the body of the responses to mount and unmount requests. Failed requests have an Error and,
+// since V2, a Code.
+type Response struct {
+	Status string    `json:"status,omitempty"`
+	Error  string    `json:"error,omitempty"`
+	Code   ErrorCode `json:"code,omitempty"`
+}
+
+// Operation types
+const (
+	OperationMount   = "mount"
+	OperationUnmount = "unmount"
+)
+
+// OperationState is the state of an operation
+type OperationState string
+
+const (
+	OperationRunning   OperationState = "running"
+	OperationSucceeded OperationState = "succeeded"
+	OperationFailed    OperationState = "failed"
+)
+
+// OperationPollWait is how long the client waits for an operation to complete in each poll
+const OperationPollWait = 30 * time.Second
+
+// Operation is a mount or unmount done asynchronously since V2. Mount and unmount requests are answered with
+// 202 and the operation, whose state is then polled on <base path>/operations/<id>?wait=<duration>. A request
+// for a path that has an operation of the same type in progress returns that operation.
+type Operation struct {
+	ID         string         `json:"id"`
+	Type       string         `json:"type"`
+	Path       string         `json:"path"`
+	State      OperationState `json:"state"`
+	Error      string         `json:"error,omitempty"`
+	Code       ErrorCode      `json:"code,omitempty"`
+	StartedAt  time.Time      `json:"startedAt"`
+	FinishedAt *time.Time     `json:"finishedAt,omitempty"`
+}
+
+// Done tells if the operation completed
+func (o *Operation) Done() bool {
+	return o.State != OperationRunning
+}
+
+// ErrorResponse returns the response of a request failing with code
+func ErrorResponse(code ErrorCode, format string, args ...any) Response {
+	return Response{Error: fmt.Sprintf(format, args...), Code: code}
+}
+
+// VersionInfo is returned on VersionPath
+type VersionInfo struct {
+	APIVersions []string `json:"apiVersions"`
+	Version     string   `json:"version"`
+	GitCommit   string   `json:"gitCommit"`
+}
+
+// Health is returned on HealthPath, Reasons tell why the service cannot mount if it is not Ready
+type Health struct {
+	Ready   bool     `json:"ready"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// ErrorCode tells why a request failed
+type ErrorCode string
+
+const (
+	CodeInvalidRequest  ErrorCode = "invalid_request"
+	CodeInvalidMounter  ErrorCode = "invalid_mounter"
+	CodeInvalidArgs     ErrorCode = "invalid_args"
+	CodeMounterNotFound ErrorCode = "mounter_not_found"
+	CodeMountFailed     ErrorCode = "mount_failed"
+	CodeUnmountFailed   ErrorCode = "unmount_failed"
+	CodeForbidden       ErrorCode = "forbidden"
+	CodeNotFound        ErrorCode = "not_found"
+	CodeInternal        ErrorCode = "internal"
+	// CodeOperationInProgress rejects a request for a path that has an operation of another type in progress
+	CodeOperationInProgress ErrorCode = "operation_in_progress"
+)
+
+// GRPCCode returns the gRPC code of the CSI calls failing with c. Codes unknown to this release map to
+// codes.Unknown, callers fall back to the HTTP status of the response.
+func (c ErrorCode) GRPCCode() codes.Code {
+	switch c {
+	case CodeInvalidRequest, CodeInvalidMounter, CodeInvalidArgs:
+		return codes.InvalidArgument
+	case CodeMounterNotFound:
+		return codes.FailedPrecondition
+	case CodeMountFailed, CodeUnmountFailed, CodeInternal:
+		return codes.Internal
+	case CodeForbidden:
+		return codes.PermissionDenied
+	case CodeNotFound:
+		return codes.NotFound
+	case CodeOperationInProgress:
+		return codes.Aborted
+	case CodeBadCredentials, CodeSignatureMismatch:
+		return codes.Unauthenticated
+	case CodeAccessDenied:
+		return codes.PermissionDenied
+	case CodeBucketNotFound:
+		return codes.NotFound
+	case CodeTLSError, CodeFuseUnavailable:
+		return codes.FailedPrecondition
+	case CodeDNSFailure:
+		return codes.Unavailable
+	default:
+		return codes.Unknown
+	}
+}
+
+// HTTPStatusToGRPCCode maps the HTTP status of a failed response without a known error code
+func HTTPStatusToGRPCCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusInternalServerError:
+		return codes.Internal
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	default:
+		return codes.Unknown
+	}
+}
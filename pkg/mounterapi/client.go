@@ -0,0 +1,290 @@
+package mounterapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/klog/v2"
+)
+
+// Interface is the client of cos-csi-mounter used by the mounters
+type Interface interface {
+	Mount(ctx context.Context, request *MountRequest) error
+	Unmount(ctx context.Context, request *UnmountRequest) error
+	Health(ctx context.Context) (*Health, error)
+}
+
+// Client calls cos-csi-mounter over its unix socket. Errors are gRPC status errors. The API version is
+// negotiated on the first request, and again if the service stops serving it, so that the node server and the
+// service on the host can be upgraded independently.
+type Client struct {
+	socketPath string
+	httpClient *http.Client
+	// timeout bounds a request including the wait for its operation
+	timeout time.Duration
+
+	mu      sync.Mutex
+	version string
+}
+
+// NewClient returns a client of the service listening on socketPath whose requests time out after timeout.
+// Mounts and unmounts that do not complete in time keep running in the service, retrying the request waits for
+// them again.
+func NewClient(socketPath string, timeout time.Duration) *Client {
+	dialer := func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", socketPath)
+	}
+	return &Client{
+		socketPath: socketPath,
+		httpClient: &http.Client{
+			Transport: &http.Transport{DialContext: dialer},
+			Timeout:   timeout,
+		},
+		timeout: timeout,
+	}
+}
+
+// Mount requests a mount
+func (c *Client) Mount(ctx context.Context, request *MountRequest) error {
+	return c.call(ctx, "/mount", request)
+}
+
+// Unmount requests an unmount, bounded by the deadline of ctx unless the request sets its timeout
+func (c *Client) Unmount(ctx context.Context, request *UnmountRequest) error {
+	if deadline, ok := ctx.Deadline(); ok && request.Timeout.Duration == 0 {
+		bounded := *request
+		bounded.Timeout.Duration = time.Until(deadline)
+		request = &bounded
+	}
+	return c.call(ctx, "/unmount", request)
+}
+
+// MountLogs returns the last lines of the output of the mounter process of path, the service serves them since V2
+func (c *Client) MountLogs(ctx context.Context, path string, lines int) (string, error) {
+	version, err := c.APIVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+	if version == V1 {
+		return "", status.Errorf(codes.Unimplemented, "cos-csi-mounter does not serve mount logs with API %s", version)
+	}
+	logsPath := (&url.URL{Path: BasePath(version) + "/logs" + path}).EscapedPath()
+	code, body, err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s?lines=%d", logsPath, lines), nil)
+	if err != nil {
+		return "", err
+	}
+	if code != http.StatusOK {
+		return "", responseError(code, body)
+	}
+	return string(body), nil
+}
+
+// Health returns the health of the service. Services that predate HealthPath are healthy once they answer.
+func (c *Client) Health(ctx context.Context) (*Health, error) {
+	code, body, err := c.do(ctx, http.MethodGet, HealthPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch code {
+	case http.StatusNotFound:
+		return &Health{Ready: true}, nil
+	case http.StatusOK, http.StatusServiceUnavailable:
+		var health Health
+		if err := json.Unmarshal(body, &health); err != nil {
+			return nil, status.Errorf(codes.Internal, "invalid health response from cos-csi-mounter: %v", err)
+		}
+		return &health, nil
+	}
+	return nil, responseError(code, body)
+}
+
+// APIVersion returns the API version used with the service, negotiating it if needed
+func (c *Client) APIVersion(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.version != "" {
+		return c.version, nil
+	}
+	version, err := c.negotiate(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.version = version
+	return version, nil
+}
+
+func (c *Client) forgetVersion(version string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.version == version {
+		c.version = ""
+	}
+}
+
+func (c *Client) negotiate(ctx context.Context) (string, error) {
+	code, body, err := c.do(ctx, http.MethodGet, VersionPath, nil)
+	if err != nil {
+		return "", err
+	}
+	if code == http.StatusNotFound {
+		klog.Infof("cos-csi-mounter at %s does not serve %s, using API %s", c.socketPath, VersionPath, V1)
+		return V1, nil
+	}
+	if code != http.StatusOK {
+		return "", responseError(code, body)
+	}
+
+	var info VersionInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return "", status.Errorf(codes.Internal, "invalid version response from cos-csi-mounter: %v", err)
+	}
+	for _, version := range SupportedVersions {
+		if slices.Contains(info.APIVersions, version) {
+			klog.Infof("Using API %s of cos-csi-mounter %s (%s)", version, info.Version, info.GitCommit)
+			return version, nil
+		}
+	}
+	return "", status.Errorf(codes.FailedPrecondition, "cos-csi-mounter %s serves API versions %v, none of the supported versions %v",
+		info.Version, info.APIVersions, SupportedVersions)
+}
+
+func (c *Client) call(ctx context.Context, route string, request any) error {
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return status.Errorf(codes.Internal, "cannot encode request to cos-csi-mounter: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	for attempt := 1; ; attempt++ {
+		version, err := c.APIVersion(ctx)
+		if err != nil {
+			return err
+		}
+		code, body, err := c.do(ctx, http.MethodPost, BasePath(version)+route, payload)
+		if err != nil {
+			return err
+		}
+		klog.Infof("response from cos-csi-mounter -> Response body: %s, Response code: %v", body, code)
+		switch {
+		case code == http.StatusOK:
+			return nil
+		case code == http.StatusAccepted:
+			var op Operation
+			if err := json.Unmarshal(body, &op); err != nil {
+				return status.Errorf(codes.Internal, "invalid operation from cos-csi-mounter: %v", err)
+			}
+			return c.wait(ctx, version, op)
+		case code == http.StatusNotFound && attempt == 1:
+			// the routes of the API do not answer 404, the service was replaced by one that does not serve the version
+			klog.Warningf("cos-csi-mounter does not serve %s, negotiating the API version again", BasePath(version)+route)
+			c.forgetVersion(version)
+			continue
+		}
+		return responseError(code, body)
+	}
+}
+
+// wait polls op until it completes and returns its error
+func (c *Client) wait(ctx context.Context, version string, op Operation) error {
+	for !op.Done() {
+		klog.Infof("Waiting for operation %s: %s of %s", op.ID, op.Type, op.Path)
+		code, body, err := c.do(ctx, http.MethodGet,
+			fmt.Sprintf("%s/operations/%s?wait=%s", BasePath(version), op.ID, OperationPollWait), nil)
+		if err != nil {
+			if ctx.Err() != nil {
+				return status.Errorf(codes.DeadlineExceeded, "%s of %s did not complete in time, operation %s is in progress",
+					op.Type, op.Path, op.ID)
+			}
+			return err
+		}
+		if code == http.StatusNotFound {
+			// the service restarted
+			return status.Errorf(codes.Aborted, "operation %s, %s of %s, is not known to cos-csi-mounter anymore", op.ID, op.Type, op.Path)
+		}
+		if code != http.StatusOK {
+			return responseError(code, body)
+		}
+		if err := json.Unmarshal(body, &op); err != nil {
+			return status.Errorf(codes.Internal, "invalid operation from cos-csi-mounter: %v", err)
+		}
+	}
+
+	klog.Infof("Operation %s, %s of %s, %s", op.ID, op.Type, op.Path, op.State)
+	if op.State == OperationFailed {
+		code := op.Code.GRPCCode()
+		if code == codes.Unknown {
+			code = codes.Internal
+		}
+		return &Error{Code: op.Code, GRPCCode: code, Message: op.Error}
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, payload []byte) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, "http://unix"+path, bytes.NewReader(payload))
+	if err != nil {
+		return 0, nil, status.Errorf(codes.Internal, "cannot create request to cos-csi-mounter: %v", err)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	response, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, status.Errorf(codes.Unavailable, "cos-csi-mounter at %s is not available: %v", c.socketPath, err)
+	}
+	defer func() {
+		if err := response.Body.Close(); err != nil {
+			klog.Errorf("failed to close response body: %v", err)
+		}
+	}()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return 0, nil, status.Errorf(codes.Unavailable, "cannot read response of cos-csi-mounter: %v", err)
+	}
+	return response.StatusCode, body, nil
+}
+
+// Error is the error of a failed request with the code of the response. Its gRPC code is that of Code, or of the
+// HTTP status for codes unknown to this release.
+type Error struct {
+	Code     ErrorCode
+	GRPCCode codes.Code
+	Message  string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// GRPCStatus makes the CSI calls returning the error fail with its gRPC code
+func (e *Error) GRPCStatus() *status.Status {
+	return status.New(e.GRPCCode, e.Message)
+}
+
+// responseError frames the gRPC error of a failed response. The error code of the response decides the gRPC
+// code, responses of V1 services and codes unknown to this release fall back to the HTTP status.
+func responseError(httpStatus int, body []byte) error {
+	var response Response
+	if err := json.Unmarshal(body, &response); err != nil || response.Error == "" {
+		return status.Error(HTTPStatusToGRPCCode(httpStatus), string(body))
+	}
+	code := response.Code.GRPCCode()
+	if code == codes.Unknown {
+		code = HTTPStatusToGRPCCode(httpStatus)
+	}
+	return &Error{Code: response.Code, GRPCCode: code, Message: response.Error}
+}
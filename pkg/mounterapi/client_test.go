@@ -0,0 +1,340 @@
+package mounterapi
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// startServer serves handler on a unix socket and returns a client of it
+func startServer(t *testing.T, handler http.Handler) *Client {
+	socketPath := filepath.Join(t.TempDir(), "coscsi.sock")
+	listener, err := net.Listen("unix", socketPath)
+	assert.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(handler)
+	_ = server.Listener.Close()
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(server.Close)
+
+	return NewClient(socketPath, time.Minute)
+}
+
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestClient_MountV2(t *testing.T) {
+	var got MountRequest
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+VersionPath, func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, VersionInfo{APIVersions: []string{V1, V2, "v3"}, Version: "1.0.0"})
+	})
+	mux.HandleFunc("POST "+V2BasePath+"/mount", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		writeJSON(w, http.StatusOK, Response{Status: "success"})
+	})
+	client := startServer(t, mux)
+
+	request := &MountRequest{
+		Path:    `/var/lib/kubelet/pods/"quoted"/mount`,
+		Bucket:  `bucket","mounter":"injected`,
+		Mounter: "s3fs",
+		Args:    json.RawMessage(`{"endpoint":"https://s3.test"}`),
+	}
+	assert.NoError(t, client.Mount(context.Background(), request))
+	assert.Equal(t, request.Path, got.Path)
+	assert.Equal(t, request.Bucket, got.Bucket)
+	assert.Equal(t, "s3fs", got.Mounter)
+	assert.JSONEq(t, `{"endpoint":"https://s3.test"}`, string(got.Args))
+
+	version, err := client.APIVersion(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, V2, version)
+}
+
+func TestClient_FallbackToV1(t *testing.T) {
+	var unmounted string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+V1BasePath+"/unmount", func(w http.ResponseWriter, r *http.Request) {
+		var request UnmountRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		unmounted = request.Path
+		writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+	})
+	client := startServer(t, mux)
+
+	assert.NoError(t, client.Unmount(context.Background(), &UnmountRequest{Path: "/mnt/test"}))
+	assert.Equal(t, "/mnt/test", unmounted)
+	version, _ := client.APIVersion(context.Background())
+	assert.Equal(t, V1, version)
+}
+
+func TestClient_UnmountSendsDeadline(t *testing.T) {
+	var timeout time.Duration
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+V1BasePath+"/unmount", func(w http.ResponseWriter, r *http.Request) {
+		var request UnmountRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		timeout = request.Timeout.Duration
+		writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+	})
+	client := startServer(t, mux)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	request := &UnmountRequest{Path: "/mnt/test"}
+	assert.NoError(t, client.Unmount(ctx, request))
+	assert.Greater(t, timeout, 20*time.Second)
+	assert.LessOrEqual(t, timeout, 30*time.Second)
+	// the request of the caller is left as is
+	assert.Zero(t, request.Timeout.Duration)
+}
+
+func TestClient_RenegotiatesWhenVersionIsGone(t *testing.T) {
+	versions := []string{V2}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+VersionPath, func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, VersionInfo{APIVersions: versions})
+	})
+	mux.HandleFunc("POST "+V1BasePath+"/mount", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, Response{Status: "success"})
+	})
+	client := startServer(t, mux)
+
+	version, err := client.APIVersion(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, V2, version)
+
+	// the service was replaced by one serving V1 only
+	versions = []string{V1}
+	assert.NoError(t, client.Mount(context.Background(), &MountRequest{Path: "/mnt/test"}))
+	version, _ = client.APIVersion(context.Background())
+	assert.Equal(t, V1, version)
+}
+
+func TestClient_NoCommonVersion(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+VersionPath, func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, VersionInfo{APIVersions: []string{"v3"}, Version: "9.0.0"})
+	})
+	client := startServer(t, mux)
+
+	err := client.Mount(context.Background(), &MountRequest{Path: "/mnt/test"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestClient_Unavailable(t *testing.T) {
+	client := NewClient(filepath.Join(t.TempDir(), "missing.sock"), time.Second)
+
+	err := client.Mount(context.Background(), &MountRequest{Path: "/mnt/test"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestClient_ErrorCodes(t *testing.T) {
+	testCases := []struct {
+		name         string
+		httpStatus   int
+		body         any
+		expectedCode codes.Code
+		expectedMsg  string
+	}{
+		{
+			name:         "invalid args",
+			httpStatus:   http.StatusBadRequest,
+			body:         ErrorResponse(CodeInvalidArgs, "invalid args for mounter: %s", "bad"),
+			expectedCode: codes.InvalidArgument,
+			expectedMsg:  "invalid args for mounter: bad",
+		},
+		{
+			name:         "mounter not found",
+			httpStatus:   http.StatusInternalServerError,
+			body:         ErrorResponse(CodeMounterNotFound, "mount failed: no binary"),
+			expectedCode: codes.FailedPrecondition,
+			expectedMsg:  "mount failed: no binary",
+		},
+		{
+			name:         "classified mount failure",
+			httpStatus:   http.StatusInternalServerError,
+			body:         ErrorResponse(CodeBucketNotFound, "mount failed, the bucket does not exist: exit status 1"),
+			expectedCode: codes.NotFound,
+			expectedMsg:  "mount failed, the bucket does not exist: exit status 1",
+		},
+		{
+			name:         "code unknown to the client",
+			httpStatus:   http.StatusServiceUnavailable,
+			body:         ErrorResponse("draining", "service is draining"),
+			expectedCode: codes.Unavailable,
+			expectedMsg:  "service is draining",
+		},
+		{
+			name:         "v1 response",
+			httpStatus:   http.StatusInternalServerError,
+			body:         map[string]string{"error": "mount failed: exit status 1"},
+			expectedCode: codes.Internal,
+			expectedMsg:  "mount failed: exit status 1",
+		},
+		{
+			name:         "not json",
+			httpStatus:   http.StatusForbidden,
+			body:         "forbidden",
+			expectedCode: codes.PermissionDenied,
+			expectedMsg:  "\"forbidden\"\n",
+		},
+	}
+	for _, tc := range testCases {
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET "+VersionPath, func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(w, http.StatusOK, VersionInfo{APIVersions: SupportedVersions})
+		})
+		mux.HandleFunc("POST "+V2BasePath+"/mount", func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(w, tc.httpStatus, tc.body)
+		})
+		client := startServer(t, mux)
+
+		err := client.Mount(context.Background(), &MountRequest{Path: "/mnt/test"})
+		st, _ := status.FromError(err)
+		assert.Equal(t, tc.expectedCode, st.Code(), tc.name)
+		assert.Equal(t, tc.expectedMsg, st.Message(), tc.name)
+	}
+}
+
+func TestClient_WaitsForOperation(t *testing.T) {
+	testCases := []struct {
+		name         string
+		final        Operation
+		expectedCode codes.Code
+	}{
+		{
+			name:         "succeeded",
+			final:        Operation{ID: "op1", Type: OperationMount, State: OperationSucceeded},
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "failed",
+			final:        Operation{ID: "op1", Type: OperationMount, State: OperationFailed, Code: CodeMountFailed, Error: "mount failed: exit status 1"},
+			expectedCode: codes.Internal,
+		},
+		{
+			name:         "failed with unknown code",
+			final:        Operation{ID: "op1", Type: OperationMount, State: OperationFailed, Code: "new_code", Error: "failed"},
+			expectedCode: codes.Internal,
+		},
+	}
+	for _, tc := range testCases {
+		polls := 0
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET "+VersionPath, func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(w, http.StatusOK, VersionInfo{APIVersions: SupportedVersions})
+		})
+		mux.HandleFunc("POST "+V2BasePath+"/mount", func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(w, http.StatusAccepted, Operation{ID: "op1", Type: OperationMount, State: OperationRunning})
+		})
+		mux.HandleFunc("GET "+V2BasePath+"/operations/op1", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, OperationPollWait.String(), r.URL.Query().Get("wait"))
+			polls++
+			if polls < 2 {
+				writeJSON(w, http.StatusOK, Operation{ID: "op1", Type: OperationMount, State: OperationRunning})
+				return
+			}
+			writeJSON(w, http.StatusOK, tc.final)
+		})
+		client := startServer(t, mux)
+
+		err := client.Mount(context.Background(), &MountRequest{Path: "/mnt/test"})
+		assert.Equal(t, tc.expectedCode, status.Code(err), tc.name)
+		assert.Equal(t, 2, polls, tc.name)
+	}
+}
+
+func TestClient_OperationLost(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+VersionPath, func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, VersionInfo{APIVersions: SupportedVersions})
+	})
+	mux.HandleFunc("POST "+V2BasePath+"/unmount", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusAccepted, Operation{ID: "op1", Type: OperationUnmount, State: OperationRunning})
+	})
+	mux.HandleFunc("GET "+V2BasePath+"/operations/op1", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusNotFound, ErrorResponse(CodeNotFound, "no operation op1"))
+	})
+	client := startServer(t, mux)
+
+	err := client.Unmount(context.Background(), &UnmountRequest{Path: "/mnt/test"})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+}
+
+func TestClient_OperationTimeout(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+VersionPath, func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, VersionInfo{APIVersions: SupportedVersions})
+	})
+	mux.HandleFunc("POST "+V2BasePath+"/mount", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusAccepted, Operation{ID: "op1", Type: OperationMount, State: OperationRunning})
+	})
+	mux.HandleFunc("GET "+V2BasePath+"/operations/op1", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	client := startServer(t, mux)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := client.Mount(ctx, &MountRequest{Path: "/mnt/test"})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+func TestClient_MountLogs(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+VersionPath, func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, VersionInfo{APIVersions: SupportedVersions})
+	})
+	mux.HandleFunc("GET "+V2BasePath+"/logs/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != V2BasePath+"/logs/mnt/test" {
+			writeJSON(w, http.StatusNotFound, ErrorResponse(CodeNotFound, "no log of %s", r.URL.Path))
+			return
+		}
+		assert.Equal(t, "5", r.URL.Query().Get("lines"))
+		_, _ = w.Write([]byte("s3fs: could not connect\n"))
+	})
+	client := startServer(t, mux)
+
+	logs, err := client.MountLogs(context.Background(), "/mnt/test", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, "s3fs: could not connect\n", logs)
+
+	_, err = client.MountLogs(context.Background(), "/mnt/other", 5)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestClient_Health(t *testing.T) {
+	health := Health{Ready: false, Reasons: []string{"/dev/fuse is missing"}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+HealthPath, func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusServiceUnavailable, health)
+	})
+	client := startServer(t, mux)
+
+	got, err := client.Health(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, &health, got)
+
+	// services that predate the health route are healthy once they answer
+	got, err = startServer(t, http.NewServeMux()).Health(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, got.Ready)
+
+	_, err = NewClient(filepath.Join(t.TempDir(), "missing.sock"), time.Second).Health(context.Background())
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
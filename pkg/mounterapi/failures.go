@@ -0,0 +1,111 @@
+package mounterapi
+
+import "regexp"
+
+// Codes of the mount failures recognized in the output of the mounters, they replace CodeMountFailed
+const (
+	CodeBadCredentials    ErrorCode = "bad_credentials"
+	CodeSignatureMismatch ErrorCode = "signature_mismatch"
+	CodeAccessDenied      ErrorCode = "access_denied"
+	CodeBucketNotFound    ErrorCode = "bucket_not_found"
+	CodeTLSError          ErrorCode = "tls_error"
+	CodeDNSFailure        ErrorCode = "dns_failure"
+	CodeFuseUnavailable   ErrorCode = "fuse_unavailable"
+)
+
+// MountFailure is a known cause of failed mounts
+type MountFailure struct {
+	Code ErrorCode
+	// Reason is the reason of the Kubernetes events reporting the failure
+	Reason string
+	// Hint tells what to check to fix the failure
+	Hint string
+
+	patterns []*regexp.Regexp
+}
+
+// MountFailures is the catalog of known failures, in the order they are matched against the mounter output
+var MountFailures = []*MountFailure{
+	{
+		Code:   CodeSignatureMismatch,
+		Reason: "COSSignatureMismatch",
+		Hint:   "the request signature does not match, check the secretKey of the secret and the clock of the node",
+		patterns: compile(`SignatureDoesNotMatch`, `(?i)signature we calculated does not match`,
+			`(?i)RequestTimeTooSkewed`),
+	},
+	{
+		Code:   CodeBadCredentials,
+		Reason: "COSBadCredentials",
+		Hint:   "the credentials were rejected, check the accessKey and secretKey or the apiKey of the secret",
+		patterns: compile(`InvalidAccessKeyId`, `(?i)access key id you provided does not exist`, `BXNIM0415E`,
+			`(?i)provided api key could not be found`, `(?i)could not get iam (access )?token`,
+			`(?i)invalid ibm api key`, `(?i)responseCode[=: ]+401\b`),
+	},
+	{
+		Code:     CodeAccessDenied,
+		Reason:   "COSAccessDenied",
+		Hint:     "the credentials are not allowed to access the bucket, grant them access to the bucket and objectPath",
+		patterns: compile(`AccessDenied`, `(?i)access denied`, `(?i)responseCode[=: ]+403\b`, `(?i)\b403 Forbidden\b`),
+	},
+	{
+		Code:   CodeBucketNotFound,
+		Reason: "COSBucketNotFound",
+		Hint:   "the bucket does not exist, check the bucketName of the secret and that the endpoint is the one of its region",
+		patterns: compile(`NoSuchBucket`, `(?i)specified bucket does not exist`, `(?i)bucket not found`,
+			`(?i)responseCode[=: ]+404\b`),
+	},
+	{
+		Code:   CodeTLSError,
+		Reason: "COSTLSError",
+		// curl codes: 35 SSL connect error, 51 and 60 certificate verification, 58 client certificate, 59
+		// cipher not supported, 77 CA bundle
+		Hint: "the TLS connection to the endpoint failed, check the cipher_suites mount option and the CA certificates of the node",
+		patterns: compile(`(?i)curlCode[=: ]+(35|51|58|59|60|77)\b`, `(?i)ssl connect error`, `(?i)failed setting cipher list`,
+			`(?i)x509: `, `(?i)tls: `, `(?i)certificate verify failed`, `(?i)ssl certificate problem`),
+	},
+	{
+		Code:   CodeDNSFailure,
+		Reason: "COSEndpointUnresolvable",
+		Hint:   "the endpoint cannot be resolved, check the cosEndpoint of the secret and the DNS of the node",
+		patterns: compile(`(?i)curlCode[=: ]+6\b`, `(?i)could not resolve host`, `(?i)no such host`,
+			`(?i)name or service not known`, `(?i)temporary failure in name resolution`),
+	},
+	{
+		Code:   CodeFuseUnavailable,
+		Reason: "FUSEUnavailable",
+		Hint:   "FUSE is not available on the node, check that the fuse module is loaded and /dev/fuse exists",
+		patterns: compile(`(?i)fuse: device not found`, `(?i)(failed to open|cannot open|no such file or directory).*/dev/fuse`,
+			`(?i)fusermount3?: .*not found`, `(?i)fuse device not found`),
+	},
+}
+
+func compile(exprs ...string) []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, 0, len(exprs))
+	for _, expr := range exprs {
+		patterns = append(patterns, regexp.MustCompile(expr))
+	}
+	return patterns
+}
+
+// ClassifyMountOutput returns the first failure of the catalog found in the output of a failed mount, nil if
+// none is found
+func ClassifyMountOutput(output string) *MountFailure {
+	for _, failure := range MountFailures {
+		for _, pattern := range failure.patterns {
+			if pattern.MatchString(output) {
+				return failure
+			}
+		}
+	}
+	return nil
+}
+
+// MountFailureOf returns the failure of the catalog with code, nil if code is not one of them
+func MountFailureOf(code ErrorCode) *MountFailure {
+	for _, failure := range MountFailures {
+		if failure.Code == code {
+			return failure
+		}
+	}
+	return nil
+}
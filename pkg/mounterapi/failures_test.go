@@ -0,0 +1,41 @@
+package mounterapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+)
+
+func TestClassifyMountOutput(t *testing.T) {
+	testCases := []struct {
+		output string
+		code   ErrorCode
+	}{
+		{output: "s3fs: CheckBucket(): SignatureDoesNotMatch", code: CodeSignatureMismatch},
+		{output: "ERROR : InvalidAccessKeyId: The AWS Access Key Id you provided does not exist in our records.", code: CodeBadCredentials},
+		{output: "BXNIM0415E: Provided API key could not be found", code: CodeBadCredentials},
+		{output: "s3fs: Failed to check bucket and directory for mount point : responseCode=403", code: CodeAccessDenied},
+		{output: "AccessDenied: Access Denied\n\tstatus code: 403", code: CodeAccessDenied},
+		{output: "ERROR : NoSuchBucket: The specified bucket does not exist", code: CodeBucketNotFound},
+		{output: "s3fs: curlCode: 59 msg: Failed to use specified cipher", code: CodeTLSError},
+		{output: "x509: certificate signed by unknown authority", code: CodeTLSError},
+		{output: "curlCode: 6 msg: Couldn't resolve host name", code: CodeDNSFailure},
+		{output: "dial tcp: lookup s3.example.test: no such host", code: CodeDNSFailure},
+		{output: "fuse: device not found, try 'modprobe fuse' first", code: CodeFuseUnavailable},
+		{output: "mount helper error: fusermount3: executable file not found in $PATH", code: CodeFuseUnavailable},
+	}
+	for _, tc := range testCases {
+		failure := ClassifyMountOutput(tc.output)
+		if assert.NotNil(t, failure, tc.output) {
+			assert.Equal(t, tc.code, failure.Code, tc.output)
+			assert.NotEqual(t, codes.Unknown, failure.Code.GRPCCode(), tc.output)
+			assert.Same(t, failure, MountFailureOf(tc.code))
+		}
+	}
+
+	assert.Nil(t, ClassifyMountOutput("exit status 1"))
+	assert.Nil(t, MountFailureOf(CodeMountFailed))
+	assert.Equal(t, codes.Unauthenticated, CodeBadCredentials.GRPCCode())
+	assert.Equal(t, codes.FailedPrecondition, CodeTLSError.GRPCCode())
+}
@@ -0,0 +1,43 @@
+package mounterapi
+
+import (
+	"context"
+	"sync"
+)
+
+// FakeClient records the requests it gets and fails them with MountErr and UnmountErr, its health is
+// HealthResult, healthy if nil, or HealthErr
+type FakeClient struct {
+	MountErr     error
+	UnmountErr   error
+	HealthResult *Health
+	HealthErr    error
+
+	mu              sync.Mutex
+	MountRequests   []MountRequest
+	UnmountRequests []UnmountRequest
+}
+
+func (f *FakeClient) Mount(_ context.Context, request *MountRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.MountRequests = append(f.MountRequests, *request)
+	return f.MountErr
+}
+
+func (f *FakeClient) Unmount(_ context.Context, request *UnmountRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.UnmountRequests = append(f.UnmountRequests, *request)
+	return f.UnmountErr
+}
+
+func (f *FakeClient) Health(_ context.Context) (*Health, error) {
+	if f.HealthErr != nil {
+		return nil, f.HealthErr
+	}
+	if f.HealthResult != nil {
+		return f.HealthResult, nil
+	}
+	return &Health{Ready: true}, nil
+}
@@ -0,0 +1,62 @@
+package mounterapi
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Probes of the readiness of a new mount
+const (
+	// ProbeMountpoint only waits for the path to be a mountpoint
+	ProbeMountpoint = "mountpoint"
+	// ProbeStat stats the root of the mount, the mounter answers it once it is connected
+	ProbeStat = "stat"
+	// ProbeList lists the root of the mount, which makes the mounter list the bucket under its objectPath
+	ProbeList = "list"
+)
+
+const (
+	// DefaultMountTimeout is the wait for a new mount to be ready when the volume does not set it
+	DefaultMountTimeout = 30 * time.Second
+	// MaxMountTimeout keeps the wait below the timeout of kubelet for NodePublishVolume
+	MaxMountTimeout = 110 * time.Second
+)
+
+// Readiness tells when a new mount is ready to serve I/O, the zero value waits DefaultMountTimeout for the stat
+// probe to succeed
+type Readiness struct {
+	// Timeout bounds the wait for the mount to be ready
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+	// Probe is ProbeMountpoint, ProbeStat or ProbeList
+	Probe string `json:"probe,omitempty"`
+}
+
+// Validate checks the timeout and probe of r
+func (r Readiness) Validate() error {
+	if r.Timeout.Duration < 0 || r.Timeout.Duration > MaxMountTimeout {
+		return fmt.Errorf("mount timeout %v is not between 0 and %v", r.Timeout.Duration, MaxMountTimeout)
+	}
+	switch r.Probe {
+	case "", ProbeMountpoint, ProbeStat, ProbeList:
+		return nil
+	}
+	return fmt.Errorf("unknown mount probe %q, it must be %s, %s or %s", r.Probe, ProbeMountpoint, ProbeStat, ProbeList)
+}
+
+// MountTimeout returns the wait for the mount to be ready
+func (r Readiness) MountTimeout() time.Duration {
+	if r.Timeout.Duration == 0 {
+		return DefaultMountTimeout
+	}
+	return r.Timeout.Duration
+}
+
+// MountProbe returns the probe of the mount
+func (r Readiness) MountProbe() string {
+	if r.Probe == "" {
+		return ProbeStat
+	}
+	return r.Probe
+}
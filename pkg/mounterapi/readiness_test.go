@@ -0,0 +1,25 @@
+package mounterapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReadiness(t *testing.T) {
+	var readiness Readiness
+	assert.NoError(t, readiness.Validate())
+	assert.Equal(t, DefaultMountTimeout, readiness.MountTimeout())
+	assert.Equal(t, ProbeStat, readiness.MountProbe())
+
+	readiness = Readiness{Timeout: metav1.Duration{Duration: time.Minute}, Probe: ProbeMountpoint}
+	assert.NoError(t, readiness.Validate())
+	assert.Equal(t, time.Minute, readiness.MountTimeout())
+	assert.Equal(t, ProbeMountpoint, readiness.MountProbe())
+
+	assert.Error(t, Readiness{Timeout: metav1.Duration{Duration: -time.Second}}.Validate())
+	assert.Error(t, Readiness{Timeout: metav1.Duration{Duration: MaxMountTimeout + time.Second}}.Validate())
+	assert.ErrorContains(t, Readiness{Probe: "write"}.Validate(), `unknown mount probe "write"`)
+}
@@ -0,0 +1,142 @@
+// Package mountpolicy holds the administrator policy on the mount options users may set, enforced by the node
+// server on the mountOptions of the secrets and by cos-csi-mounter on the arguments of the mount requests.
+package mountpolicy
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Policy is a list of rules, an option is accepted if no rule applying to the mounter and namespace rejects it
+type Policy struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rule constrains the options of the mounters and namespaces it applies to
+type Rule struct {
+	// Mounters the rule applies to, all mounters if empty
+	Mounters []string `json:"mounters,omitempty"`
+	// Namespaces the rule applies to, all namespaces if empty. Rules with namespaces only apply where the
+	// namespace of the volume is known, i.e. in the node server.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Allow lists the only options that may be set, any option may be set if empty
+	Allow []string `json:"allow,omitempty"`
+	// Deny lists the options that may not be set
+	Deny []string `json:"deny,omitempty"`
+	// Values constrains the value of options by a regular expression that must match the whole value
+	Values map[string]string `json:"values,omitempty"`
+
+	values map[string]*regexp.Regexp
+}
+
+// Violation is the rejection of an option by the policy
+type Violation struct {
+	Option string
+	Reason string
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("mount option %q is not allowed: %s", v.Option, v.Reason)
+}
+
+// Load reads a policy from a YAML file
+func Load(file string) (*Policy, error) {
+	data, err := os.ReadFile(file) // #nosec G304: file given by the administrator
+	if err != nil {
+		return nil, err
+	}
+	policy := &Policy{}
+	if err = yaml.UnmarshalStrict(data, policy); err != nil {
+		return nil, fmt.Errorf("invalid mount option policy %s: %v", file, err)
+	}
+	if err = policy.Compile(); err != nil {
+		return nil, fmt.Errorf("invalid mount option policy %s: %v", file, err)
+	}
+	return policy, nil
+}
+
+// Compile validates the value constraints of the rules, it must be called before Check on a policy that is not
+// loaded with Load
+func (p *Policy) Compile() error {
+	if p == nil {
+		return nil
+	}
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		rule.values = make(map[string]*regexp.Regexp, len(rule.Values))
+		for option, expr := range rule.Values {
+			re, err := regexp.Compile("^(?:" + expr + ")$")
+			if err != nil {
+				return fmt.Errorf("rule %d: invalid value constraint of %q: %v", i, option, err)
+			}
+			rule.values[optionName(option)] = re
+		}
+	}
+	return nil
+}
+
+// Check returns a *Violation naming the first option of options that the policy rejects for mounter in
+// namespace. An empty namespace only matches the rules that apply to all namespaces. A nil policy accepts all
+// options.
+func (p *Policy) Check(mounter, namespace string, options map[string]string) error {
+	if p == nil {
+		return nil
+	}
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, rule := range p.Rules {
+		if !rule.appliesTo(mounter, namespace) {
+			continue
+		}
+		for _, name := range names {
+			option := optionName(name)
+			if slices.ContainsFunc(rule.Deny, func(o string) bool { return optionName(o) == option }) {
+				return &Violation{Option: option, Reason: "denied by the administrator"}
+			}
+			if len(rule.Allow) > 0 && !slices.ContainsFunc(rule.Allow, func(o string) bool { return optionName(o) == option }) {
+				return &Violation{Option: option, Reason: "not in the options allowed by the administrator"}
+			}
+			if re, ok := rule.values[option]; ok && !re.MatchString(options[name]) {
+				return &Violation{Option: option, Reason: fmt.Sprintf("value %q does not match %q", options[name], rule.Values[option])}
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Rule) appliesTo(mounter, namespace string) bool {
+	if len(r.Mounters) > 0 && !slices.Contains(r.Mounters, mounter) {
+		return false
+	}
+	return len(r.Namespaces) == 0 || (namespace != "" && slices.Contains(r.Namespaces, namespace))
+}
+
+// ParseOptions parses mount options given one per line, as in the mountOptions of a secret. Lines may hold
+// several comma separated options, s3fs passes them on as is. Options without value map to an empty value.
+func ParseOptions(data string) map[string]string {
+	options := map[string]string{}
+	for _, line := range strings.Split(data, "\n") {
+		for _, opt := range strings.Split(line, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(opt), "=")
+			if name = optionName(name); name != "" {
+				options[name] = strings.TrimSpace(value)
+			}
+		}
+	}
+	return options
+}
+
+// optionName returns the name of option without the dashes of command line flags, so that rules match the
+// options of all mounters
+func optionName(option string) string {
+	return strings.TrimLeft(strings.TrimSpace(option), "-")
+}
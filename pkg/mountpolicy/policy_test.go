@@ -0,0 +1,96 @@
+package mountpolicy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testPolicy(t *testing.T) *Policy {
+	policy := &Policy{Rules: []Rule{
+		{Deny: []string{"passwd_file", "url"}},
+		{Mounters: []string{"s3fs"}, Deny: []string{"allow_other"}, Values: map[string]string{"retries": `[0-9]+`}},
+		{Mounters: []string{"rclone"}, Namespaces: []string{"team-a"}, Allow: []string{"--vfs-cache-mode", "dir-perms"}},
+	}}
+	assert.NoError(t, policy.Compile())
+	return policy
+}
+
+func TestCheck(t *testing.T) {
+	policy := testPolicy(t)
+	testCases := []struct {
+		name      string
+		mounter   string
+		namespace string
+		options   map[string]string
+		violation string
+	}{
+		{name: "no options", mounter: "s3fs"},
+		{name: "denied for all mounters", mounter: "rclone", options: map[string]string{"url": "https://evil"}, violation: "url"},
+		{name: "denied for the mounter", mounter: "s3fs", options: map[string]string{"allow_other": ""}, violation: "allow_other"},
+		{name: "other mounter", mounter: "rclone", options: map[string]string{"allow_other": ""}},
+		{name: "value matches", mounter: "s3fs", options: map[string]string{"retries": "5"}},
+		{name: "value does not match", mounter: "s3fs", options: map[string]string{"retries": "5;rm"}, violation: "retries"},
+		{name: "allowed in namespace", mounter: "rclone", namespace: "team-a", options: map[string]string{"vfs-cache-mode": "full"}},
+		{name: "not allowed in namespace", mounter: "rclone", namespace: "team-a", options: map[string]string{"--transfers": "8"}, violation: "transfers"},
+		{name: "namespace rule skipped", mounter: "rclone", namespace: "team-b", options: map[string]string{"transfers": "8"}},
+		{name: "unknown namespace", mounter: "rclone", options: map[string]string{"transfers": "8"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := policy.Check(tc.mounter, tc.namespace, tc.options)
+			if tc.violation == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var violation *Violation
+			assert.True(t, errors.As(err, &violation))
+			assert.Equal(t, tc.violation, violation.Option)
+			assert.Contains(t, err.Error(), tc.violation)
+		})
+	}
+
+	var nilPolicy *Policy
+	assert.NoError(t, nilPolicy.Check("s3fs", "", map[string]string{"passwd_file": "/etc/shadow"}))
+}
+
+func TestParseOptions(t *testing.T) {
+	options := ParseOptions("retries=5\n\n  multireq_max = 10 \nnotsup_compat_dir,x=1,passwd_file=/etc/shadow\n--vfs-cache-mode=full")
+	assert.Equal(t, map[string]string{
+		"retries":           "5",
+		"multireq_max":      "10",
+		"notsup_compat_dir": "",
+		"x":                 "1",
+		"passwd_file":       "/etc/shadow",
+		"vfs-cache-mode":    "full",
+	}, options)
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		file := filepath.Join(dir, "policy.yaml")
+		assert.NoError(t, os.WriteFile(file, []byte(content), 0600))
+		return file
+	}
+
+	policy, err := Load(write(`
+rules:
+- mounters: [s3fs]
+  deny: [passwd_file]
+  values:
+    retries: "[0-9]+"
+`))
+	assert.NoError(t, err)
+	assert.Error(t, policy.Check("s3fs", "", map[string]string{"retries": "many"}))
+
+	_, err = Load(write("rules:\n- values:\n    retries: \"[\"\n"))
+	assert.Error(t, err)
+	_, err = Load(write("rules:\n- denied: [url]\n"))
+	assert.Error(t, err)
+	_, err = Load(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,71 @@
+package nativefs
+
+import (
+	"sync"
+	"time"
+)
+
+type entryKind int
+
+const (
+	kindNotFound entryKind = iota
+	kindFile
+	kindDir
+)
+
+// metaEntry is what is known about a key: whether it is a file, a directory or missing
+type metaEntry struct {
+	kind    entryKind
+	size    int64
+	mtime   time.Time
+	expires time.Time
+}
+
+// metaCache caches stat results for ttl, including negative ones, to avoid a HEAD/LIST per lookup
+type metaCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]metaEntry
+	now     func() time.Time
+}
+
+func newMetaCache(ttl time.Duration) *metaCache {
+	return &metaCache{
+		ttl:     ttl,
+		entries: map[string]metaEntry{},
+		now:     time.Now,
+	}
+}
+
+func (c *metaCache) get(key string) (metaEntry, bool) {
+	if c.ttl <= 0 {
+		return metaEntry{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return metaEntry{}, false
+	}
+	if c.now().After(e.expires) {
+		delete(c.entries, key)
+		return metaEntry{}, false
+	}
+	return e, true
+}
+
+func (c *metaCache) put(key string, e metaEntry) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e.expires = c.now().Add(c.ttl)
+	c.entries[key] = e
+}
+
+func (c *metaCache) invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
@@ -0,0 +1,230 @@
+// Package nativefs implements a FUSE filesystem serving a COS bucket through the driver's s3client.
+// It targets read-mostly workloads: reads are streamed with ranged GETs, writes are buffered in a
+// local file and uploaded (multipart for large files) when the file is flushed.
+package nativefs
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
+	"github.com/IBM/ibm-object-csi-driver/pkg/s3client"
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// FsName is the filesystem type the native mounter registers, the mount table shows "fuse.<FsName>"
+	FsName = constants.NativeFsName
+
+	DefaultPartSize    = 64 * 1024 * 1024
+	DefaultReadAhead   = 8 * 1024 * 1024
+	DefaultMetadataTTL = 10 * time.Second
+
+	// MinPartSize is the smallest part size accepted by COS for multipart uploads
+	MinPartSize = 5 * 1024 * 1024
+)
+
+// Options configure a native filesystem
+type Options struct {
+	Bucket string
+	// Prefix restricts the filesystem to the objects under it, e.g. "dir/subdir"
+	Prefix   string
+	UID      uint32
+	GID      uint32
+	DirMode  uint32
+	FileMode uint32
+	ReadOnly bool
+	// MetadataTTL is how long object metadata and directory entries are cached. Zero disables caching.
+	MetadataTTL time.Duration
+	// PartSize is the size of multipart upload parts, files up to this size are uploaded with a single PUT
+	PartSize int64
+	// ReadAhead is the size of the ranged GET issued when a read needs a new stream
+	ReadAhead int64
+	// TempDir holds the files buffering writes until they are uploaded
+	TempDir string
+}
+
+// FS is a native filesystem instance, serving one bucket (prefix)
+type FS struct {
+	client  s3client.ObjectClient
+	opts    Options
+	cache   *metaCache
+	metrics *Metrics
+}
+
+// New creates a native filesystem on top of client
+func New(client s3client.ObjectClient, opts Options, metrics *Metrics) *FS {
+	if opts.PartSize <= 0 {
+		opts.PartSize = DefaultPartSize
+	}
+	if opts.ReadAhead <= 0 {
+		opts.ReadAhead = DefaultReadAhead
+	}
+	if opts.DirMode == 0 {
+		opts.DirMode = 0755
+	}
+	if opts.FileMode == 0 {
+		opts.FileMode = 0644
+	}
+	if metrics == nil {
+		metrics = NewMetrics(nil, nil)
+	}
+	opts.Prefix = strings.Trim(opts.Prefix, "/")
+	if opts.Prefix != "" {
+		opts.Prefix += "/"
+	}
+	return &FS{
+		client:  client,
+		opts:    opts,
+		cache:   newMetaCache(opts.MetadataTTL),
+		metrics: metrics,
+	}
+}
+
+// Root returns the root directory node of the filesystem
+func (f *FS) Root() fs.InodeEmbedder {
+	return &dirNode{fsys: f, key: f.opts.Prefix}
+}
+
+// MountOptions are the FUSE level settings of a mount
+type MountOptions struct {
+	AllowOther bool
+	Debug      bool
+}
+
+// Mount mounts the filesystem on dir and starts serving it. The returned server is unmounted
+// when the mountpoint is unmounted, callers typically block on server.Wait().
+func (f *FS) Mount(dir string, mo MountOptions) (*fuse.Server, error) {
+	ttl := f.opts.MetadataTTL
+	options := []string{}
+	if f.opts.ReadOnly {
+		options = append(options, "ro")
+	}
+	return fs.Mount(dir, f.Root(), &fs.Options{
+		EntryTimeout:    &ttl,
+		AttrTimeout:     &ttl,
+		NegativeTimeout: &ttl,
+		UID:             f.opts.UID,
+		GID:             f.opts.GID,
+		MountOptions: fuse.MountOptions{
+			AllowOther:  mo.AllowOther,
+			Debug:       mo.Debug,
+			FsName:      FsName,
+			Name:        FsName,
+			DirectMount: true,
+			MaxWrite:    fuse.MAX_KERNEL_WRITE,
+			Options:     options,
+		},
+	})
+}
+
+// stat finds out whether key is a file, a directory (some object exists under key/) or missing
+func (f *FS) stat(key string) (metaEntry, syscall.Errno) {
+	if e, ok := f.cache.get(key); ok {
+		f.metrics.cacheHits.Inc()
+		return e, 0
+	}
+	f.metrics.cacheMisses.Inc()
+
+	info, err := f.client.HeadObject(f.opts.Bucket, key)
+	if err == nil {
+		e := metaEntry{kind: kindFile, size: info.Size, mtime: info.LastModified}
+		f.cache.put(key, e)
+		return e, 0
+	}
+	if !errors.Is(err, s3client.ErrObjectNotFound) {
+		klog.Errorf("nativefs: cannot stat %s: %v", key, err)
+		return metaEntry{}, syscall.EIO
+	}
+
+	listing, err := f.client.ListObjects(f.opts.Bucket, key+"/", "/", "", 1)
+	if err != nil {
+		klog.Errorf("nativefs: cannot list %s/: %v", key, err)
+		return metaEntry{}, syscall.EIO
+	}
+	e := metaEntry{kind: kindNotFound}
+	if len(listing.Objects) > 0 || len(listing.CommonPrefixes) > 0 {
+		e.kind = kindDir
+	}
+	f.cache.put(key, e)
+	return e, 0
+}
+
+// readDir lists the direct children of the directory with key dirKey ("" or ending with "/")
+func (f *FS) readDir(dirKey string) ([]fuse.DirEntry, syscall.Errno) {
+	var (
+		entries []fuse.DirEntry
+		token   string
+	)
+	for {
+		listing, err := f.client.ListObjects(f.opts.Bucket, dirKey, "/", token, 0)
+		if err != nil {
+			klog.Errorf("nativefs: cannot list %s: %v", dirKey, err)
+			return nil, syscall.EIO
+		}
+		for _, obj := range listing.Objects {
+			name := strings.TrimPrefix(obj.Key, dirKey)
+			if name == "" {
+				// directory marker object
+				continue
+			}
+			f.cache.put(obj.Key, metaEntry{kind: kindFile, size: obj.Size, mtime: obj.LastModified})
+			entries = append(entries, fuse.DirEntry{Name: name, Mode: syscall.S_IFREG})
+		}
+		for _, p := range listing.CommonPrefixes {
+			name := strings.TrimSuffix(strings.TrimPrefix(p, dirKey), "/")
+			if name == "" {
+				continue
+			}
+			f.cache.put(strings.TrimSuffix(p, "/"), metaEntry{kind: kindDir})
+			entries = append(entries, fuse.DirEntry{Name: name, Mode: syscall.S_IFDIR})
+		}
+		if !listing.IsTruncated || listing.NextContinuationToken == "" {
+			return entries, 0
+		}
+		token = listing.NextContinuationToken
+	}
+}
+
+// isEmptyDir reports whether nothing but the directory marker exists under dirKey
+func (f *FS) isEmptyDir(dirKey string) (bool, syscall.Errno) {
+	listing, err := f.client.ListObjects(f.opts.Bucket, dirKey, "/", "", 2)
+	if err != nil {
+		klog.Errorf("nativefs: cannot list %s: %v", dirKey, err)
+		return false, syscall.EIO
+	}
+	if len(listing.CommonPrefixes) > 0 {
+		return false, 0
+	}
+	for _, obj := range listing.Objects {
+		if obj.Key != dirKey {
+			return false, 0
+		}
+	}
+	return true, 0
+}
+
+func (f *FS) setDirAttr(out *fuse.Attr) {
+	out.Mode = syscall.S_IFDIR | f.opts.DirMode
+	out.Uid = f.opts.UID
+	out.Gid = f.opts.GID
+}
+
+func (f *FS) setFileAttr(out *fuse.Attr, size int64, mtime time.Time) {
+	out.Mode = syscall.S_IFREG | f.opts.FileMode
+	out.Uid = f.opts.UID
+	out.Gid = f.opts.GID
+	out.Size = uint64(size) // #nosec G115: sizes are never negative
+	out.Blocks = (out.Size + 511) / 512
+	out.SetTimes(nil, &mtime, &mtime)
+}
+
+// newTempFile creates the local file buffering writes to an object
+func (f *FS) newTempFile() (*os.File, error) {
+	return os.CreateTemp(f.opts.TempDir, "cos-native-")
+}
@@ -0,0 +1,324 @@
+package nativefs
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/IBM/ibm-object-csi-driver/pkg/s3client"
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+var testObjects = map[string]string{
+	"prefix/file.txt":        "hello world",
+	"prefix/dir/nested.txt":  "nested",
+	"prefix/empty/":          "",
+	"prefix/empty-dir-file/": "",
+	"other/file.txt":         "outside of prefix",
+}
+
+func newTestFS(t *testing.T, opts Options) (*FS, *dirNode, *s3client.FakeObjectClient) {
+	client := s3client.NewFakeObjectClient(testObjects)
+	opts.Bucket = "test-bucket"
+	opts.TempDir = t.TempDir()
+	if opts.Prefix == "" {
+		opts.Prefix = "/prefix/"
+	}
+	fsys := New(client, opts, NewMetrics(prometheus.NewRegistry(), nil))
+	root := fsys.Root().(*dirNode)
+	fs.NewNodeFS(root, &fs.Options{})
+	return fsys, root, client
+}
+
+func readAll(t *testing.T, h fs.FileReader, size int) []byte {
+	buf := make([]byte, size)
+	res, errno := h.Read(context.Background(), buf, 0)
+	assert.Equal(t, syscall.Errno(0), errno)
+	data, _ := res.Bytes(buf)
+	return data
+}
+
+func TestNew_Defaults(t *testing.T) {
+	fsys := New(s3client.NewFakeObjectClient(nil), Options{Prefix: "/a/b/"}, nil)
+	assert.Equal(t, "a/b/", fsys.opts.Prefix)
+	assert.Equal(t, int64(DefaultPartSize), fsys.opts.PartSize)
+	assert.Equal(t, int64(DefaultReadAhead), fsys.opts.ReadAhead)
+	assert.Equal(t, uint32(0755), fsys.opts.DirMode)
+	assert.Equal(t, uint32(0644), fsys.opts.FileMode)
+
+	fsys = New(s3client.NewFakeObjectClient(nil), Options{}, nil)
+	assert.Equal(t, "", fsys.opts.Prefix)
+}
+
+func TestLookup(t *testing.T) {
+	_, root, _ := newTestFS(t, Options{UID: 1000, GID: 2000})
+	ctx := context.Background()
+
+	var out fuse.EntryOut
+	node, errno := root.Lookup(ctx, "file.txt", &out)
+	assert.Equal(t, syscall.Errno(0), errno)
+	assert.NotNil(t, node)
+	assert.Equal(t, uint64(11), out.Size)
+	assert.Equal(t, uint32(syscall.S_IFREG|0644), out.Mode)
+	assert.Equal(t, uint32(1000), out.Uid)
+	assert.Equal(t, uint32(2000), out.Gid)
+
+	node, errno = root.Lookup(ctx, "dir", &out)
+	assert.Equal(t, syscall.Errno(0), errno)
+	assert.True(t, node.IsDir())
+
+	// a directory only made of its marker object
+	node, errno = root.Lookup(ctx, "empty", &out)
+	assert.Equal(t, syscall.Errno(0), errno)
+	assert.True(t, node.IsDir())
+
+	_, errno = root.Lookup(ctx, "missing", &out)
+	assert.Equal(t, syscall.ENOENT, errno)
+}
+
+func TestLookup_Error(t *testing.T) {
+	_, root, client := newTestFS(t, Options{})
+	client.FailHead = true
+
+	_, errno := root.Lookup(context.Background(), "file.txt", &fuse.EntryOut{})
+	assert.Equal(t, syscall.EIO, errno)
+}
+
+func TestStat_MetadataCache(t *testing.T) {
+	fsys, _, client := newTestFS(t, Options{MetadataTTL: time.Minute})
+	now := time.Now()
+	fsys.cache.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		e, errno := fsys.stat("prefix/file.txt")
+		assert.Equal(t, syscall.Errno(0), errno)
+		assert.Equal(t, kindFile, e.kind)
+	}
+	assert.Equal(t, 1, client.Calls["HeadObject"])
+
+	// negative entries are cached as well
+	for i := 0; i < 2; i++ {
+		e, _ := fsys.stat("prefix/missing")
+		assert.Equal(t, kindNotFound, e.kind)
+	}
+	assert.Equal(t, 2, client.Calls["HeadObject"])
+
+	now = now.Add(2 * time.Minute)
+	_, _ = fsys.stat("prefix/file.txt")
+	assert.Equal(t, 3, client.Calls["HeadObject"])
+
+	assert.Equal(t, float64(3), testutil.ToFloat64(fsys.metrics.cacheHits))
+	assert.Equal(t, float64(3), testutil.ToFloat64(fsys.metrics.cacheMisses))
+}
+
+func TestStat_CacheDisabled(t *testing.T) {
+	fsys, _, client := newTestFS(t, Options{})
+
+	_, _ = fsys.stat("prefix/file.txt")
+	_, _ = fsys.stat("prefix/file.txt")
+	assert.Equal(t, 2, client.Calls["HeadObject"])
+}
+
+func TestReaddir(t *testing.T) {
+	_, root, _ := newTestFS(t, Options{})
+
+	stream, errno := root.Readdir(context.Background())
+	assert.Equal(t, syscall.Errno(0), errno)
+
+	entries := map[string]uint32{}
+	for stream.HasNext() {
+		e, errno := stream.Next()
+		assert.Equal(t, syscall.Errno(0), errno)
+		entries[e.Name] = e.Mode
+	}
+	assert.Equal(t, map[string]uint32{
+		"file.txt":       syscall.S_IFREG,
+		"dir":            syscall.S_IFDIR,
+		"empty":          syscall.S_IFDIR,
+		"empty-dir-file": syscall.S_IFDIR,
+	}, entries)
+}
+
+func TestReaddir_Error(t *testing.T) {
+	_, root, client := newTestFS(t, Options{})
+	client.FailList = true
+
+	_, errno := root.Readdir(context.Background())
+	assert.Equal(t, syscall.EIO, errno)
+}
+
+func TestRead_Streaming(t *testing.T) {
+	_, root, client := newTestFS(t, Options{ReadAhead: 4})
+	ctx := context.Background()
+
+	node, _ := root.Lookup(ctx, "file.txt", &fuse.EntryOut{})
+	fh, _, errno := node.Operations().(*fileNode).Open(ctx, syscall.O_RDONLY)
+	assert.Equal(t, syscall.Errno(0), errno)
+	h := fh.(*readHandle)
+
+	assert.Equal(t, []byte("hello world"), readAll(t, h, 64))
+	// 11 bytes read in chunks of 4
+	assert.Equal(t, 3, client.Calls["GetObjectRange"])
+
+	buf := make([]byte, 5)
+	res, errno := h.Read(ctx, buf, 6)
+	assert.Equal(t, syscall.Errno(0), errno)
+	data, _ := res.Bytes(buf)
+	assert.Equal(t, []byte("world"), data)
+
+	res, errno = h.Read(ctx, buf, 100)
+	assert.Equal(t, syscall.Errno(0), errno)
+	assert.Equal(t, 0, res.Size())
+
+	assert.Equal(t, syscall.Errno(0), h.Release(ctx))
+	assert.Nil(t, h.body)
+}
+
+func TestRead_Error(t *testing.T) {
+	fsys, _, client := newTestFS(t, Options{})
+	client.FailGet = true
+
+	h := &readHandle{fsys: fsys, key: "prefix/file.txt", size: 11}
+	_, errno := h.Read(context.Background(), make([]byte, 4), 0)
+	assert.Equal(t, syscall.EIO, errno)
+	assert.Equal(t, float64(1), testutil.ToFloat64(fsys.metrics.operations.WithLabelValues("read", "error")))
+}
+
+func TestCreate_UploadOnFlush(t *testing.T) {
+	fsys, root, client := newTestFS(t, Options{PartSize: MinPartSize})
+	ctx := context.Background()
+
+	node, fh, _, errno := root.Create(ctx, "new.txt", syscall.O_WRONLY|syscall.O_CREAT, 0644, &fuse.EntryOut{})
+	assert.Equal(t, syscall.Errno(0), errno)
+	assert.NotNil(t, node)
+	h := fh.(*writeHandle)
+
+	n, errno := h.Write(ctx, []byte("hello"), 0)
+	assert.Equal(t, syscall.Errno(0), errno)
+	assert.Equal(t, uint32(5), n)
+	_, _ = h.Write(ctx, []byte(" native"), 5)
+
+	// nothing is uploaded before the file is closed
+	assert.NotContains(t, client.Objects, "prefix/new.txt")
+
+	assert.Equal(t, syscall.Errno(0), h.Flush(ctx))
+	assert.Equal(t, "hello native", string(client.Objects["prefix/new.txt"]))
+	assert.Equal(t, []int64{MinPartSize}, client.Uploads)
+
+	// a second flush without new writes does not upload again
+	assert.Equal(t, syscall.Errno(0), h.Flush(ctx))
+	assert.Equal(t, 1, client.Calls["UploadObject"])
+	assert.Equal(t, syscall.Errno(0), h.Release(ctx))
+
+	var out fuse.AttrOut
+	assert.Equal(t, syscall.Errno(0), node.Operations().(*fileNode).Getattr(ctx, nil, &out))
+	assert.Equal(t, uint64(12), out.Size)
+	assert.Equal(t, float64(12), testutil.ToFloat64(fsys.metrics.bytesWritten))
+}
+
+func TestCreate_EmptyFile(t *testing.T) {
+	_, root, client := newTestFS(t, Options{})
+	ctx := context.Background()
+
+	_, fh, _, errno := root.Create(ctx, "empty.txt", syscall.O_WRONLY|syscall.O_CREAT, 0644, &fuse.EntryOut{})
+	assert.Equal(t, syscall.Errno(0), errno)
+	assert.Equal(t, syscall.Errno(0), fh.(*writeHandle).Flush(ctx))
+
+	data, ok := client.Objects["prefix/empty.txt"]
+	assert.True(t, ok)
+	assert.Empty(t, data)
+}
+
+func TestOpen_WriteExisting(t *testing.T) {
+	_, root, client := newTestFS(t, Options{})
+	ctx := context.Background()
+
+	node, _ := root.Lookup(ctx, "file.txt", &fuse.EntryOut{})
+	file := node.Operations().(*fileNode)
+
+	// without O_TRUNC the current content is kept
+	fh, flags, errno := file.Open(ctx, syscall.O_WRONLY)
+	assert.Equal(t, syscall.Errno(0), errno)
+	assert.Equal(t, uint32(fuse.FOPEN_DIRECT_IO), flags)
+	h := fh.(*writeHandle)
+	_, _ = h.Write(ctx, []byte("HELLO"), 0)
+	assert.Equal(t, syscall.Errno(0), h.Flush(ctx))
+	assert.Equal(t, "HELLO world", string(client.Objects["prefix/file.txt"]))
+
+	fh, _, errno = file.Open(ctx, syscall.O_WRONLY|syscall.O_TRUNC)
+	assert.Equal(t, syscall.Errno(0), errno)
+	h = fh.(*writeHandle)
+	_, _ = h.Write(ctx, []byte("new"), 0)
+	assert.Equal(t, syscall.Errno(0), h.Flush(ctx))
+	assert.Equal(t, "new", string(client.Objects["prefix/file.txt"]))
+}
+
+func TestUpload_Error(t *testing.T) {
+	_, root, client := newTestFS(t, Options{})
+	ctx := context.Background()
+	client.FailUpload = true
+
+	_, fh, _, _ := root.Create(ctx, "new.txt", syscall.O_WRONLY|syscall.O_CREAT, 0644, &fuse.EntryOut{})
+	assert.Equal(t, syscall.EIO, fh.(*writeHandle).Flush(ctx))
+}
+
+func TestReadOnly(t *testing.T) {
+	_, root, _ := newTestFS(t, Options{ReadOnly: true})
+	ctx := context.Background()
+
+	_, _, _, errno := root.Create(ctx, "new.txt", syscall.O_WRONLY|syscall.O_CREAT, 0644, &fuse.EntryOut{})
+	assert.Equal(t, syscall.EROFS, errno)
+	_, errno = root.Mkdir(ctx, "newdir", 0755, &fuse.EntryOut{})
+	assert.Equal(t, syscall.EROFS, errno)
+	assert.Equal(t, syscall.EROFS, root.Unlink(ctx, "file.txt"))
+	assert.Equal(t, syscall.EROFS, root.Rmdir(ctx, "empty"))
+
+	node, _ := root.Lookup(ctx, "file.txt", &fuse.EntryOut{})
+	_, _, errno = node.Operations().(*fileNode).Open(ctx, syscall.O_RDWR)
+	assert.Equal(t, syscall.EROFS, errno)
+}
+
+func TestMkdirRmdirUnlink(t *testing.T) {
+	_, root, client := newTestFS(t, Options{})
+	ctx := context.Background()
+
+	node, errno := root.Mkdir(ctx, "newdir", 0755, &fuse.EntryOut{})
+	assert.Equal(t, syscall.Errno(0), errno)
+	assert.True(t, node.IsDir())
+	assert.Contains(t, client.Objects, "prefix/newdir/")
+
+	assert.Equal(t, syscall.ENOTEMPTY, root.Rmdir(ctx, "dir"))
+	assert.Equal(t, syscall.Errno(0), root.Rmdir(ctx, "newdir"))
+	assert.NotContains(t, client.Objects, "prefix/newdir/")
+
+	assert.Equal(t, syscall.Errno(0), root.Unlink(ctx, "file.txt"))
+	assert.NotContains(t, client.Objects, "prefix/file.txt")
+
+	client.FailDelete = true
+	assert.Equal(t, syscall.EIO, root.Unlink(ctx, "dir/nested.txt"))
+}
+
+func TestSetattr_Truncate(t *testing.T) {
+	_, root, client := newTestFS(t, Options{})
+	ctx := context.Background()
+
+	node, _ := root.Lookup(ctx, "file.txt", &fuse.EntryOut{})
+	file := node.Operations().(*fileNode)
+
+	in := &fuse.SetAttrIn{}
+	in.Valid = fuse.FATTR_SIZE
+	in.Size = 5
+	var out fuse.AttrOut
+	// shrinking an object in place is not supported
+	assert.Equal(t, syscall.ENOTSUP, file.Setattr(ctx, nil, in, &out))
+
+	in.Size = 0
+	assert.Equal(t, syscall.Errno(0), file.Setattr(ctx, nil, in, &out))
+	assert.Equal(t, uint64(0), out.Size)
+	assert.Empty(t, client.Objects["prefix/file.txt"])
+}
@@ -0,0 +1,259 @@
+package nativefs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/IBM/ibm-object-csi-driver/pkg/s3client"
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+	"k8s.io/klog/v2"
+)
+
+// readHandle streams an object. Sequential reads are served from one ranged GET of ReadAhead bytes,
+// a new request is only issued when the stream is exhausted or the reader seeks.
+type readHandle struct {
+	fsys *FS
+	key  string
+	size int64
+
+	mu   sync.Mutex
+	body io.ReadCloser
+	pos  int64
+	// got counts the bytes returned by the current body
+	got int64
+}
+
+var (
+	_ fs.FileReader   = (*readHandle)(nil)
+	_ fs.FileReleaser = (*readHandle)(nil)
+)
+
+func (h *readHandle) Read(_ context.Context, dest []byte, off int64) (res fuse.ReadResult, errno syscall.Errno) {
+	defer h.fsys.metrics.observe("read", time.Now(), &errno)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if off >= h.size {
+		return fuse.ReadResultData(nil), 0
+	}
+	want := int64(len(dest))
+	if off+want > h.size {
+		want = h.size - off
+	}
+
+	var read int64
+	for read < want {
+		if h.body == nil || h.pos != off+read {
+			if errno := h.reopen(off + read); errno != 0 {
+				return nil, errno
+			}
+		}
+		n, err := h.body.Read(dest[read:want])
+		read += int64(n)
+		h.pos += int64(n)
+		h.got += int64(n)
+		if errors.Is(err, io.EOF) {
+			empty := h.got == 0
+			h.closeBody()
+			if empty {
+				// the object is shorter than it was when the file was opened
+				break
+			}
+			continue
+		}
+		if err != nil {
+			klog.Errorf("nativefs: cannot read %s at %d: %v", h.key, h.pos, err)
+			h.closeBody()
+			return nil, syscall.EIO
+		}
+	}
+
+	h.fsys.metrics.bytesRead.Add(float64(read))
+	return fuse.ReadResultData(dest[:read]), 0
+}
+
+func (h *readHandle) reopen(off int64) syscall.Errno {
+	h.closeBody()
+	body, err := h.fsys.client.GetObjectRange(h.fsys.opts.Bucket, h.key, off, h.fsys.opts.ReadAhead)
+	if err != nil {
+		if errors.Is(err, s3client.ErrObjectNotFound) {
+			return syscall.ENOENT
+		}
+		klog.Errorf("nativefs: cannot get %s at %d: %v", h.key, off, err)
+		return syscall.EIO
+	}
+	h.body = body
+	h.pos = off
+	h.got = 0
+	return 0
+}
+
+func (h *readHandle) closeBody() {
+	if h.body != nil {
+		_ = h.body.Close() // #nosec G104: nothing to do if closing a response body fails
+		h.body = nil
+	}
+}
+
+func (h *readHandle) Release(_ context.Context) syscall.Errno {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closeBody()
+	return 0
+}
+
+// writeHandle buffers the whole file in a local temporary file and uploads it on flush
+type writeHandle struct {
+	node *fileNode
+
+	mu    sync.Mutex
+	file  *os.File
+	size  int64
+	dirty bool
+}
+
+var (
+	_ fs.FileWriter    = (*writeHandle)(nil)
+	_ fs.FileReader    = (*writeHandle)(nil)
+	_ fs.FileFlusher   = (*writeHandle)(nil)
+	_ fs.FileFsyncer   = (*writeHandle)(nil)
+	_ fs.FileReleaser  = (*writeHandle)(nil)
+	_ fs.FileGetattrer = (*writeHandle)(nil)
+)
+
+// newWriteHandle creates the write buffer of node, downloading the current object first if keepContent is set
+func newWriteHandle(node *fileNode, keepContent bool) (*writeHandle, syscall.Errno) {
+	fsys := node.fsys
+	file, err := fsys.newTempFile()
+	if err != nil {
+		klog.Errorf("nativefs: cannot create write buffer for %s: %v", node.key, err)
+		return nil, syscall.EIO
+	}
+	// the buffer is only reachable through the handle
+	_ = os.Remove(file.Name()) // #nosec G104: a leftover file is removed with the temp dir
+
+	h := &writeHandle{node: node, file: file}
+	if !keepContent {
+		return h, 0
+	}
+
+	body, err := fsys.client.GetObjectRange(fsys.opts.Bucket, node.key, 0, -1)
+	if errors.Is(err, s3client.ErrObjectNotFound) {
+		return h, 0
+	}
+	if err != nil {
+		klog.Errorf("nativefs: cannot download %s for writing: %v", node.key, err)
+		_ = file.Close()
+		return nil, syscall.EIO
+	}
+	defer func() { _ = body.Close() }()
+	n, err := io.Copy(file, body)
+	if err != nil {
+		klog.Errorf("nativefs: cannot download %s for writing: %v", node.key, err)
+		_ = file.Close()
+		return nil, syscall.EIO
+	}
+	h.size = n
+	return h, 0
+}
+
+func (h *writeHandle) Write(_ context.Context, data []byte, off int64) (written uint32, errno syscall.Errno) {
+	defer h.node.fsys.metrics.observe("write", time.Now(), &errno)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n, err := h.file.WriteAt(data, off)
+	if err != nil {
+		klog.Errorf("nativefs: cannot buffer write to %s: %v", h.node.key, err)
+		return 0, syscall.EIO
+	}
+	if end := off + int64(n); end > h.size {
+		h.size = end
+	}
+	h.dirty = true
+	return uint32(n), 0 // #nosec G115: n is bounded by the fuse max write size
+}
+
+func (h *writeHandle) Read(_ context.Context, dest []byte, off int64) (fuse.ReadResult, syscall.Errno) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n, err := h.file.ReadAt(dest, off)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, syscall.EIO
+	}
+	return fuse.ReadResultData(dest[:n]), 0
+}
+
+func (h *writeHandle) Getattr(_ context.Context, out *fuse.AttrOut) syscall.Errno {
+	h.mu.Lock()
+	size := h.size
+	h.mu.Unlock()
+
+	_, mtime := h.node.attr()
+	h.node.fsys.setFileAttr(&out.Attr, size, mtime)
+	return 0
+}
+
+func (h *writeHandle) truncate(size int64) syscall.Errno {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.file.Truncate(size); err != nil {
+		return syscall.EIO
+	}
+	h.size = size
+	h.dirty = true
+	return 0
+}
+
+// Flush is called on every close of the file descriptor, that is when the data is uploaded
+func (h *writeHandle) Flush(_ context.Context) syscall.Errno {
+	return h.upload()
+}
+
+func (h *writeHandle) Fsync(_ context.Context, _ uint32) syscall.Errno {
+	return h.upload()
+}
+
+func (h *writeHandle) upload() (errno syscall.Errno) {
+	fsys := h.node.fsys
+	defer fsys.metrics.observe("upload", time.Now(), &errno)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.dirty {
+		return 0
+	}
+	if err := fsys.client.UploadObject(fsys.opts.Bucket, h.node.key, h.file, h.size, fsys.opts.PartSize); err != nil {
+		klog.Errorf("nativefs: cannot upload %s: %v", h.node.key, err)
+		return syscall.EIO
+	}
+	h.dirty = false
+	fsys.metrics.bytesWritten.Add(float64(h.size))
+
+	now := time.Now()
+	h.node.setAttr(h.size, now)
+	fsys.cache.put(h.node.key, metaEntry{kind: kindFile, size: h.size, mtime: now})
+	return 0
+}
+
+func (h *writeHandle) Release(_ context.Context) syscall.Errno {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.dirty {
+		klog.Warningf("nativefs: discarding changes to %s which could not be uploaded", h.node.key)
+	}
+	_ = h.file.Close() // #nosec G104: the buffer is already unlinked
+	return 0
+}
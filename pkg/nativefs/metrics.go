@@ -0,0 +1,79 @@
+package nativefs
+
+import (
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "cos_native_fuse"
+
+// Metrics holds the Prometheus instrumentation of a native filesystem
+type Metrics struct {
+	operations   *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	bytesRead    prometheus.Counter
+	bytesWritten prometheus.Counter
+	cacheHits    prometheus.Counter
+	cacheMisses  prometheus.Counter
+}
+
+// NewMetrics creates the filesystem metrics and registers them with reg, if reg is not nil.
+// constLabels are attached to every metric, e.g. to tell apart the bucket being served.
+func NewMetrics(reg prometheus.Registerer, constLabels prometheus.Labels) *Metrics {
+	m := &Metrics{
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   metricsNamespace,
+			Name:        "operations_total",
+			Help:        "Number of filesystem operations by operation and result.",
+			ConstLabels: constLabels,
+		}, []string{"operation", "result"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   metricsNamespace,
+			Name:        "operation_duration_seconds",
+			Help:        "Latency of filesystem operations, including the object storage requests they issue.",
+			ConstLabels: constLabels,
+			Buckets:     prometheus.ExponentialBuckets(0.001, 4, 10),
+		}, []string{"operation"}),
+		bytesRead: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   metricsNamespace,
+			Name:        "read_bytes_total",
+			Help:        "Bytes returned to readers.",
+			ConstLabels: constLabels,
+		}),
+		bytesWritten: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   metricsNamespace,
+			Name:        "written_bytes_total",
+			Help:        "Bytes uploaded to object storage.",
+			ConstLabels: constLabels,
+		}),
+		cacheHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   metricsNamespace,
+			Name:        "metadata_cache_hits_total",
+			Help:        "Metadata lookups served from the cache.",
+			ConstLabels: constLabels,
+		}),
+		cacheMisses: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   metricsNamespace,
+			Name:        "metadata_cache_misses_total",
+			Help:        "Metadata lookups sent to object storage.",
+			ConstLabels: constLabels,
+		}),
+	}
+	if reg != nil {
+		reg.MustRegister(m.operations, m.duration, m.bytesRead, m.bytesWritten, m.cacheHits, m.cacheMisses)
+	}
+	return m
+}
+
+// observe records one operation that started at start. It is deferred by the operations,
+// hence errno points to their named result.
+func (m *Metrics) observe(op string, start time.Time, errno *syscall.Errno) {
+	result := "success"
+	if *errno != 0 {
+		result = "error"
+	}
+	m.operations.WithLabelValues(op, result).Inc()
+	m.duration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,239 @@
+package nativefs
+
+import (
+	"context"
+	"io"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hanwen/go-fuse/v2/fs"
+	"github.com/hanwen/go-fuse/v2/fuse"
+	"k8s.io/klog/v2"
+)
+
+// dirNode is a directory: the bucket root (prefix) or a common prefix below it
+type dirNode struct {
+	fs.Inode
+	fsys *FS
+	// key is the object key prefix of the directory, "" or ending with "/"
+	key string
+}
+
+var (
+	_ fs.NodeGetattrer = (*dirNode)(nil)
+	_ fs.NodeLookuper  = (*dirNode)(nil)
+	_ fs.NodeReaddirer = (*dirNode)(nil)
+	_ fs.NodeCreater   = (*dirNode)(nil)
+	_ fs.NodeMkdirer   = (*dirNode)(nil)
+	_ fs.NodeUnlinker  = (*dirNode)(nil)
+	_ fs.NodeRmdirer   = (*dirNode)(nil)
+)
+
+func (d *dirNode) Getattr(_ context.Context, _ fs.FileHandle, out *fuse.AttrOut) syscall.Errno {
+	d.fsys.setDirAttr(&out.Attr)
+	return 0
+}
+
+func (d *dirNode) Lookup(ctx context.Context, name string, out *fuse.EntryOut) (node *fs.Inode, errno syscall.Errno) {
+	defer d.fsys.metrics.observe("lookup", time.Now(), &errno)
+
+	key := d.key + name
+	e, errno := d.fsys.stat(key)
+	if errno != 0 {
+		return nil, errno
+	}
+	switch e.kind {
+	case kindFile:
+		child := &fileNode{fsys: d.fsys, key: key, size: e.size, mtime: e.mtime}
+		d.fsys.setFileAttr(&out.Attr, e.size, e.mtime)
+		return d.NewInode(ctx, child, fs.StableAttr{Mode: syscall.S_IFREG}), 0
+	case kindDir:
+		d.fsys.setDirAttr(&out.Attr)
+		return d.NewInode(ctx, &dirNode{fsys: d.fsys, key: key + "/"}, fs.StableAttr{Mode: syscall.S_IFDIR}), 0
+	default:
+		return nil, syscall.ENOENT
+	}
+}
+
+func (d *dirNode) Readdir(_ context.Context) (stream fs.DirStream, errno syscall.Errno) {
+	defer d.fsys.metrics.observe("readdir", time.Now(), &errno)
+
+	entries, errno := d.fsys.readDir(d.key)
+	if errno != 0 {
+		return nil, errno
+	}
+	return fs.NewListDirStream(entries), 0
+}
+
+func (d *dirNode) Create(ctx context.Context, name string, _ uint32, _ uint32, out *fuse.EntryOut) (node *fs.Inode, fh fs.FileHandle, fuseFlags uint32, errno syscall.Errno) {
+	defer d.fsys.metrics.observe("create", time.Now(), &errno)
+
+	if d.fsys.opts.ReadOnly {
+		return nil, nil, 0, syscall.EROFS
+	}
+	child := &fileNode{fsys: d.fsys, key: d.key + name, mtime: time.Now()}
+	h, errno := newWriteHandle(child, false)
+	if errno != 0 {
+		return nil, nil, 0, errno
+	}
+	// an empty object is created on flush even if nothing is written
+	h.dirty = true
+	d.fsys.cache.invalidate(child.key)
+	d.fsys.setFileAttr(&out.Attr, 0, child.mtime)
+	return d.NewInode(ctx, child, fs.StableAttr{Mode: syscall.S_IFREG}), h, 0, 0
+}
+
+func (d *dirNode) Mkdir(ctx context.Context, name string, _ uint32, out *fuse.EntryOut) (node *fs.Inode, errno syscall.Errno) {
+	defer d.fsys.metrics.observe("mkdir", time.Now(), &errno)
+
+	if d.fsys.opts.ReadOnly {
+		return nil, syscall.EROFS
+	}
+	key := d.key + name
+	// directories only exist through their content, a marker object keeps an empty one visible
+	if err := d.fsys.client.UploadObject(d.fsys.opts.Bucket, key+"/", emptyReader{}, 0, d.fsys.opts.PartSize); err != nil {
+		klog.Errorf("nativefs: cannot create directory marker %s/: %v", key, err)
+		return nil, syscall.EIO
+	}
+	d.fsys.cache.put(key, metaEntry{kind: kindDir})
+	d.fsys.setDirAttr(&out.Attr)
+	return d.NewInode(ctx, &dirNode{fsys: d.fsys, key: key + "/"}, fs.StableAttr{Mode: syscall.S_IFDIR}), 0
+}
+
+func (d *dirNode) Unlink(_ context.Context, name string) (errno syscall.Errno) {
+	defer d.fsys.metrics.observe("unlink", time.Now(), &errno)
+
+	if d.fsys.opts.ReadOnly {
+		return syscall.EROFS
+	}
+	key := d.key + name
+	if err := d.fsys.client.DeleteObject(d.fsys.opts.Bucket, key); err != nil {
+		klog.Errorf("nativefs: cannot delete %s: %v", key, err)
+		return syscall.EIO
+	}
+	d.fsys.cache.invalidate(key)
+	return 0
+}
+
+func (d *dirNode) Rmdir(_ context.Context, name string) (errno syscall.Errno) {
+	defer d.fsys.metrics.observe("rmdir", time.Now(), &errno)
+
+	if d.fsys.opts.ReadOnly {
+		return syscall.EROFS
+	}
+	key := d.key + name
+	empty, errno := d.fsys.isEmptyDir(key + "/")
+	if errno != 0 {
+		return errno
+	}
+	if !empty {
+		return syscall.ENOTEMPTY
+	}
+	if err := d.fsys.client.DeleteObject(d.fsys.opts.Bucket, key+"/"); err != nil {
+		klog.Errorf("nativefs: cannot delete directory marker %s/: %v", key, err)
+		return syscall.EIO
+	}
+	d.fsys.cache.invalidate(key)
+	return 0
+}
+
+// fileNode is an object
+type fileNode struct {
+	fs.Inode
+	fsys *FS
+	key  string
+
+	mu    sync.Mutex
+	size  int64
+	mtime time.Time
+}
+
+var (
+	_ fs.NodeGetattrer = (*fileNode)(nil)
+	_ fs.NodeSetattrer = (*fileNode)(nil)
+	_ fs.NodeOpener    = (*fileNode)(nil)
+)
+
+func (n *fileNode) attr() (int64, time.Time) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.size, n.mtime
+}
+
+func (n *fileNode) setAttr(size int64, mtime time.Time) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.size = size
+	n.mtime = mtime
+}
+
+func (n *fileNode) Getattr(ctx context.Context, f fs.FileHandle, out *fuse.AttrOut) syscall.Errno {
+	if h, ok := f.(*writeHandle); ok {
+		return h.Getattr(ctx, out)
+	}
+	size, mtime := n.attr()
+	n.fsys.setFileAttr(&out.Attr, size, mtime)
+	return 0
+}
+
+// Setattr only supports changing the size of a file opened for writing, other changes are accepted but ignored
+// since object storage has no notion of ownership or permissions.
+func (n *fileNode) Setattr(ctx context.Context, f fs.FileHandle, in *fuse.SetAttrIn, out *fuse.AttrOut) syscall.Errno {
+	if size, ok := in.GetSize(); ok {
+		h, isWrite := f.(*writeHandle)
+		if !isWrite {
+			if size == 0 && !n.fsys.opts.ReadOnly {
+				return n.truncate()
+			}
+			return syscall.ENOTSUP
+		}
+		if errno := h.truncate(int64(size)); errno != 0 { // #nosec G115: size is bounded by the kernel
+			return errno
+		}
+	}
+	return n.Getattr(ctx, f, out)
+}
+
+// truncate replaces the object with an empty one
+func (n *fileNode) truncate() syscall.Errno {
+	if err := n.fsys.client.UploadObject(n.fsys.opts.Bucket, n.key, emptyReader{}, 0, n.fsys.opts.PartSize); err != nil {
+		klog.Errorf("nativefs: cannot truncate %s: %v", n.key, err)
+		return syscall.EIO
+	}
+	now := time.Now()
+	n.setAttr(0, now)
+	n.fsys.cache.put(n.key, metaEntry{kind: kindFile, mtime: now})
+	return 0
+}
+
+func (n *fileNode) Open(_ context.Context, flags uint32) (fh fs.FileHandle, fuseFlags uint32, errno syscall.Errno) {
+	defer n.fsys.metrics.observe("open", time.Now(), &errno)
+
+	if flags&syscall.O_ACCMODE == syscall.O_RDONLY {
+		size, _ := n.attr()
+		return &readHandle{fsys: n.fsys, key: n.key, size: size}, 0, 0
+	}
+	if n.fsys.opts.ReadOnly {
+		return nil, 0, syscall.EROFS
+	}
+	h, errno := newWriteHandle(n, flags&syscall.O_TRUNC == 0)
+	if errno != 0 {
+		return nil, 0, errno
+	}
+	if flags&syscall.O_TRUNC != 0 {
+		h.dirty = true
+	}
+	// the kernel must not serve stale pages of the previous object version
+	return h, fuse.FOPEN_DIRECT_IO, 0
+}
+
+// emptyReader is the body of zero length objects
+type emptyReader struct{}
+
+func (emptyReader) ReadAt(p []byte, _ int64) (int, error) {
+	if len(p) > 0 {
+		return 0, io.EOF
+	}
+	return 0, nil
+}
@@ -0,0 +1,149 @@
+/**
+ * Copyright 2021 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3client
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/IBM/ibm-cos-sdk-go/aws/awserr"
+	"github.com/IBM/ibm-cos-sdk-go/service/s3"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// authErrorCodes are the error codes of COS and of the IAM token retrieval telling that the credentials are wrong
+var authErrorCodes = []string{
+	"InvalidAccessKeyId", "SignatureDoesNotMatch", "InvalidCredentials", "NoCredentialProviders",
+	"IbmApiKeyIdNotFound", "TokenManagerRetrieveError", "ErrFetchingIAMToken",
+}
+
+// AccessError is a failure to access a bucket, it carries the gRPC code of the CSI call it fails
+type AccessError struct {
+	Code    codes.Code
+	Message string
+	Err     error
+}
+
+func (e *AccessError) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %v", e.Message, e.Err)
+}
+
+func (e *AccessError) Unwrap() error {
+	return e.Err
+}
+
+// GRPCStatus makes the CSI calls returning the error fail with its code
+func (e *AccessError) GRPCStatus() *status.Status {
+	return status.New(e.Code, e.Error())
+}
+
+// VerifyBucketAccess checks that the endpoint of client is reachable and that its credentials may list bucket
+// under objectPath. If requirePath is set, objectPath must hold at least one object, as s3fs requires. The
+// failures are returned as *AccessError.
+func VerifyBucketAccess(client ObjectClient, endpoint, bucket, objectPath string, requirePath bool) error {
+	prefix := strings.Trim(objectPath, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	listing, err := client.ListObjects(bucket, prefix, "", "", 1)
+	if err != nil {
+		return ClassifyAccessError(err, endpoint, bucket)
+	}
+	if requirePath && prefix != "" && len(listing.Objects) == 0 {
+		return &AccessError{Code: codes.NotFound,
+			Message: fmt.Sprintf("objectPath %s does not exist in bucket %s, create it or set the objectPath of the secret to an existing prefix", objectPath, bucket)}
+	}
+	return nil
+}
+
+// ClassifyAccessError maps an error of a request to bucket at endpoint to an *AccessError with the gRPC code and
+// a message telling what to check
+func ClassifyAccessError(err error, endpoint, bucket string) *AccessError {
+	code := accessErrorCode(err)
+	var message string
+	switch code {
+	case codes.Unauthenticated:
+		message = fmt.Sprintf("cannot authenticate to %s, check the accessKey and secretKey or the apiKey of the secret and the IAM endpoint", endpoint)
+	case codes.PermissionDenied:
+		message = fmt.Sprintf("the credentials of the secret are not allowed to list bucket %s, grant them at least the Reader role on the bucket", bucket)
+	case codes.NotFound:
+		message = fmt.Sprintf("bucket %s does not exist at %s, check the bucketName of the secret and that the endpoint is the one of its region", bucket, endpoint)
+	case codes.Unavailable:
+		message = fmt.Sprintf("cannot reach %s, check the cosEndpoint of the secret and the network access of the node to it", endpoint)
+	default:
+		message = fmt.Sprintf("cannot access bucket %s at %s", bucket, endpoint)
+	}
+	return &AccessError{Code: code, Message: message, Err: err}
+}
+
+func accessErrorCode(err error) codes.Code {
+	var reqErr awserr.RequestFailure
+	if errors.As(err, &reqErr) {
+		switch {
+		case reqErr.StatusCode() == http.StatusUnauthorized || slices.Contains(authErrorCodes, reqErr.Code()):
+			return codes.Unauthenticated
+		case reqErr.StatusCode() == http.StatusForbidden:
+			return codes.PermissionDenied
+		case reqErr.StatusCode() == http.StatusNotFound:
+			return codes.NotFound
+		case reqErr.StatusCode() >= http.StatusInternalServerError:
+			return codes.Unavailable
+		}
+	}
+	// the IAM token and connection failures are not responses of COS, they are only told apart by the error
+	// they wrap
+	var netErr net.Error
+	if errors.As(err, &netErr) || hasNetError(err) {
+		return codes.Unavailable
+	}
+	var aerr awserr.Error
+	if errors.As(err, &aerr) {
+		switch {
+		case slices.Contains(authErrorCodes, aerr.Code()):
+			return codes.Unauthenticated
+		case aerr.Code() == s3.ErrCodeNoSuchBucket:
+			return codes.NotFound
+		case aerr.Code() == "AccessDenied":
+			return codes.PermissionDenied
+		case aerr.Code() == "RequestError" || aerr.Code() == "RequestCanceled":
+			return codes.Unavailable
+		}
+	}
+	return codes.Internal
+}
+
+// hasNetError tells whether one of the errors wrapped by the awserr.Error err, which do not implement Unwrap, is a
+// network error
+func hasNetError(err error) bool {
+	var aerr awserr.Error
+	for errors.As(err, &aerr) {
+		err = aerr.OrigErr()
+		var netErr net.Error
+		if errors.As(err, &netErr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+/**
+ * Copyright 2021 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3client
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/IBM/ibm-cos-sdk-go/aws/awserr"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClassifyAccessError(t *testing.T) {
+	dnsErr := &url.Error{Op: "Get", URL: "https://s3.test", Err: &net.DNSError{Err: "no such host", Name: "s3.test"}}
+	testCases := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{name: "invalid access key", err: awserr.NewRequestFailure(awserr.New("InvalidAccessKeyId", "", nil), 403, ""), code: codes.Unauthenticated},
+		{name: "signature mismatch", err: awserr.NewRequestFailure(awserr.New("SignatureDoesNotMatch", "", nil), 403, ""), code: codes.Unauthenticated},
+		{name: "unauthorized", err: awserr.NewRequestFailure(awserr.New("Unauthorized", "", nil), 401, ""), code: codes.Unauthenticated},
+		{name: "access denied", err: awserr.NewRequestFailure(awserr.New("AccessDenied", "", nil), 403, ""), code: codes.PermissionDenied},
+		{name: "no such bucket", err: awserr.NewRequestFailure(awserr.New("NoSuchBucket", "", nil), 404, ""), code: codes.NotFound},
+		{name: "server error", err: awserr.NewRequestFailure(awserr.New("ServiceUnavailable", "", nil), 503, ""), code: codes.Unavailable},
+		{name: "bad api key", err: awserr.New("TokenManagerRetrieveError", "error retrieving the token", errors.New("Provided API key could not be found")), code: codes.Unauthenticated},
+		{name: "iam unreachable", err: awserr.New("TokenManagerRetrieveError", "error retrieving the token", awserr.New("ErrFetchingIAMToken", "", dnsErr)), code: codes.Unavailable},
+		{name: "dns failure", err: awserr.New("RequestError", "send request failed", dnsErr), code: codes.Unavailable},
+		{name: "unknown", err: errors.New("boom"), code: codes.Internal},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ClassifyAccessError(tc.err, "https://s3.test", "bucket")
+			assert.Equal(t, tc.code, err.Code)
+			assert.Equal(t, tc.code, status.Code(err))
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestVerifyBucketAccess(t *testing.T) {
+	api := &fakeObjectAPI{objects: map[string]string{"data/file": "x"}}
+	client := &COSObjectClient{svc: api, logger: zap.NewNop()}
+
+	assert.NoError(t, VerifyBucketAccess(client, "https://s3.test", "bucket", "", true))
+	assert.NoError(t, VerifyBucketAccess(client, "https://s3.test", "bucket", "/data", true))
+	assert.NoError(t, VerifyBucketAccess(client, "https://s3.test", "bucket", "missing", false))
+
+	err := VerifyBucketAccess(client, "https://s3.test", "bucket", "missing", true)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Contains(t, err.Error(), "objectPath missing")
+
+	api.errList = awserr.NewRequestFailure(awserr.New("NoSuchBucket", "", nil), 404, "")
+	err = VerifyBucketAccess(client, "https://s3.test", "bucket", "", false)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Contains(t, err.Error(), "bucket bucket does not exist")
+}
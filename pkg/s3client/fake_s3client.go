@@ -1,7 +1,13 @@
 package s3client
 
 import (
+	"bytes"
 	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -64,3 +70,130 @@ func (s *fakeCOSSession) UpdateQuotaLimit(quota int64, apiKey, bucketName, cosEn
 	}
 	return nil
 }
+
+// FakeObjectClient is an in-memory ObjectClient
+type FakeObjectClient struct {
+	mu      sync.Mutex
+	Objects map[string][]byte
+	// Calls counts the calls made per method, e.g. Calls["HeadObject"]
+	Calls map[string]int
+	// Uploads records the partSize used for every UploadObject call
+	Uploads []int64
+
+	FailList   bool
+	FailHead   bool
+	FailGet    bool
+	FailUpload bool
+	FailDelete bool
+}
+
+// NewFakeObjectClient returns a fake holding a copy of objects, keyed by object key
+func NewFakeObjectClient(objects map[string]string) *FakeObjectClient {
+	f := &FakeObjectClient{Objects: map[string][]byte{}, Calls: map[string]int{}}
+	for k, v := range objects {
+		f.Objects[k] = []byte(v)
+	}
+	return f
+}
+
+func (f *FakeObjectClient) ListObjects(bucket, prefix, delimiter, continuationToken string, maxKeys int64) (*ObjectListing, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Calls["ListObjects"]++
+	if f.FailList {
+		return nil, errors.New("failed to list objects")
+	}
+
+	keys := make([]string, 0, len(f.Objects))
+	for k := range f.Objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	listing := &ObjectListing{}
+	seen := map[string]bool{}
+	for _, k := range keys {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		if maxKeys > 0 && int64(len(listing.Objects)+len(listing.CommonPrefixes)) >= maxKeys {
+			listing.IsTruncated = true
+			break
+		}
+		rest := strings.TrimPrefix(k, prefix)
+		if delimiter != "" {
+			if i := strings.Index(rest, delimiter); i >= 0 {
+				cp := prefix + rest[:i+len(delimiter)]
+				if !seen[cp] {
+					seen[cp] = true
+					listing.CommonPrefixes = append(listing.CommonPrefixes, cp)
+				}
+				continue
+			}
+		}
+		listing.Objects = append(listing.Objects, ObjectInfo{Key: k, Size: int64(len(f.Objects[k])), LastModified: time.Unix(0, 0)})
+	}
+	return listing, nil
+}
+
+func (f *FakeObjectClient) HeadObject(bucket, key string) (*ObjectInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Calls["HeadObject"]++
+	if f.FailHead {
+		return nil, errors.New("failed to head object")
+	}
+	data, ok := f.Objects[key]
+	if !ok {
+		return nil, ErrObjectNotFound
+	}
+	return &ObjectInfo{Key: key, Size: int64(len(data)), LastModified: time.Unix(0, 0)}, nil
+}
+
+func (f *FakeObjectClient) GetObjectRange(bucket, key string, offset, length int64) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Calls["GetObjectRange"]++
+	if f.FailGet {
+		return nil, errors.New("failed to get object")
+	}
+	data, ok := f.Objects[key]
+	if !ok {
+		return nil, ErrObjectNotFound
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	end := int64(len(data))
+	if length > 0 && offset+length < end {
+		end = offset + length
+	}
+	return io.NopCloser(bytes.NewReader(data[offset:end])), nil
+}
+
+func (f *FakeObjectClient) UploadObject(bucket, key string, body io.ReaderAt, size, partSize int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Calls["UploadObject"]++
+	if f.FailUpload {
+		return errors.New("failed to upload object")
+	}
+	data := make([]byte, size)
+	if _, err := body.ReadAt(data, 0); err != nil && err != io.EOF {
+		return err
+	}
+	f.Objects[key] = data
+	f.Uploads = append(f.Uploads, partSize)
+	return nil
+}
+
+func (f *FakeObjectClient) DeleteObject(bucket, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Calls["DeleteObject"]++
+	if f.FailDelete {
+		return errors.New("failed to delete object")
+	}
+	delete(f.Objects, key)
+	return nil
+}
@@ -0,0 +1,263 @@
+/**
+ * Copyright 2021 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/IBM/ibm-cos-sdk-go/aws"
+	"github.com/IBM/ibm-cos-sdk-go/aws/awserr"
+	"github.com/IBM/ibm-cos-sdk-go/service/s3"
+	"go.uber.org/zap"
+)
+
+// ErrObjectNotFound is returned when the requested object does not exist
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectInfo holds the metadata of an object
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+	ETag         string
+}
+
+// ObjectListing is one page of a bucket listing
+type ObjectListing struct {
+	Objects []ObjectInfo
+	// CommonPrefixes are the "directories" found when listing with a delimiter
+	CommonPrefixes        []string
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+// ObjectClient is an interface for object level access to a bucket, as needed by a filesystem
+type ObjectClient interface {
+	// ListObjects lists one page of objects under prefix, grouping keys by delimiter if set
+	ListObjects(bucket, prefix, delimiter, continuationToken string, maxKeys int64) (*ObjectListing, error)
+
+	// HeadObject returns the metadata of an object or ErrObjectNotFound
+	HeadObject(bucket, key string) (*ObjectInfo, error)
+
+	// GetObjectRange streams an object starting at offset. A negative length reads until the end of the object.
+	GetObjectRange(bucket, key string, offset, length int64) (io.ReadCloser, error)
+
+	// UploadObject stores size bytes of body under key, using a multipart upload if size exceeds partSize
+	UploadObject(bucket, key string, body io.ReaderAt, size, partSize int64) error
+
+	// DeleteObject removes an object
+	DeleteObject(bucket, key string) error
+}
+
+type objectAPI interface {
+	ListObjectsV2(input *s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error)
+	HeadObject(input *s3.HeadObjectInput) (*s3.HeadObjectOutput, error)
+	GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error)
+	PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error)
+	DeleteObject(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error)
+	CreateMultipartUpload(input *s3.CreateMultipartUploadInput) (*s3.CreateMultipartUploadOutput, error)
+	UploadPart(input *s3.UploadPartInput) (*s3.UploadPartOutput, error)
+	CompleteMultipartUpload(input *s3.CompleteMultipartUploadInput) (*s3.CompleteMultipartUploadOutput, error)
+	AbortMultipartUpload(input *s3.AbortMultipartUploadInput) (*s3.AbortMultipartUploadOutput, error)
+}
+
+// COSObjectClient implements ObjectClient on top of the COS SDK
+type COSObjectClient struct {
+	logger *zap.Logger
+	svc    objectAPI
+}
+
+var _ ObjectClient = &COSObjectClient{}
+
+// NewObjectClient creates a client for object level access using the same credentials as NewObjectStorageSession
+func NewObjectClient(endpoint, locationConstraint string, creds *ObjectStorageCredentials, lgr *zap.Logger) ObjectClient {
+	return &COSObjectClient{
+		svc:    newS3Service(endpoint, locationConstraint, creds),
+		logger: lgr,
+	}
+}
+
+func (c *COSObjectClient) ListObjects(bucket, prefix, delimiter, continuationToken string, maxKeys int64) (*ObjectListing, error) {
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	}
+	if delimiter != "" {
+		input.Delimiter = aws.String(delimiter)
+	}
+	if continuationToken != "" {
+		input.ContinuationToken = aws.String(continuationToken)
+	}
+	if maxKeys > 0 {
+		input.MaxKeys = aws.Int64(maxKeys)
+	}
+
+	resp, err := c.svc.ListObjectsV2(input)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list bucket '%s' with prefix '%s': %w", bucket, prefix, err)
+	}
+
+	listing := &ObjectListing{
+		IsTruncated:           aws.BoolValue(resp.IsTruncated),
+		NextContinuationToken: aws.StringValue(resp.NextContinuationToken),
+	}
+	for _, obj := range resp.Contents {
+		listing.Objects = append(listing.Objects, ObjectInfo{
+			Key:          aws.StringValue(obj.Key),
+			Size:         aws.Int64Value(obj.Size),
+			LastModified: aws.TimeValue(obj.LastModified),
+			ETag:         aws.StringValue(obj.ETag),
+		})
+	}
+	for _, p := range resp.CommonPrefixes {
+		listing.CommonPrefixes = append(listing.CommonPrefixes, aws.StringValue(p.Prefix))
+	}
+	return listing, nil
+}
+
+func (c *COSObjectClient) HeadObject(bucket, key string) (*ObjectInfo, error) {
+	resp, err := c.svc.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("cannot head object %s/%s: %v", bucket, key, err)
+	}
+	return &ObjectInfo{
+		Key:          key,
+		Size:         aws.Int64Value(resp.ContentLength),
+		LastModified: aws.TimeValue(resp.LastModified),
+		ETag:         aws.StringValue(resp.ETag),
+	}, nil
+}
+
+func (c *COSObjectClient) GetObjectRange(bucket, key string, offset, length int64) (io.ReadCloser, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	switch {
+	case length > 0:
+		input.Range = aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	case offset > 0:
+		input.Range = aws.String(fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := c.svc.GetObject(input)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("cannot get object %s/%s: %v", bucket, key, err)
+	}
+	return resp.Body, nil
+}
+
+func (c *COSObjectClient) UploadObject(bucket, key string, body io.ReaderAt, size, partSize int64) error {
+	if partSize <= 0 || size <= partSize {
+		_, err := c.svc.PutObject(&s3.PutObjectInput{
+			Bucket:        aws.String(bucket),
+			Key:           aws.String(key),
+			Body:          io.NewSectionReader(body, 0, size),
+			ContentLength: aws.Int64(size),
+		})
+		if err != nil {
+			return fmt.Errorf("cannot put object %s/%s: %v", bucket, key, err)
+		}
+		return nil
+	}
+
+	mpu, err := c.svc.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create multipart upload for %s/%s: %v", bucket, key, err)
+	}
+
+	var parts []*s3.CompletedPart
+	for partNumber, offset := int64(1), int64(0); offset < size; partNumber, offset = partNumber+1, offset+partSize {
+		length := partSize
+		if offset+length > size {
+			length = size - offset
+		}
+		resp, err := c.svc.UploadPart(&s3.UploadPartInput{
+			Bucket:        aws.String(bucket),
+			Key:           aws.String(key),
+			UploadId:      mpu.UploadId,
+			PartNumber:    aws.Int64(partNumber),
+			Body:          io.NewSectionReader(body, offset, length),
+			ContentLength: aws.Int64(length),
+		})
+		if err != nil {
+			c.abortUpload(bucket, key, mpu.UploadId)
+			return fmt.Errorf("cannot upload part %d of %s/%s: %v", partNumber, bucket, key, err)
+		}
+		parts = append(parts, &s3.CompletedPart{ETag: resp.ETag, PartNumber: aws.Int64(partNumber)})
+	}
+
+	_, err = c.svc.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(bucket),
+		Key:             aws.String(key),
+		UploadId:        mpu.UploadId,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		c.abortUpload(bucket, key, mpu.UploadId)
+		return fmt.Errorf("cannot complete multipart upload of %s/%s: %v", bucket, key, err)
+	}
+	return nil
+}
+
+func (c *COSObjectClient) DeleteObject(bucket, key string) error {
+	_, err := c.svc.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("cannot delete object %s/%s: %v", bucket, key, err)
+	}
+	return nil
+}
+
+func (c *COSObjectClient) abortUpload(bucket, key string, uploadID *string) {
+	_, err := c.svc.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(bucket),
+		Key:      aws.String(key),
+		UploadId: uploadID,
+	})
+	if err != nil {
+		c.logger.Warn("failed to abort multipart upload", zap.String("bucket", bucket), zap.String("key", key), zap.Error(err))
+	}
+}
+
+func isNotFound(err error) bool {
+	if reqErr, ok := err.(awserr.RequestFailure); ok && reqErr.StatusCode() == http.StatusNotFound {
+		return true
+	}
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code() == s3.ErrCodeNoSuchKey || aerr.Code() == "NotFound"
+	}
+	return false
+}
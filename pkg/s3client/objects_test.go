@@ -0,0 +1,220 @@
+/**
+ * Copyright 2021 IBM Corp.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3client
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/IBM/ibm-cos-sdk-go/aws"
+	"github.com/IBM/ibm-cos-sdk-go/aws/awserr"
+	"github.com/IBM/ibm-cos-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type fakeObjectAPI struct {
+	objects  map[string]string
+	getRange string
+	parts    []string
+	aborted  bool
+
+	errList     error
+	errGet      error
+	errPut      error
+	errDelete   error
+	errPart     error
+	errComplete error
+}
+
+func (a *fakeObjectAPI) ListObjectsV2(input *s3.ListObjectsV2Input) (*s3.ListObjectsV2Output, error) {
+	if a.errList != nil {
+		return nil, a.errList
+	}
+	out := &s3.ListObjectsV2Output{IsTruncated: aws.Bool(true), NextContinuationToken: aws.String("next")}
+	for key, data := range a.objects {
+		if strings.HasPrefix(key, aws.StringValue(input.Prefix)) {
+			out.Contents = append(out.Contents, &s3.Object{Key: aws.String(key), Size: aws.Int64(int64(len(data)))})
+		}
+	}
+	out.CommonPrefixes = []*s3.CommonPrefix{{Prefix: aws.String("dir/")}}
+	return out, nil
+}
+
+func (a *fakeObjectAPI) HeadObject(input *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+	data, ok := a.objects[aws.StringValue(input.Key)]
+	if !ok {
+		return nil, awserr.New("NotFound", "Not Found", nil)
+	}
+	return &s3.HeadObjectOutput{ContentLength: aws.Int64(int64(len(data))), ETag: aws.String("etag")}, nil
+}
+
+func (a *fakeObjectAPI) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	if a.errGet != nil {
+		return nil, a.errGet
+	}
+	a.getRange = aws.StringValue(input.Range)
+	return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(a.objects[aws.StringValue(input.Key)]))}, nil
+}
+
+func (a *fakeObjectAPI) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	if a.errPut != nil {
+		return nil, a.errPut
+	}
+	data, _ := io.ReadAll(input.Body)
+	a.objects[aws.StringValue(input.Key)] = string(data)
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (a *fakeObjectAPI) DeleteObject(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
+	if a.errDelete != nil {
+		return nil, a.errDelete
+	}
+	delete(a.objects, aws.StringValue(input.Key))
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func (a *fakeObjectAPI) CreateMultipartUpload(_ *s3.CreateMultipartUploadInput) (*s3.CreateMultipartUploadOutput, error) {
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String("upload-id")}, nil
+}
+
+func (a *fakeObjectAPI) UploadPart(input *s3.UploadPartInput) (*s3.UploadPartOutput, error) {
+	if a.errPart != nil {
+		return nil, a.errPart
+	}
+	data, _ := io.ReadAll(input.Body)
+	a.parts = append(a.parts, string(data))
+	return &s3.UploadPartOutput{ETag: aws.String("etag")}, nil
+}
+
+func (a *fakeObjectAPI) CompleteMultipartUpload(input *s3.CompleteMultipartUploadInput) (*s3.CompleteMultipartUploadOutput, error) {
+	if a.errComplete != nil {
+		return nil, a.errComplete
+	}
+	a.objects[aws.StringValue(input.Key)] = strings.Join(a.parts, "")
+	return &s3.CompleteMultipartUploadOutput{}, nil
+}
+
+func (a *fakeObjectAPI) AbortMultipartUpload(_ *s3.AbortMultipartUploadInput) (*s3.AbortMultipartUploadOutput, error) {
+	a.aborted = true
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func newTestObjectClient(api *fakeObjectAPI) *COSObjectClient {
+	if api.objects == nil {
+		api.objects = map[string]string{}
+	}
+	return &COSObjectClient{svc: api, logger: zap.NewNop()}
+}
+
+func TestListObjects(t *testing.T) {
+	client := newTestObjectClient(&fakeObjectAPI{objects: map[string]string{"a/file": "data", "b/file": "data"}})
+
+	listing, err := client.ListObjects("bucket", "a/", "/", "token", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []ObjectInfo{{Key: "a/file", Size: 4}}, listing.Objects)
+	assert.Equal(t, []string{"dir/"}, listing.CommonPrefixes)
+	assert.True(t, listing.IsTruncated)
+	assert.Equal(t, "next", listing.NextContinuationToken)
+
+	client = newTestObjectClient(&fakeObjectAPI{errList: errors.New("list failed")})
+	_, err = client.ListObjects("bucket", "a/", "/", "", 0)
+	assert.ErrorContains(t, err, "list failed")
+}
+
+func TestHeadObject(t *testing.T) {
+	client := newTestObjectClient(&fakeObjectAPI{objects: map[string]string{"file": "data"}})
+
+	info, err := client.HeadObject("bucket", "file")
+	assert.NoError(t, err)
+	assert.Equal(t, &ObjectInfo{Key: "file", Size: 4, ETag: "etag"}, info)
+
+	_, err = client.HeadObject("bucket", "missing")
+	assert.ErrorIs(t, err, ErrObjectNotFound)
+}
+
+func TestGetObjectRange(t *testing.T) {
+	testCases := []struct {
+		testCaseName  string
+		offset        int64
+		length        int64
+		expectedRange string
+	}{
+		{testCaseName: "Positive: Whole object", offset: 0, length: -1, expectedRange: ""},
+		{testCaseName: "Positive: Range", offset: 10, length: 5, expectedRange: "bytes=10-14"},
+		{testCaseName: "Positive: Till the end", offset: 10, length: -1, expectedRange: "bytes=10-"},
+	}
+
+	for _, tc := range testCases {
+		t.Log("Testcase being executed", tc.testCaseName)
+		api := &fakeObjectAPI{objects: map[string]string{"file": "data"}}
+		body, err := newTestObjectClient(api).GetObjectRange("bucket", "file", tc.offset, tc.length)
+		assert.NoError(t, err)
+		assert.NotNil(t, body)
+		assert.Equal(t, tc.expectedRange, api.getRange)
+	}
+
+	api := &fakeObjectAPI{errGet: awserr.New(s3.ErrCodeNoSuchKey, "no such key", nil)}
+	_, err := newTestObjectClient(api).GetObjectRange("bucket", "file", 0, -1)
+	assert.ErrorIs(t, err, ErrObjectNotFound)
+}
+
+func TestUploadObject(t *testing.T) {
+	data := "0123456789"
+
+	// single PUT
+	api := &fakeObjectAPI{}
+	assert.NoError(t, newTestObjectClient(api).UploadObject("bucket", "file", strings.NewReader(data), 10, 10))
+	assert.Equal(t, data, api.objects["file"])
+	assert.Empty(t, api.parts)
+
+	// multipart
+	api = &fakeObjectAPI{}
+	assert.NoError(t, newTestObjectClient(api).UploadObject("bucket", "file", strings.NewReader(data), 10, 4))
+	assert.Equal(t, []string{"0123", "4567", "89"}, api.parts)
+	assert.Equal(t, data, api.objects["file"])
+	assert.False(t, api.aborted)
+
+	// failed part aborts the upload
+	api = &fakeObjectAPI{errPart: errors.New("part failed")}
+	err := newTestObjectClient(api).UploadObject("bucket", "file", strings.NewReader(data), 10, 4)
+	assert.ErrorContains(t, err, "part failed")
+	assert.True(t, api.aborted)
+
+	api = &fakeObjectAPI{errComplete: errors.New("complete failed")}
+	err = newTestObjectClient(api).UploadObject("bucket", "file", strings.NewReader(data), 10, 4)
+	assert.ErrorContains(t, err, "complete failed")
+	assert.True(t, api.aborted)
+
+	api = &fakeObjectAPI{errPut: errors.New("put failed")}
+	err = newTestObjectClient(api).UploadObject("bucket", "file", strings.NewReader(data), 10, 0)
+	assert.ErrorContains(t, err, "put failed")
+}
+
+func TestDeleteObject(t *testing.T) {
+	api := &fakeObjectAPI{objects: map[string]string{"file": "data"}}
+	assert.NoError(t, newTestObjectClient(api).DeleteObject("bucket", "file"))
+	assert.NotContains(t, api.objects, "file")
+
+	api = &fakeObjectAPI{errDelete: awserr.New(s3.ErrCodeNoSuchKey, "no such key", nil)}
+	assert.NoError(t, newTestObjectClient(api).DeleteObject("bucket", "file"))
+
+	api = &fakeObjectAPI{errDelete: errors.New("delete failed")}
+	assert.ErrorContains(t, newTestObjectClient(api).DeleteObject("bucket", "file"), "delete failed")
+}
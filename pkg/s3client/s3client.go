@@ -236,6 +236,14 @@ func NewS3Client(lgr *zap.Logger) (ObjectStorageSession, error) {
 
 // NewObjectStorageSession method creates a new object store session
 func (s *COSSessionFactory) NewObjectStorageSession(endpoint, locationConstraint string, creds *ObjectStorageCredentials, lgr *zap.Logger) ObjectStorageSession {
+	return &COSSession{
+		svc:             newS3Service(endpoint, locationConstraint, creds),
+		logger:          lgr,
+		rcClientFactory: &defaultRCClientFactory{},
+	}
+}
+
+func newS3Service(endpoint, locationConstraint string, creds *ObjectStorageCredentials) *s3.S3 {
 	var sdkCreds *credentials.Credentials
 	if creds.AuthType == "iam" {
 		sdkCreds = ibmiam.NewStaticCredentials(aws.NewConfig(), creds.IAMEndpoint+"/identity/token", creds.APIKey, creds.ServiceInstanceID)
@@ -248,12 +256,7 @@ func (s *COSSessionFactory) NewObjectStorageSession(endpoint, locationConstraint
 		Credentials:      sdkCreds,
 		Region:           aws.String(locationConstraint),
 	}))
-
-	return &COSSession{
-		svc:             s3.New(sess),
-		logger:          lgr,
-		rcClientFactory: &defaultRCClientFactory{},
-	}
+	return s3.New(sess)
 }
 
 func (s *COSSession) UpdateQuotaLimit(quota int64, apiKey, bucketName, cosEndpoint, iamEndpoint string) error {
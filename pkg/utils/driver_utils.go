@@ -16,6 +16,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
@@ -35,8 +36,25 @@ type StatsUtils interface {
 	GetPVC(pvcName, pvcNamespace string) (*v1.PersistentVolumeClaim, error)
 	GetSecret(secretName, secretNamespace string) (*v1.Secret, error)
 	GetPV(volumeID string) (*v1.PersistentVolume, error)
+	RecordPodEvent(pod PodRef, source EventSource, reason, message string) error
 }
 
+// PodRef identifies the pod a volume is published to
+type PodRef struct {
+	Namespace string
+	Name      string
+	UID       string
+}
+
+// EventSource is the component and node recording an event
+type EventSource struct {
+	Component string
+	Host      string
+}
+
+// maxEventMessageLength bounds the message of the events recorded by the driver
+const maxEventMessageLength = 1024
+
 type DriverStatsUtils struct {
 }
 
@@ -241,6 +259,40 @@ func (su *DriverStatsUtils) GetPV(volumeID string) (*v1.PersistentVolume, error)
 	return pv, nil
 }
 
+// RecordPodEvent records a warning event with reason and message on pod
+func (su *DriverStatsUtils) RecordPodEvent(pod PodRef, source EventSource, reason, message string) error {
+	k8sClient, err := CreateK8sClient()
+	if err != nil {
+		return err
+	}
+
+	if len(message) > maxEventMessageLength {
+		message = message[:maxEventMessageLength-3] + "..."
+	}
+	now := metav1.Now()
+	event := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{GenerateName: pod.Name + ".", Namespace: pod.Namespace},
+		InvolvedObject: v1.ObjectReference{
+			Kind:       "Pod",
+			APIVersion: "v1",
+			Namespace:  pod.Namespace,
+			Name:       pod.Name,
+			UID:        types.UID(pod.UID),
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           v1.EventTypeWarning,
+		Source:         v1.EventSource{Component: source.Component, Host: source.Host},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	if _, err = k8sClient.CoreV1().Events(pod.Namespace).Create(context.Background(), event, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("error creating event: %v", err)
+	}
+	return nil
+}
+
 func ReplaceAndReturnCopy(req interface{}) (interface{}, error) {
 	switch r := req.(type) {
 	case *csi.CreateVolumeRequest:
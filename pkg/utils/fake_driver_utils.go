@@ -18,6 +18,7 @@ type FakeStatsUtilsFuncStruct struct {
 	GetPVCFn                 func(pvcName, pvcNamespace string) (*v1.PersistentVolumeClaim, error)
 	GetSecretFn              func(secretName, secretNamespace string) (*v1.Secret, error)
 	GetPVFn                  func(volumeID string) (*v1.PersistentVolume, error)
+	RecordPodEventFn         func(pod PodRef, source EventSource, reason, message string) error
 }
 
 type FakeStatsUtilsFuncStructImpl struct {
@@ -115,3 +116,10 @@ func (m *FakeStatsUtilsFuncStructImpl) GetPV(volumeID string) (*v1.PersistentVol
 	}
 	panic("requested method should not be nil")
 }
+
+func (m *FakeStatsUtilsFuncStructImpl) RecordPodEvent(pod PodRef, source EventSource, reason, message string) error {
+	if m.FuncStruct.RecordPodEventFn != nil {
+		return m.FuncStruct.RecordPodEventFn(pod, source, reason, message)
+	}
+	panic("requested method should not be nil")
+}
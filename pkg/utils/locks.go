@@ -0,0 +1,31 @@
+package utils
+
+import "sync"
+
+// KeyLocks are locks of string keys, e.g. the target paths with an operation in progress, that are taken without
+// waiting. The zero value has no key locked.
+type KeyLocks struct {
+	mu     sync.Mutex
+	locked map[string]struct{}
+}
+
+// TryAcquire locks key, it returns false if key is already locked
+func (l *KeyLocks) TryAcquire(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.locked[key]; ok {
+		return false
+	}
+	if l.locked == nil {
+		l.locked = map[string]struct{}{}
+	}
+	l.locked[key] = exists
+	return true
+}
+
+// Release unlocks key
+func (l *KeyLocks) Release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.locked, key)
+}
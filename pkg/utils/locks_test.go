@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyLocks(t *testing.T) {
+	var locks KeyLocks
+
+	assert.True(t, locks.TryAcquire("/a"))
+	assert.False(t, locks.TryAcquire("/a"))
+	assert.True(t, locks.TryAcquire("/b"))
+
+	locks.Release("/a")
+	assert.True(t, locks.TryAcquire("/a"))
+
+	// releasing a key that is not locked is harmless
+	locks.Release("/c")
+}
@@ -12,6 +12,7 @@
 package sanity
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -23,6 +24,7 @@ import (
 	"github.com/IBM/ibm-object-csi-driver/pkg/constants"
 	csiDriver "github.com/IBM/ibm-object-csi-driver/pkg/driver"
 	"github.com/IBM/ibm-object-csi-driver/pkg/mounter"
+	mounterUtils "github.com/IBM/ibm-object-csi-driver/pkg/mounter/utils"
 	"github.com/IBM/ibm-object-csi-driver/pkg/s3client"
 	"github.com/IBM/ibm-object-csi-driver/pkg/utils"
 	"github.com/google/uuid"
@@ -185,17 +187,17 @@ func FakeNewS3fsMounterFactory() *FakeS3fsMounterFactory {
 
 type Fakes3fsMounter struct{}
 
-func (s *FakeS3fsMounterFactory) NewMounter(params mounter.MounterParams) mounter.Mounter {
+func (s *FakeS3fsMounterFactory) NewMounter(params mounter.MounterParams) (mounter.Mounter, error) {
 	klog.Info("-New S3FS Fake Mounter-")
-	return &Fakes3fsMounter{}
+	return &Fakes3fsMounter{}, nil
 }
 
-func (s3fs *Fakes3fsMounter) Mount(source string, target string) error {
+func (s3fs *Fakes3fsMounter) Mount(_ context.Context, source string, target string) error {
 	klog.Info("-S3FSMounter Mount-")
 	return nil
 }
 
-func (s3fs *Fakes3fsMounter) Unmount(target string) error {
+func (s3fs *Fakes3fsMounter) Unmount(_ context.Context, target string) error {
 	klog.Info("-S3FSMounter Unmount-")
 	return nil
 }
@@ -227,10 +229,22 @@ func (su *FakeNewMounterOptsUtils) FuseUnmount(path string) error {
 	return nil
 }
 
+func (su *FakeNewMounterOptsUtils) FuseUnmountWithContext(ctx context.Context, path string, pid int) error {
+	return nil
+}
+
 func (m *FakeNewMounterOptsUtils) FuseMount(path string, comm string, args []string) error {
 	return nil
 }
 
+func (m *FakeNewMounterOptsUtils) FuseMountWithOptions(path string, comm string, args []string, opts mounterUtils.MountOptions) error {
+	return nil
+}
+
+func (m *FakeNewMounterOptsUtils) GetMountInfo(path string) (*mounterUtils.MountInfo, error) {
+	return nil, nil
+}
+
 // Fake DriverStatsUtils
 type FakeNewDriverStatsUtils struct {
 }
@@ -286,6 +300,10 @@ func (su *FakeNewDriverStatsUtils) GetPV(volumeID string) (*v1.PersistentVolume,
 	return &v1.PersistentVolume{}, nil
 }
 
+func (su *FakeNewDriverStatsUtils) RecordPodEvent(pod utils.PodRef, source utils.EventSource, reason, message string) error {
+	return nil
+}
+
 func createTargetDir(targetPath string) error {
 	fileInfo, err := os.Stat(targetPath)
 	if err != nil && os.IsNotExist(err) {